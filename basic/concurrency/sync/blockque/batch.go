@@ -0,0 +1,107 @@
+package blockque
+
+import (
+	"context"
+	"iter"
+)
+
+// 将一批元素加入队列
+//
+// `ctx` 参数用于控制入队操作的上下文, 该参数可以用于控制入队操作的截止时间等
+// `vals` 参数表示要加入队列的元素集合
+//
+// 和逐个调用 `Put` 方法不同, 该方法每次占用一批信号量值 (最多为队列长度), 并在一次加锁中将这批元素加入链表,
+// 从而减少信号量和互斥锁的操作次数
+//
+// 如果队列剩余空间不足, 则该方法会阻塞, 直到队列空出足够的空间; 如果在阻塞期间上下文结束或队列关闭,
+// 则返回已入队的元素数量及对应的错误
+func (bq *BlockQueue[T]) OfferAll(ctx context.Context, vals []T) (int, error) {
+	if bq.Closed() {
+		return 0, ErrQueueClosed
+	}
+
+	// 合并上下文, 令队列关闭时可以唤醒阻塞的入队操作
+	ctx, cancel := withDone(ctx, bq.done)
+	defer cancel()
+
+	n := 0
+	for n < len(vals) {
+		// 每批最多占用队列长度个信号量值, 否则信号量永远无法满足
+		batch := vals[n:min(len(vals), n+int(bq.size))]
+
+		// 一次性占用本批元素所需的信号量值
+		if err := bq.sem.Acquire(ctx, int64(len(batch))); err != nil {
+			if bq.Closed() {
+				return n, ErrQueueClosed
+			}
+			return n, err
+		}
+
+		// 锁定互斥量, 将本批元素加入链表的尾部
+		bq.mux.Lock()
+		for _, val := range batch {
+			bq.lst.PushBack(val)
+		}
+		bq.mux.Unlock()
+
+		// 一次性释放本批元素对应的可出队元素信号量值
+		bq.items.Release(int64(len(batch)))
+		bq.ready.Add(int64(len(batch)))
+
+		n += len(batch)
+	}
+	return n, nil
+}
+
+// 从队列头部取出最多 `max` 个元素, 追加到 `dst` 切片中并返回追加后的切片
+//
+// 该方法不会阻塞, 如果队列为空, 则直接返回 `dst` 切片
+//
+// 和逐个调用 `Poll` 方法不同, 该方法只进行一次加锁, 并一次性占用和释放所有取出元素对应的信号量值
+func (bq *BlockQueue[T]) DrainTo(dst []T, max int) []T {
+	if max <= 0 {
+		return dst
+	}
+
+	bq.mux.Lock()
+	defer bq.mux.Unlock()
+
+	// 按可出队元素的数量一次性占用信号量值
+	//
+	// 如果有其它出队操作已占用信号量值但尚未取出元素, 或入队操作已加入元素但尚未释放信号量值,
+	// 则可出队元素少于链表长度, 所以按 `ready` 字段记录的数量而不是链表长度占用
+	n := bq.tryAcquireItems(int64(max))
+	if n == 0 {
+		return dst
+	}
+
+	// 从链表头部取出元素
+	for range n {
+		dst = append(dst, bq.lst.Remove(bq.lst.Front()))
+	}
+
+	// 一次性释放取出元素对应的信号量值
+	bq.sem.Release(n)
+	return dst
+}
+
+// 返回一个消费队列元素的迭代器
+//
+// 迭代器每次通过 `Take` 方法从队列中取出一个元素, 队列为空时阻塞等待;
+// 当上下文结束, 或队列关闭且队列中剩余元素全部取出后, 迭代结束
+//
+// 例如:
+//
+//	for val := range que.Consume(ctx) {
+//		...
+//	}
+func (bq *BlockQueue[T]) Consume(ctx context.Context) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			val, err := bq.Take(ctx)
+			if err != nil || !yield(val) {
+				return
+			}
+		}
+	}
+}
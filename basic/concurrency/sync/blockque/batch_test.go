@@ -0,0 +1,164 @@
+package blockque_test
+
+import (
+	"context"
+	slices2 "study/basic/builtin/slices"
+	"study/basic/concurrency/sync/blockque"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试批量入队
+//
+// 入队元素数量超过队列长度时, 需等待队列中的元素出队后才能继续入队
+func TestBlockQueue_OfferAll(t *testing.T) {
+	que := blockque.New[int](10)
+
+	// 批量入队 5 个元素, 无需等待
+	n, err := que.OfferAll(context.Background(), []int{0, 1, 2, 3, 4})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, que.List())
+
+	// 启动 goroutine, 不断取出队列中的元素
+	rs := make(chan []int)
+	go func() {
+		vals := make([]int, 0, 25)
+		for len(vals) < 25 {
+			vals = que.DrainTo(vals, 3)
+			time.Sleep(time.Millisecond)
+		}
+		rs <- vals
+	}()
+
+	// 批量入队 20 个元素, 超过队列剩余空间, 需等待元素出队
+	n, err = que.OfferAll(context.Background(), slices2.Range(5, 25, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, 20, n)
+
+	// 确认所有元素按入队顺序出队
+	assert.Equal(t, slices2.Range(0, 25, 1), <-rs)
+	assert.True(t, que.Empty())
+
+	// 队列已满时, 批量入队超时, 返回已入队的元素数量
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	n, err = que.OfferAll(ctx, slices2.Range(0, 15, 1))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 10, n)
+}
+
+// 测试批量出队
+func TestBlockQueue_DrainTo(t *testing.T) {
+	que := blockque.New[int](10)
+	que.OfferAll(context.Background(), []int{0, 1, 2, 3, 4})
+
+	// 取出最多 3 个元素
+	vals := que.DrainTo(nil, 3)
+	assert.Equal(t, []int{0, 1, 2}, vals)
+
+	// 取出剩余元素, 追加到切片中
+	vals = que.DrainTo(vals, 10)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, vals)
+
+	// 队列为空, 不会取出任何元素
+	vals = que.DrainTo(vals, 10)
+	assert.Len(t, vals, 5)
+
+	// 取出元素后, 队列空间被释放
+	for i := range 10 {
+		assert.True(t, que.TryOffer(i))
+	}
+	assert.False(t, que.TryOffer(10))
+
+	// `max` 参数不为正数时不取出任何元素, 且不影响队列容量
+	que = blockque.New[int](2)
+	que.TryOffer(0)
+
+	assert.Empty(t, que.DrainTo(nil, 0))
+	assert.Empty(t, que.DrainTo(nil, -1))
+	assert.True(t, que.TryOffer(1))
+	assert.False(t, que.TryOffer(2))
+
+	// 队列中的元素均可出队时, 全部取出
+	que = blockque.New[int](5)
+	que.OfferAll(context.Background(), []int{0, 1, 2})
+	assert.Equal(t, []int{0, 1, 2}, que.DrainTo(nil, 10))
+}
+
+// 测试批量出队和逐个出队同时进行时, 每个元素恰好被取出一次
+func TestBlockQueue_DrainToConcurrent(t *testing.T) {
+	const total = 10000
+
+	que := blockque.New[int](64)
+	ctx := context.Background()
+
+	go func() {
+		for i := range total {
+			que.Put(ctx, i)
+		}
+	}()
+
+	var mux sync.Mutex
+	seen := make([]int, 0, total)
+	record := func(vals ...int) {
+		mux.Lock()
+		defer mux.Unlock()
+		seen = append(seen, vals...)
+	}
+
+	var wg sync.WaitGroup
+	var taken atomic.Int64
+	for range 2 {
+		wg.Go(func() {
+			for taken.Load() < total {
+				vals := que.DrainTo(nil, 16)
+				taken.Add(int64(len(vals)))
+				record(vals...)
+			}
+		})
+		wg.Go(func() {
+			for taken.Load() < total {
+				tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+				if val, err := que.Take(tctx); err == nil {
+					taken.Add(1)
+					record(val)
+				}
+				cancel()
+			}
+		})
+	}
+	wg.Wait()
+
+	assert.ElementsMatch(t, slices2.Range(0, total, 1), seen)
+	assert.True(t, que.Empty())
+}
+
+// 测试队列长度不为正数时引发 panic
+func TestBlockQueue_InvalidSize(t *testing.T) {
+	assert.PanicsWithValue(t, "blockque: invalid queue size 0", func() { blockque.New[int](0) })
+	assert.Panics(t, func() { blockque.New[int](-1) })
+}
+
+// 测试通过迭代器消费队列元素
+func TestBlockQueue_Consume(t *testing.T) {
+	que := blockque.New[int](10)
+
+	// 启动 goroutine, 入队元素后关闭队列
+	go func() {
+		que.OfferAll(context.Background(), slices2.Range(0, 20, 1))
+		que.Close()
+	}()
+
+	// 迭代队列元素, 直到队列关闭且元素全部取出
+	vals := make([]int, 0, 20)
+	for val := range que.Consume(context.Background()) {
+		vals = append(vals, val)
+	}
+	assert.Equal(t, slices2.Range(0, 20, 1), vals)
+}
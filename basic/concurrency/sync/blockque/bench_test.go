@@ -0,0 +1,85 @@
+package blockque_test
+
+import (
+	"context"
+	"study/basic/concurrency/sync/blockque"
+	"testing"
+)
+
+// 基准测试中队列的长度
+const benchQueueSize = 1024
+
+// 测试单个生产者和单个消费者通过队列交换数据的性能
+func benchmarkQueue(b *testing.B, que blockque.Queue[int]) {
+	ctx := context.Background()
+
+	go func() {
+		for i := range b.N {
+			que.Offer(ctx, i)
+		}
+	}()
+
+	for range b.N {
+		que.Take(ctx)
+	}
+}
+
+// 测试多个生产者和多个消费者通过队列交换数据的性能
+func benchmarkQueueParallel(b *testing.B, que blockque.Queue[int]) {
+	ctx := context.Background()
+
+	// 每个并行的 goroutine 同时作为生产者和消费者, 保证队列中的元素可以被全部消费
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			que.Offer(ctx, 1)
+			que.Take(ctx)
+		}
+	})
+}
+
+// 链表实现的阻塞队列
+func BenchmarkBlockQueue(b *testing.B) {
+	benchmarkQueue(b, blockque.New[int](benchQueueSize))
+}
+
+// 环形缓冲区实现的阻塞队列
+func BenchmarkRingBlockQueue(b *testing.B) {
+	benchmarkQueue(b, blockque.NewRing[int](benchQueueSize))
+}
+
+// 带缓冲的通道
+func BenchmarkChannel(b *testing.B) {
+	ch := make(chan int, benchQueueSize)
+
+	go func() {
+		for i := range b.N {
+			ch <- i
+		}
+	}()
+
+	for range b.N {
+		<-ch
+	}
+}
+
+// 链表实现的阻塞队列, 多生产者多消费者
+func BenchmarkBlockQueue_Parallel(b *testing.B) {
+	benchmarkQueueParallel(b, blockque.New[int](benchQueueSize))
+}
+
+// 环形缓冲区实现的阻塞队列, 多生产者多消费者
+func BenchmarkRingBlockQueue_Parallel(b *testing.B) {
+	benchmarkQueueParallel(b, blockque.NewRing[int](benchQueueSize))
+}
+
+// 带缓冲的通道, 多生产者多消费者
+func BenchmarkChannel_Parallel(b *testing.B) {
+	ch := make(chan int, benchQueueSize)
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			ch <- 1
+			<-ch
+		}
+	})
+}
@@ -1,13 +1,21 @@
 package blockque
 
 import (
-	"container/list"
 	"context"
+	"errors"
+	"fmt"
+	"study/basic/container/lists"
 	"sync"
+	"sync/atomic"
 
 	"golang.org/x/sync/semaphore"
 )
 
+var (
+	// 队列已关闭错误
+	ErrQueueClosed = errors.New("queue closed")
+)
+
 // 定义阻塞队列结构体
 //
 // 信号量的一个重要作用就是定义阻塞队列, 用于多个 goroutine 之间交换数据 (生产者消费者模式)
@@ -16,24 +24,68 @@ import (
 // 一个信号量值
 //
 // 当队列元素数量达到 N 时, 所有信号量值都被占用, 继续占用信号量会导致阻塞, 直到另一个并行程序出队了一个元素并释放信号量
+//
+// 同理, 出队操作也通过另一个信号量 (`items`) 进行阻塞, 该信号量的初始值为 0, 每入队一个元素释放一个信号量值,
+// 每出队一个元素占用一个信号量值, 当队列为空时, 占用信号量会导致阻塞, 直到另一个并行程序入队了一个元素
+//
+// 信号量无法查询剩余值, 所以额外通过 `ready` 字段记录可出队元素数量的下界: 出队操作在占用 `items` 信号量值之前先扣减,
+// 入队操作在释放 `items` 信号量值之后再增加, 所以按 `ready` 字段预留的数量一定可以从 `items` 信号量中一次性占用,
+// 用于 `DrainTo` 方法批量出队
 type BlockQueue[T any] struct {
-	lst *list.List         // 存储数据的单项链表
-	sem semaphore.Weighted // 用于控制队列长度的信号量对象
-	mux sync.RWMutex       // 保护链表的读写互斥锁
+	lst    *lists.List[T]     // 存储数据的链表
+	size   int64              // 队列的长度
+	sem    semaphore.Weighted // 用于控制队列长度的信号量对象
+	items  semaphore.Weighted // 用于表示队列中可出队元素数量的信号量对象
+	ready  atomic.Int64       // 可出队元素数量的下界, 即 `items` 信号量中未被预留的值
+	mux    sync.RWMutex       // 保护链表的读写互斥锁
+	done   context.Context    // 队列关闭时结束的上下文对象
+	cancel context.CancelFunc // 结束 `done` 上下文的函数
 }
 
 // 创建 BlockQueue 结构体实例
-// `size` 参数指定了队列的长度, 即队列中最多可以存储多少个元素
+// `size` 参数指定了队列的长度, 即队列中最多可以存储多少个元素, 必须为正数, 否则引发 panic
 func New[T any](size int64) *BlockQueue[T] {
-	// 创建一个 BlockQueue 结构体实例, 并返回该实例的指针
-	return &BlockQueue[T]{
-		lst: list.New(),
-		sem: *semaphore.NewWeighted(size),
+	if size <= 0 {
+		panic(fmt.Sprintf("blockque: invalid queue size %d", size))
+	}
+
+	// 创建一个 BlockQueue 结构体实例
+	bq := &BlockQueue[T]{
+		lst:   lists.New[T](),
+		size:  size,
+		sem:   *semaphore.NewWeighted(size),
+		items: *semaphore.NewWeighted(size),
 	}
+
+	// 队列初始为空, 所以占用 `items` 信号量的全部值, 令出队操作阻塞
+	bq.items.TryAcquire(size)
+
+	// 创建表示队列关闭的上下文对象
+	bq.done, bq.cancel = context.WithCancel(context.Background())
+
+	// 返回该实例的指针
+	return bq
+}
+
+// 关闭队列
+//
+// 队列关闭后, 所有阻塞在入队和出队操作上的 goroutine 都会被唤醒并得到 `ErrQueueClosed` 错误,
+// 此后的入队操作均会失败, 但队列中剩余的元素仍可以继续出队, 直到队列为空
+func (bq *BlockQueue[T]) Close() {
+	bq.cancel()
+}
+
+// 获取队列是否已经关闭
+func (bq *BlockQueue[T]) Closed() bool {
+	return bq.done.Err() != nil
 }
 
 // 获取队列中链表的长度
 func (bq *BlockQueue[T]) Len() int {
+	// 锁定互斥量, 在函数返回前解锁互斥量
+	bq.mux.RLock()
+	defer bq.mux.RUnlock()
+
 	return bq.lst.Len()
 }
 
@@ -45,18 +97,8 @@ func (bq *BlockQueue[T]) List() []T {
 	// 在函数返回前解锁互斥量
 	defer bq.mux.RUnlock()
 
-	// 获取队列中元素的数量, 并创建一个对应长度的切片用于存储这些元素
-	len := bq.Len()
-
-	s := make([]T, len)
-	elem := bq.lst.Front()
-
 	// 遍历链表, 将每个元素的值存储到切片中
-	for i := range len {
-		s[i] = elem.Value.(T)
-		elem = elem.Next()
-	}
-	return s
+	return bq.lst.Slice()
 }
 
 // 获取队列是否为空
@@ -70,20 +112,99 @@ func (bq *BlockQueue[T]) Empty() bool {
 // `val` 参数表示要加入队列的元素
 //
 // 如果队列已满, 则该方法会阻塞, 直到队列空出至少一个元素后方能入队成功
+//
+// 如果入队失败 (上下文结束或队列已关闭), 则返回 `false`
 func (bq *BlockQueue[T]) Offer(ctx context.Context, val T) bool {
+	return bq.Put(ctx, val) == nil
+}
+
+// 将元素加入队列, 并返回入队失败的原因
+//
+// `ctx` 参数用于控制入队操作的上下文, 该参数可以用于控制入队操作的截止时间等
+// `val` 参数表示要加入队列的元素
+//
+// 如果队列已满, 则该方法会阻塞, 直到队列空出至少一个元素后方能入队成功;
+// 如果在阻塞期间上下文结束, 则返回上下文的错误; 如果队列已关闭, 则返回 `ErrQueueClosed` 错误
+func (bq *BlockQueue[T]) Put(ctx context.Context, val T) error {
+	if bq.Closed() {
+		return ErrQueueClosed
+	}
+
+	// 合并上下文, 令队列关闭时可以唤醒阻塞的入队操作
+	ctx, cancel := withDone(ctx, bq.done)
+	defer cancel()
+
 	// 尝试占用一个信号量值, 如果队列已满, 则该方法会阻塞, 直到队列空出至少一个元素后方能入队成功
 	if err := bq.sem.Acquire(ctx, 1); err != nil {
-		return false
+		if bq.Closed() {
+			return ErrQueueClosed
+		}
+		return err
 	}
 
+	bq.push(val)
+	return nil
+}
+
+// 将元素加入链表尾部, 并释放一个可出队元素信号量值
+func (bq *BlockQueue[T]) push(val T) {
+	// 锁定互斥量, 将元素加入链表的尾部
+	bq.mux.Lock()
+	bq.lst.PushBack(val)
+	bq.mux.Unlock()
+
+	// 释放一个可出队元素信号量值, 唤醒阻塞的出队操作
+	bq.items.Release(1)
+	bq.ready.Add(1)
+}
+
+// 占用一个可出队元素信号量值, 如果队列为空, 则阻塞直到有元素入队或上下文结束
+func (bq *BlockQueue[T]) acquireItem(ctx context.Context) error {
+	// 先预留再占用, 保证 `ready` 字段不会多于信号量的剩余值
+	bq.ready.Add(-1)
+	if err := bq.items.Acquire(ctx, 1); err != nil {
+		bq.ready.Add(1)
+		return err
+	}
+	return nil
+}
+
+// 尝试一次性占用最多 `max` 个可出队元素信号量值, 返回实际占用的数量, 不会阻塞
+func (bq *BlockQueue[T]) tryAcquireItems(max int64) int64 {
+	var n int64
+	for {
+		r := bq.ready.Load()
+		if n = min(r, max); n <= 0 {
+			return 0
+		}
+		if bq.ready.CompareAndSwap(r, r-n) {
+			break
+		}
+	}
+
+	// 已预留的数量一定可以占用, 这里只是防御性的检查
+	if !bq.items.TryAcquire(n) {
+		bq.ready.Add(n)
+		return 0
+	}
+	return n
+}
+
+// 从链表头部删除一个元素, 并释放一个信号量值
+//
+// 调用该方法前, 需确保已经占用了一个可出队元素信号量值
+func (bq *BlockQueue[T]) pop() T {
 	// 锁定互斥量, 在函数返回前解锁互斥量
 	bq.mux.Lock()
 	defer bq.mux.Unlock()
 
-	// 将元素加入链表的尾部
-	bq.lst.PushBack(val)
+	// 获取并删除队列头部元素
+	val := bq.lst.Remove(bq.lst.Front())
 
-	return true
+	// 释放一个信号量值
+	bq.sem.Release(1)
+
+	return val
 }
 
 // 尝试将元素加入队列
@@ -92,20 +213,47 @@ func (bq *BlockQueue[T]) Offer(ctx context.Context, val T) bool {
 //
 // 如果队列已满, 则加入元素失败, 返回 `false`
 func (bq *BlockQueue[T]) TryOffer(val T) bool {
+	if bq.Closed() {
+		return false
+	}
+
 	// 尝试占用一个信号量值, 如果队列已满, 则该方法会返回 `false`
 	if ok := bq.sem.TryAcquire(1); !ok {
 		return false
 	}
 
-	// 锁定互斥量, 在函数返回前解锁互斥量
-	bq.mux.Lock()
-	defer bq.mux.Unlock()
-
-	// 将元素加入链表的尾部
-	bq.lst.PushBack(val)
+	bq.push(val)
 	return true
 }
 
+// 从队列的头部取出一个元素
+//
+// `ctx` 参数用于控制出队操作的上下文, 该参数可以用于控制出队操作的截止时间等
+//
+// 如果队列为空, 则该方法会阻塞, 直到队列中有元素入队或上下文结束;
+// 队列关闭后, 可继续取出队列中剩余的元素, 当队列为空时返回 `ErrQueueClosed` 错误
+func (bq *BlockQueue[T]) Take(ctx context.Context) (T, error) {
+	// 合并上下文, 令队列关闭时可以唤醒阻塞的出队操作
+	mctx, cancel := withDone(ctx, bq.done)
+	defer cancel()
+
+	// 尝试占用一个可出队元素信号量值, 如果队列为空, 则该方法会阻塞
+	if err := bq.acquireItem(mctx); err != nil {
+		var zero T
+
+		// 参数上下文结束, 返回其错误
+		if err := ctx.Err(); err != nil {
+			return zero, err
+		}
+
+		// 队列已关闭, 尝试取出队列中剩余的元素
+		if bq.tryAcquireItems(1) == 0 {
+			return zero, ErrQueueClosed
+		}
+	}
+	return bq.pop(), nil
+}
+
 // 从队列的头部弹出一个元素
 //
 // `defValue` 参数表示如果队列为空时返回的默认值
@@ -114,45 +262,26 @@ func (bq *BlockQueue[T]) TryOffer(val T) bool {
 //
 // 如果队列为空, 则返回 `defValue` 参数表示的默认值及 `false` 值
 func (bq *BlockQueue[T]) Poll(defVal T) (T, bool) {
-	// 锁定互斥量, 在函数返回前解锁互斥量
-	bq.mux.Lock()
-	defer bq.mux.Unlock()
-
-	// 获取队列头部元素
-	elem := bq.lst.Front()
-	if elem == nil {
+	// 尝试占用一个可出队元素信号量值, 如果队列为空, 则返回默认值
+	if bq.tryAcquireItems(1) == 0 {
 		return defVal, false
 	}
 
-	// 删除队列头部元素
-	bq.lst.Remove(elem)
-
-	// 释放一个信号量值
-	bq.sem.Release(1)
-
-	// 返回队列头部元素的值
-	return elem.Value.(T), true
+	// 删除并返回队列头部元素的值
+	return bq.pop(), true
 }
 
 // 从队列的头部删除一个元素
 //
 // 如果队列为空, 则返回 `false` 值
 func (bq *BlockQueue[T]) Remove() bool {
-	// 锁定互斥量, 在函数返回前解锁互斥量
-	bq.mux.Lock()
-	defer bq.mux.Unlock()
-
-	// 获取队列头部元素
-	elem := bq.lst.Front()
-	if elem == nil {
+	// 尝试占用一个可出队元素信号量值, 如果队列为空, 则返回 `false`
+	if bq.tryAcquireItems(1) == 0 {
 		return false
 	}
 
 	// 删除队列头部元素
-	bq.lst.Remove(elem)
-
-	// 释放一个信号量值
-	bq.sem.Release(1)
+	bq.pop()
 	return true
 }
 
@@ -173,5 +302,5 @@ func (bq *BlockQueue[T]) Peek(defVal T) (T, bool) {
 	}
 
 	// 返回队列头部元素的值
-	return elem.Value.(T), true
+	return elem.Value, true
 }
@@ -115,3 +115,85 @@ func TestBlockQueue_OfferWithTimeout(t *testing.T) {
 	assert.Less(t, time.Since(start), 10*time.Millisecond)
 	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, que.List())
 }
+
+// 测试队列阻塞出队
+func TestBlockQueue_Take(t *testing.T) {
+	que := blockque.New[int](10)
+
+	// 启动一个 goroutine, 在 100ms 后入队一个元素
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		que.Offer(context.Background(), 1)
+	}()
+
+	start := time.Now()
+
+	// 队列为空, 出队操作阻塞, 直到 100ms 后有元素入队
+	val, err := que.Take(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, val)
+	assertion.DurationMatch(t, 100*time.Millisecond, time.Since(start))
+
+	// 确认出队后队列为空
+	assert.True(t, que.Empty())
+
+	start = time.Now()
+
+	// 队列为空, 出队操作等待 100ms 后超时
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = que.Take(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assertion.DurationMatch(t, 100*time.Millisecond, time.Since(start))
+}
+
+// 测试关闭队列
+//
+// 关闭队列会唤醒所有阻塞的入队和出队操作, 队列中剩余的元素仍可继续出队
+func TestBlockQueue_Close(t *testing.T) {
+	que := blockque.New[int](2)
+
+	// 启动一个 goroutine, 在 100ms 后关闭队列
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		que.Close()
+	}()
+
+	// 队列为空, 出队操作阻塞, 直到队列关闭
+	_, err := que.Take(context.Background())
+	assert.ErrorIs(t, err, blockque.ErrQueueClosed)
+	assert.True(t, que.Closed())
+
+	// 队列关闭后, 入队操作失败
+	assert.ErrorIs(t, que.Put(context.Background(), 1), blockque.ErrQueueClosed)
+	assert.False(t, que.Offer(context.Background(), 1))
+	assert.False(t, que.TryOffer(1))
+
+	que = blockque.New[int](2)
+	que.Offer(context.Background(), 1)
+	que.Offer(context.Background(), 2)
+
+	// 启动一个 goroutine, 在 100ms 后关闭队列
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		que.Close()
+	}()
+
+	// 队列已满, 入队操作阻塞, 直到队列关闭
+	err = que.Put(context.Background(), 3)
+	assert.ErrorIs(t, err, blockque.ErrQueueClosed)
+
+	// 队列关闭后, 仍可取出队列中剩余的元素
+	val, err := que.Take(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, val)
+
+	val, ok := que.Poll(-1)
+	assert.True(t, ok)
+	assert.Equal(t, 2, val)
+
+	// 队列中的元素取完后, 出队操作返回错误
+	_, err = que.Take(context.Background())
+	assert.ErrorIs(t, err, blockque.ErrQueueClosed)
+}
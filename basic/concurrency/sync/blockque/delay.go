@@ -0,0 +1,27 @@
+package blockque
+
+import "time"
+
+// 定义延迟队列结构体
+//
+// 队列中的每个元素都具备一个到期时间, 元素只有在到期后才能出队, 元素按到期时间的先后顺序出队
+//
+// 延迟队列常用于定时任务调度, 例如: 将任务按执行时间入队, 由消费者通过 `Take` 方法阻塞等待,
+// 任务到期后消费者即可取出并执行, 无需额外维护堆和定时器循环
+type DelayQueue[T any] struct {
+	heapQueue[T]
+}
+
+// 创建 DelayQueue 结构体实例
+//
+// `size` 参数指定了队列的长度, 即队列中最多可以存储多少个元素
+// `deadline` 参数为获取元素到期时间的函数, 对同一个元素, 该函数应始终返回相同的结果
+func NewDelay[T any](size int64, deadline func(val T) time.Time) *DelayQueue[T] {
+	bq := &DelayQueue[T]{}
+	bq.init(
+		size,
+		func(a, b T) int { return deadline(a).Compare(deadline(b)) },
+		func(val T) time.Duration { return time.Until(deadline(val)) },
+	)
+	return bq
+}
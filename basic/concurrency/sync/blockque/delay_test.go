@@ -0,0 +1,74 @@
+package blockque_test
+
+import (
+	"context"
+	"study/basic/concurrency/sync/blockque"
+	"study/basic/testing/assertion"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 用于测试的定时任务结构体
+type Timed struct {
+	Name string
+	At   time.Time
+}
+
+// 测试延迟队列按到期时间顺序出队
+func TestDelayQueue_Take(t *testing.T) {
+	que := blockque.NewDelay(10, func(v Timed) time.Time { return v.At })
+
+	now := time.Now()
+
+	que.Offer(context.Background(), Timed{"B", now.Add(100 * time.Millisecond)})
+	que.Offer(context.Background(), Timed{"A", now.Add(50 * time.Millisecond)})
+
+	// 元素均未到期, 无法出队
+	_, ok := que.Poll(Timed{})
+	assert.False(t, ok)
+
+	// 查看队列头部元素, 即最早到期的元素
+	v, ok := que.Peek(Timed{})
+	assert.True(t, ok)
+	assert.Equal(t, "A", v.Name)
+
+	// 出队操作阻塞到第一个元素到期
+	v, err := que.Take(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "A", v.Name)
+	assertion.DurationMatch(t, 50*time.Millisecond, time.Since(now))
+
+	// 入队一个更早到期的元素, 等待中的出队操作会被唤醒并取出该元素
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		que.Offer(context.Background(), Timed{"C", time.Now()})
+	}()
+
+	v, err = que.Take(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "C", v.Name)
+
+	v, err = que.Take(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "B", v.Name)
+	assertion.DurationMatch(t, 100*time.Millisecond, time.Since(now))
+}
+
+// 测试延迟队列出队超时
+func TestDelayQueue_TakeWithTimeout(t *testing.T) {
+	que := blockque.NewDelay(10, func(v Timed) time.Time { return v.At })
+	que.Offer(context.Background(), Timed{"A", time.Now().Add(time.Second)})
+
+	start := time.Now()
+
+	// 元素在 1s 后才到期, 出队操作等待 100ms 后超时
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := que.Take(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assertion.DurationMatch(t, 100*time.Millisecond, time.Since(start))
+	assert.Equal(t, 1, que.Len())
+}
@@ -0,0 +1,308 @@
+package blockque
+
+import (
+	"container/heap"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// 堆中存储的元素
+type heapEntry[T any] struct {
+	val T      // 元素值
+	seq uint64 // 元素入队序号, 用于保证相同优先级的元素按入队顺序出队
+}
+
+// 实现 `heap.Interface` 接口的最小堆类型
+type entryHeap[T any] struct {
+	entries []heapEntry[T]   // 存储堆元素的切片
+	cmp     func(a, b T) int // 元素比较函数
+}
+
+// 比较两个堆元素, 优先级相同时比较入队序号
+func (h *entryHeap[T]) compare(a, b heapEntry[T]) int {
+	if c := h.cmp(a.val, b.val); c != 0 {
+		return c
+	}
+	if a.seq < b.seq {
+		return -1
+	}
+	if a.seq > b.seq {
+		return 1
+	}
+	return 0
+}
+
+func (h *entryHeap[T]) Len() int { return len(h.entries) }
+
+func (h *entryHeap[T]) Less(i, j int) bool {
+	return h.compare(h.entries[i], h.entries[j]) < 0
+}
+
+func (h *entryHeap[T]) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+}
+
+func (h *entryHeap[T]) Push(x any) {
+	h.entries = append(h.entries, x.(heapEntry[T]))
+}
+
+func (h *entryHeap[T]) Pop() any {
+	n := len(h.entries) - 1
+	e := h.entries[n]
+
+	// 清除切片中对元素的引用, 以便元素被垃圾回收
+	h.entries[n] = heapEntry[T]{}
+	h.entries = h.entries[:n]
+	return e
+}
+
+// 基于最小堆的阻塞队列
+//
+// 该类型为 `PriorityBlockQueue` 和 `DelayQueue` 的公共实现:
+//   - 通过信号量控制队列长度, 和 `BlockQueue` 一致;
+//   - 通过最小堆决定元素的出队顺序;
+//   - 通过 `delay` 函数决定堆顶元素还需等待多久才能出队;
+//
+// 由于堆顶元素可能随时变化 (新元素入队或元素到期), 所以出队操作无法通过信号量阻塞,
+// 而是通过 `signal` 通道进行通知: 每次入队时关闭当前通道并创建新通道, 唤醒所有等待中的出队操作重新检查堆顶元素
+type heapQueue[T any] struct {
+	heap   entryHeap[T]              // 存储元素的最小堆
+	seq    uint64                    // 下一个入队元素的序号
+	sem    semaphore.Weighted        // 用于控制队列长度的信号量对象
+	mux    sync.Mutex                // 保护堆的互斥锁
+	signal chan struct{}             // 通知出队操作堆已发生变化的通道
+	delay  func(val T) time.Duration // 获取元素还需等待多久才能出队的函数, 返回值 <= 0 表示可出队
+	done   context.Context           // 队列关闭时结束的上下文对象
+	cancel context.CancelFunc        // 结束 `done` 上下文的函数
+}
+
+// 初始化实例
+func (q *heapQueue[T]) init(size int64, cmp func(a, b T) int, delay func(val T) time.Duration) {
+	q.heap = entryHeap[T]{cmp: cmp}
+	q.sem = *semaphore.NewWeighted(size)
+	q.signal = make(chan struct{})
+	q.delay = delay
+	q.done, q.cancel = context.WithCancel(context.Background())
+}
+
+// 获取队列中元素的数量
+func (q *heapQueue[T]) Len() int {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	return q.heap.Len()
+}
+
+// 获取队列中现存全部元素的切片, 切片中的元素按出队顺序排列
+func (q *heapQueue[T]) List() []T {
+	q.mux.Lock()
+	entries := slices.Clone(q.heap.entries)
+	q.mux.Unlock()
+
+	// 按出队顺序对元素进行排序
+	slices.SortFunc(entries, q.heap.compare)
+
+	s := make([]T, len(entries))
+	for i, e := range entries {
+		s[i] = e.val
+	}
+	return s
+}
+
+// 获取队列是否为空
+func (q *heapQueue[T]) Empty() bool {
+	return q.Len() == 0
+}
+
+// 关闭队列
+//
+// 队列关闭后, 所有阻塞在入队和出队操作上的 goroutine 都会被唤醒, 此后的入队操作均会失败,
+// 但队列中剩余的元素仍可以继续出队
+func (q *heapQueue[T]) Close() {
+	q.cancel()
+
+	// 唤醒所有等待中的出队操作
+	q.mux.Lock()
+	q.notify()
+	q.mux.Unlock()
+}
+
+// 获取队列是否已经关闭
+func (q *heapQueue[T]) Closed() bool {
+	return q.done.Err() != nil
+}
+
+// 通知所有等待中的出队操作, 调用前需锁定互斥量
+func (q *heapQueue[T]) notify() {
+	close(q.signal)
+	q.signal = make(chan struct{})
+}
+
+// 将元素加入队列
+//
+// 如果队列已满, 则该方法会阻塞, 直到队列空出至少一个元素后方能入队成功
+func (q *heapQueue[T]) Offer(ctx context.Context, val T) bool {
+	return q.Put(ctx, val) == nil
+}
+
+// 将元素加入队列, 并返回入队失败的原因
+//
+// 如果队列已满, 则该方法会阻塞, 直到队列空出至少一个元素后方能入队成功;
+// 如果在阻塞期间上下文结束, 则返回上下文的错误; 如果队列已关闭, 则返回 `ErrQueueClosed` 错误
+func (q *heapQueue[T]) Put(ctx context.Context, val T) error {
+	if q.Closed() {
+		return ErrQueueClosed
+	}
+
+	// 合并上下文, 令队列关闭时可以唤醒阻塞的入队操作
+	ctx, cancel := withDone(ctx, q.done)
+	defer cancel()
+
+	// 尝试占用一个信号量值, 如果队列已满, 则该方法会阻塞
+	if err := q.sem.Acquire(ctx, 1); err != nil {
+		if q.Closed() {
+			return ErrQueueClosed
+		}
+		return err
+	}
+
+	q.push(val)
+	return nil
+}
+
+// 尝试将元素加入队列
+//
+// 如果队列已满或已关闭, 则加入元素失败, 返回 `false`
+func (q *heapQueue[T]) TryOffer(val T) bool {
+	if q.Closed() || !q.sem.TryAcquire(1) {
+		return false
+	}
+
+	q.push(val)
+	return true
+}
+
+// 将元素加入堆, 并通知等待中的出队操作
+func (q *heapQueue[T]) push(val T) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	heap.Push(&q.heap, heapEntry[T]{val: val, seq: q.seq})
+	q.seq++
+
+	q.notify()
+}
+
+// 尝试从堆中弹出堆顶元素, 调用前需锁定互斥量
+//
+// 如果堆为空, 则返回 `-1` 作为等待时长; 如果堆顶元素尚不能出队, 则返回其还需等待的时长
+func (q *heapQueue[T]) tryPop() (val T, wait time.Duration, ok bool) {
+	if q.heap.Len() == 0 {
+		return val, -1, false
+	}
+
+	// 判断堆顶元素是否可以出队
+	if q.delay != nil {
+		if wait = q.delay(q.heap.entries[0].val); wait > 0 {
+			return val, wait, false
+		}
+	}
+
+	// 弹出堆顶元素, 并释放一个信号量值
+	val = heap.Pop(&q.heap).(heapEntry[T]).val
+	q.sem.Release(1)
+
+	return val, 0, true
+}
+
+// 从队列中取出一个元素
+//
+// 如果队列中没有可出队的元素, 则该方法会阻塞, 直到有元素可出队或上下文结束;
+// 队列关闭后, 可继续取出队列中剩余的元素, 当队列为空时返回 `ErrQueueClosed` 错误
+func (q *heapQueue[T]) Take(ctx context.Context) (T, error) {
+	for {
+		q.mux.Lock()
+
+		val, wait, ok := q.tryPop()
+		if ok {
+			q.mux.Unlock()
+			return val, nil
+		}
+
+		// 队列已关闭且为空, 返回错误
+		if wait < 0 && q.Closed() {
+			q.mux.Unlock()
+			return val, ErrQueueClosed
+		}
+
+		signal := q.signal
+		q.mux.Unlock()
+
+		// 如果堆顶元素尚未到期, 则设置定时器, 在元素到期时重新检查
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+
+		// 等待上下文结束, 堆发生变化或元素到期
+		var err error
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-signal:
+		case <-timeout:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil {
+			return val, err
+		}
+	}
+}
+
+// 从队列中弹出一个元素
+//
+// 如果队列中没有可出队的元素, 则返回 `defVal` 参数表示的默认值及 `false` 值
+func (q *heapQueue[T]) Poll(defVal T) (T, bool) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	if val, _, ok := q.tryPop(); ok {
+		return val, true
+	}
+	return defVal, false
+}
+
+// 从队列中删除一个可出队的元素
+//
+// 如果队列中没有可出队的元素, 则返回 `false` 值
+func (q *heapQueue[T]) Remove() bool {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	_, _, ok := q.tryPop()
+	return ok
+}
+
+// 获取队列的头部元素, 但不从队列中删除该元素
+//
+// 对于 `DelayQueue`, 头部元素即最早到期的元素, 无论该元素是否已经到期
+//
+// 如果队列为空, 则返回 `defValue` 参数表示的默认值及 `false` 值
+func (q *heapQueue[T]) Peek(defVal T) (T, bool) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	if q.heap.Len() == 0 {
+		return defVal, false
+	}
+	return q.heap.entries[0].val, true
+}
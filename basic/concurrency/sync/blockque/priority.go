@@ -0,0 +1,67 @@
+package blockque
+
+import "container/heap"
+
+// 定义优先级阻塞队列结构体
+//
+// 和 `BlockQueue` 一致, 通过信号量限制队列的长度, 但元素不再按入队顺序出队,
+// 而是按照比较函数确定的优先级顺序出队 (比较结果较小的元素优先出队), 优先级相同的元素按入队顺序出队
+//
+// 该队列内部通过最小堆存储元素, 入队和出队的时间复杂度均为 `O(log n)`
+type PriorityBlockQueue[T any] struct {
+	heapQueue[T]
+}
+
+// 创建 PriorityBlockQueue 结构体实例
+//
+// `size` 参数指定了队列的长度, 即队列中最多可以存储多少个元素
+// `cmp` 参数为元素比较函数, 其返回值的含义和 `slices.SortFunc` 函数的比较函数一致
+func NewPriority[T any](size int64, cmp func(a, b T) int) *PriorityBlockQueue[T] {
+	bq := &PriorityBlockQueue[T]{}
+	bq.init(size, cmp, nil)
+	return bq
+}
+
+// 从队列中弹出最早入队的元素, 而不是优先级最高的元素
+//
+// 和 `Poll` 方法不同, 该方法需遍历整个堆, 时间复杂度为 `O(n)`, 可用于在队列已满时淘汰等待最久的元素;
+// 如果队列为空, 则返回 `defVal` 参数表示的默认值及 `false` 值
+func (bq *PriorityBlockQueue[T]) PollOldest(defVal T) (T, bool) {
+	bq.mux.Lock()
+	defer bq.mux.Unlock()
+
+	if bq.heap.Len() == 0 {
+		return defVal, false
+	}
+
+	// 查找入队序号最小的元素
+	oldest := 0
+	for i, e := range bq.heap.entries {
+		if e.seq < bq.heap.entries[oldest].seq {
+			oldest = i
+		}
+	}
+
+	// 从堆中删除该元素, 并释放一个信号量值
+	val := heap.Remove(&bq.heap, oldest).(heapEntry[T]).val
+	bq.sem.Release(1)
+
+	return val, true
+}
+
+// 从队列中删除第一个令 `match` 函数返回 `true` 的元素, 并释放其占用的队列空间
+//
+// 该方法需遍历整个堆, 时间复杂度为 `O(n)`, 可用于删除已取消的元素; 如果没有匹配的元素, 则返回 `false` 值
+func (bq *PriorityBlockQueue[T]) RemoveFunc(match func(T) bool) bool {
+	bq.mux.Lock()
+	defer bq.mux.Unlock()
+
+	for i, e := range bq.heap.entries {
+		if match(e.val) {
+			heap.Remove(&bq.heap, i)
+			bq.sem.Release(1)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package blockque_test
+
+import (
+	"cmp"
+	"context"
+	"study/basic/concurrency/sync/blockque"
+	"study/basic/testing/assertion"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 用于测试的任务结构体
+type Job struct {
+	Name     string
+	Priority int
+}
+
+// 测试优先级队列按优先级顺序出队
+func TestPriorityBlockQueue_Order(t *testing.T) {
+	que := blockque.NewPriority(10, func(a, b Job) int {
+		return cmp.Compare(a.Priority, b.Priority)
+	})
+
+	que.Offer(context.Background(), Job{"C", 3})
+	que.Offer(context.Background(), Job{"A", 1})
+	que.Offer(context.Background(), Job{"B1", 2})
+	que.Offer(context.Background(), Job{"B2", 2})
+
+	// 确认队列中的元素按出队顺序排列, 优先级相同的元素按入队顺序排列
+	assert.Equal(t, 4, que.Len())
+	assert.Equal(t, []Job{{"A", 1}, {"B1", 2}, {"B2", 2}, {"C", 3}}, que.List())
+
+	// 查看队列头部元素
+	job, ok := que.Peek(Job{})
+	assert.True(t, ok)
+	assert.Equal(t, "A", job.Name)
+
+	// 按优先级顺序出队
+	names := make([]string, 0, 4)
+	for {
+		job, ok := que.Poll(Job{})
+		if !ok {
+			break
+		}
+		names = append(names, job.Name)
+	}
+	assert.Equal(t, []string{"A", "B1", "B2", "C"}, names)
+	assert.True(t, que.Empty())
+}
+
+// 测试优先级队列的阻塞入队和出队
+func TestPriorityBlockQueue_Block(t *testing.T) {
+	que := blockque.NewPriority(2, cmp.Compare[int])
+
+	// 队列已满, 再次入队失败
+	assert.True(t, que.TryOffer(2))
+	assert.True(t, que.TryOffer(1))
+	assert.False(t, que.TryOffer(0))
+
+	// 启动一个 goroutine, 在 100ms 后出队一个元素
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		val, err := que.Take(context.Background())
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, val)
+	}()
+
+	start := time.Now()
+
+	// 入队一个新元素, 总体耗时 100ms 以上 (包括等待队列出队)
+	assert.Nil(t, que.Put(context.Background(), 0))
+	assertion.DurationMatch(t, 100*time.Millisecond, time.Since(start))
+	assert.Equal(t, []int{0, 2}, que.List())
+
+	// 取出队列中全部元素
+	que.Remove()
+	que.Remove()
+
+	// 启动一个 goroutine, 在 100ms 后关闭队列
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		que.Close()
+	}()
+
+	// 队列为空, 出队操作阻塞, 直到队列关闭
+	_, err := que.Take(context.Background())
+	assert.ErrorIs(t, err, blockque.ErrQueueClosed)
+	assert.False(t, que.TryOffer(0))
+}
+
+// 测试从优先级队列中弹出最早入队的元素
+func TestPriorityBlockQueue_PollOldest(t *testing.T) {
+	que := blockque.NewPriority(3, func(a, b Job) int {
+		return cmp.Compare(a.Priority, b.Priority)
+	})
+
+	que.Offer(context.Background(), Job{"C", 3})
+	que.Offer(context.Background(), Job{"A", 1})
+	que.Offer(context.Background(), Job{"B", 2})
+	assert.False(t, que.TryOffer(Job{"D", 0}))
+
+	// 弹出最早入队的元素, 而不是优先级最高的元素
+	job, ok := que.PollOldest(Job{})
+	assert.True(t, ok)
+	assert.Equal(t, "C", job.Name)
+
+	// 弹出元素后可再次入队, 剩余元素仍按优先级顺序排列
+	assert.True(t, que.TryOffer(Job{"D", 0}))
+	assert.Equal(t, []Job{{"D", 0}, {"A", 1}, {"B", 2}}, que.List())
+
+	for _, name := range []string{"A", "B", "D"} {
+		job, ok := que.PollOldest(Job{})
+		assert.True(t, ok)
+		assert.Equal(t, name, job.Name)
+	}
+
+	_, ok = que.PollOldest(Job{})
+	assert.False(t, ok)
+}
+
+// 测试从优先级队列中删除指定的元素
+func TestPriorityBlockQueue_RemoveFunc(t *testing.T) {
+	que := blockque.NewPriority(3, cmp.Compare[int])
+
+	que.TryOffer(3)
+	que.TryOffer(1)
+	que.TryOffer(2)
+	assert.False(t, que.TryOffer(4))
+
+	// 删除元素后, 释放其占用的队列空间
+	assert.True(t, que.RemoveFunc(func(v int) bool { return v == 1 }))
+	assert.False(t, que.RemoveFunc(func(v int) bool { return v == 1 }))
+	assert.True(t, que.TryOffer(4))
+
+	assert.Equal(t, []int{2, 3, 4}, que.List())
+}
@@ -0,0 +1,70 @@
+package blockque
+
+import "context"
+
+// 定义阻塞队列接口
+//
+// `BlockQueue`, `RingBlockQueue`, `PriorityBlockQueue` 以及 `DelayQueue` 均实现了该接口, 区别仅在于元素的出队顺序:
+//   - `BlockQueue` 和 `RingBlockQueue` 按元素入队顺序 (先进先出) 出队;
+//   - `PriorityBlockQueue` 按元素的优先级顺序出队;
+//   - `DelayQueue` 按元素的到期时间顺序出队, 且元素只有在到期后才能出队;
+type Queue[T any] interface {
+	// 获取队列中元素的数量
+	Len() int
+
+	// 获取队列中现存全部元素的切片, 切片中元素的顺序即元素的出队顺序
+	List() []T
+
+	// 获取队列是否为空
+	Empty() bool
+
+	// 将元素加入队列, 如果队列已满则阻塞, 入队失败返回 `false`
+	Offer(ctx context.Context, val T) bool
+
+	// 将元素加入队列, 如果队列已满则阻塞, 并返回入队失败的原因
+	Put(ctx context.Context, val T) error
+
+	// 尝试将元素加入队列, 如果队列已满则返回 `false`
+	TryOffer(val T) bool
+
+	// 从队列中取出一个元素, 如果队列中没有可出队的元素则阻塞
+	Take(ctx context.Context) (T, error)
+
+	// 从队列中弹出一个元素, 如果队列中没有可出队的元素则返回 `defVal` 参数值及 `false`
+	Poll(defVal T) (T, bool)
+
+	// 获取队列的头部元素, 但不从队列中删除该元素
+	Peek(defVal T) (T, bool)
+
+	// 从队列的头部删除一个元素
+	Remove() bool
+
+	// 关闭队列
+	Close()
+
+	// 获取队列是否已经关闭
+	Closed() bool
+}
+
+// 确认各队列类型实现了 `Queue` 接口
+var (
+	_ Queue[int] = (*BlockQueue[int])(nil)
+	_ Queue[int] = (*PriorityBlockQueue[int])(nil)
+	_ Queue[int] = (*DelayQueue[int])(nil)
+	_ Queue[int] = (*RingBlockQueue[int])(nil)
+)
+
+// 将参数上下文和队列关闭上下文进行合并
+//
+// 返回的上下文会在参数上下文结束或队列关闭 (`done` 上下文结束) 时结束
+func withDone(ctx context.Context, done context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	// 在队列关闭时, 结束合并后的上下文
+	stop := context.AfterFunc(done, cancel)
+
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
@@ -0,0 +1,386 @@
+package blockque
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// 存储单元序号的最高位, 表示 `Peek` 或 `List` 方法正在读取该存储单元中的元素
+const ringReading = 1 << 63
+
+// 环形缓冲区中的存储单元
+//
+// `seq` 字段表示存储单元的状态, 设存储单元对应的位置为 `pos`, 则:
+//   - `seq == 2*pos` 表示存储单元为空, 可以写入位置为 `pos` 的元素;
+//   - `seq == 2*pos+1` 表示存储单元已写入位置为 `pos` 的元素, 可以读取;
+//   - 元素读取后, `seq` 被设置为 `2*(pos+cap)`, 即下一轮写入的位置;
+//
+// 序号取位置的 2 倍, 是为了在缓冲区长度为 1 时, 区分 "已写入" 和 "下一轮可写入" 两种状态
+//
+// 入队和出队对 `val` 字段的读写已由序号保证先后顺序, 无需加锁; `Peek` 和 `List` 方法读取元素前,
+// 通过 CAS 操作在序号上设置 `ringReading` 标记, 读取后清除, 已占用该位置的出队操作需等待标记清除后再读取元素
+// (Go 的内存模型不允许像 seqlock 那样不加同步的读取 `val` 字段再校验序号, 否则构成数据竞争)
+type ringCell[T any] struct {
+	seq atomic.Uint64 // 存储单元序号
+	val T             // 存储的元素
+}
+
+// 定义基于环形缓冲区的阻塞队列结构体
+//
+// 和 `BlockQueue` 不同, 该队列预先分配固定长度的环形缓冲区, 入队和出队时无需分配内存,
+// 且多个生产者和消费者之间通过 CAS 操作竞争缓冲区的读写位置 (`tail` 和 `head`), 入队和出队操作互不阻塞 (MPMC 队列)
+//
+// 当队列已满或为空时, 入队或出队操作通过 `notFull` 和 `notEmpty` 通道阻塞等待,
+// 只有存在等待者时, 另一方才会向通道发送通知, 所以不存在等待者时不会产生额外的开销
+//
+// 注意: `Peek` 和 `List` 方法逐个读取存储单元中未出队的元素, 不会阻止入队和出队操作,
+// 所以在并发入队和出队的情况下, 其结果只是尽力而为的近似值 (例如 `List` 方法的结果可能不是某一时刻队列的完整快照)
+type RingBlockQueue[T any] struct {
+	cells    []ringCell[T] // 环形缓冲区
+	cap      uint64        // 缓冲区长度
+	head     atomic.Uint64 // 下一个出队位置
+	tail     atomic.Uint64 // 下一个入队位置
+	putWait  atomic.Int64  // 等待入队的 goroutine 数量
+	takeWait atomic.Int64  // 等待出队的 goroutine 数量
+	notFull  chan struct{} // 通知等待入队的 goroutine 队列已有空间
+	notEmpty chan struct{} // 通知等待出队的 goroutine 队列已有元素
+	done     chan struct{} // 队列关闭时关闭的通道
+	once     sync.Once     // 保证队列只关闭一次
+}
+
+// 创建 RingBlockQueue 结构体实例
+//
+// `size` 参数指定了队列的长度, 即队列中最多可以存储多少个元素, 必须为正数, 否则引发 panic
+func NewRing[T any](size int64) *RingBlockQueue[T] {
+	if size <= 0 {
+		panic(fmt.Sprintf("blockque: invalid ring size %d", size))
+	}
+
+	bq := &RingBlockQueue[T]{
+		cells:    make([]ringCell[T], size),
+		cap:      uint64(size),
+		notFull:  make(chan struct{}, 1),
+		notEmpty: make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+
+	// 初始化每个存储单元的序号为其位置
+	for i := range bq.cells {
+		bq.cells[i].seq.Store(uint64(2 * i))
+	}
+	return bq
+}
+
+// 获取队列中元素的数量
+//
+// 在并发入队和出队的情况下, 返回值为某一时刻的近似值
+func (bq *RingBlockQueue[T]) Len() int {
+	head := bq.head.Load()
+	tail := bq.tail.Load()
+
+	// 由于 `head` 和 `tail` 分两次读取, 所以需要将结果限制在合理范围内
+	if tail <= head {
+		return 0
+	}
+	return int(min(tail-head, bq.cap))
+}
+
+// 获取队列是否为空
+func (bq *RingBlockQueue[T]) Empty() bool {
+	return bq.Len() == 0
+}
+
+// 获取队列中现存全部元素的切片
+//
+// 在并发入队和出队的情况下, 遍历期间出队的元素会被跳过, 遍历开始后入队的元素不会被包含
+func (bq *RingBlockQueue[T]) List() []T {
+	head := bq.head.Load()
+	tail := bq.tail.Load()
+	if tail <= head {
+		return nil
+	}
+
+	s := make([]T, 0, min(tail-head, bq.cap))
+	for pos := head; pos < tail; pos++ {
+		if val, ok := bq.load(pos); ok {
+			s = append(s, val)
+		}
+	}
+	return s
+}
+
+// 读取位置为 `pos` 的元素, 如果该元素尚未写入或已经出队, 则返回 `false`
+//
+// 读取期间存储单元被标记为正在读取, 元素不会被出队操作清除
+func (bq *RingBlockQueue[T]) load(pos uint64) (val T, ok bool) {
+	cell := &bq.cells[pos%bq.cap]
+
+	for {
+		seq := cell.seq.Load()
+		if seq == (2*pos+1)|ringReading {
+			// 其它 goroutine 正在读取该元素, 等待其读取完毕
+			runtime.Gosched()
+			continue
+		}
+		if seq != 2*pos+1 {
+			return val, false
+		}
+		if cell.seq.CompareAndSwap(seq, seq|ringReading) {
+			break
+		}
+	}
+
+	val = cell.val
+	cell.seq.Store(2*pos + 1)
+	return val, true
+}
+
+// 关闭队列
+//
+// 队列关闭后, 所有阻塞在入队和出队操作上的 goroutine 都会被唤醒, 此后的入队操作均会失败,
+// 但队列中剩余的元素仍可以继续出队, 直到队列为空
+func (bq *RingBlockQueue[T]) Close() {
+	bq.once.Do(func() { close(bq.done) })
+}
+
+// 获取队列是否已经关闭
+func (bq *RingBlockQueue[T]) Closed() bool {
+	select {
+	case <-bq.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// 向通道发送通知, 如果通道中已有通知, 则忽略本次通知
+func wakeup(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// 尝试将元素写入环形缓冲区, 如果缓冲区已满, 则返回 `false`
+func (bq *RingBlockQueue[T]) enqueue(val T) bool {
+	pos := bq.tail.Load()
+	for {
+		cell := &bq.cells[pos%bq.cap]
+		seq := cell.seq.Load() &^ ringReading
+
+		switch {
+		case seq == 2*pos:
+			// 存储单元为空, 尝试占用该位置
+			if bq.tail.CompareAndSwap(pos, pos+1) {
+				cell.val = val
+				cell.seq.Store(2*pos + 1)
+				return true
+			}
+			pos = bq.tail.Load()
+		case seq < 2*pos:
+			// 存储单元中上一轮的元素尚未出队, 缓冲区已满
+			return false
+		default:
+			// 该位置已被其它生产者占用, 重新读取入队位置
+			pos = bq.tail.Load()
+		}
+	}
+}
+
+// 尝试从环形缓冲区读取元素, 如果缓冲区为空, 则返回 `false`
+func (bq *RingBlockQueue[T]) dequeue() (val T, ok bool) {
+	pos := bq.head.Load()
+	for {
+		cell := &bq.cells[pos%bq.cap]
+		seq := cell.seq.Load() &^ ringReading
+
+		switch {
+		case seq == 2*pos+1:
+			// 存储单元已写入元素, 尝试占用该位置
+			if bq.head.CompareAndSwap(pos, pos+1) {
+				var zero T
+
+				// 等待 `Peek` 或 `List` 方法读取完毕, 只有和这两个方法并发时才会等待
+				for cell.seq.Load() != 2*pos+1 {
+					runtime.Gosched()
+				}
+
+				val = cell.val
+				cell.val = zero
+				cell.seq.Store(2 * (pos + bq.cap))
+				return val, true
+			}
+			pos = bq.head.Load()
+		case seq < 2*pos+1:
+			// 存储单元尚未写入元素, 缓冲区为空
+			return val, false
+		default:
+			// 该位置已被其它消费者占用, 重新读取出队位置
+			pos = bq.head.Load()
+		}
+	}
+}
+
+// 元素入队成功后, 通知等待的 goroutine
+func (bq *RingBlockQueue[T]) afterEnqueue() {
+	if bq.takeWait.Load() > 0 {
+		wakeup(bq.notEmpty)
+	}
+	// 队列仍有空间, 将通知传递给其它等待入队的 goroutine
+	if bq.putWait.Load() > 0 && bq.Len() < int(bq.cap) {
+		wakeup(bq.notFull)
+	}
+}
+
+// 元素出队成功后, 通知等待的 goroutine
+func (bq *RingBlockQueue[T]) afterDequeue() {
+	if bq.putWait.Load() > 0 {
+		wakeup(bq.notFull)
+	}
+	// 队列仍有元素, 将通知传递给其它等待出队的 goroutine
+	if bq.takeWait.Load() > 0 && bq.Len() > 0 {
+		wakeup(bq.notEmpty)
+	}
+}
+
+// 将元素加入队列
+//
+// 如果队列已满, 则该方法会阻塞, 直到队列空出至少一个元素后方能入队成功
+//
+// 如果入队失败 (上下文结束或队列已关闭), 则返回 `false`
+func (bq *RingBlockQueue[T]) Offer(ctx context.Context, val T) bool {
+	return bq.Put(ctx, val) == nil
+}
+
+// 将元素加入队列, 并返回入队失败的原因
+//
+// 如果队列已满, 则该方法会阻塞, 直到队列空出至少一个元素后方能入队成功;
+// 如果在阻塞期间上下文结束, 则返回上下文的错误; 如果队列已关闭, 则返回 `ErrQueueClosed` 错误
+func (bq *RingBlockQueue[T]) Put(ctx context.Context, val T) error {
+	for {
+		if bq.TryOffer(val) {
+			return nil
+		}
+		if bq.Closed() {
+			return ErrQueueClosed
+		}
+
+		// 登记为等待者后再次尝试入队, 避免在登记前错过出队通知
+		bq.putWait.Add(1)
+		if bq.TryOffer(val) {
+			bq.putWait.Add(-1)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			bq.putWait.Add(-1)
+			return ctx.Err()
+		case <-bq.done:
+			bq.putWait.Add(-1)
+			return ErrQueueClosed
+		case <-bq.notFull:
+			bq.putWait.Add(-1)
+		}
+	}
+}
+
+// 尝试将元素加入队列
+//
+// 如果队列已满或已关闭, 则加入元素失败, 返回 `false`
+func (bq *RingBlockQueue[T]) TryOffer(val T) bool {
+	if bq.Closed() || !bq.enqueue(val) {
+		return false
+	}
+
+	bq.afterEnqueue()
+	return true
+}
+
+// 从队列的头部取出一个元素
+//
+// 如果队列为空, 则该方法会阻塞, 直到队列中有元素入队或上下文结束;
+// 队列关闭后, 可继续取出队列中剩余的元素, 当队列为空时返回 `ErrQueueClosed` 错误
+func (bq *RingBlockQueue[T]) Take(ctx context.Context) (T, error) {
+	var zero T
+
+	for {
+		if val, ok := bq.Poll(zero); ok {
+			return val, nil
+		}
+		if bq.Closed() {
+			// 队列关闭前可能刚有元素入队, 再次尝试出队
+			if val, ok := bq.Poll(zero); ok {
+				return val, nil
+			}
+			return zero, ErrQueueClosed
+		}
+
+		// 登记为等待者后再次尝试出队, 避免在登记前错过入队通知
+		bq.takeWait.Add(1)
+		if val, ok := bq.Poll(zero); ok {
+			bq.takeWait.Add(-1)
+			return val, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			bq.takeWait.Add(-1)
+			return zero, ctx.Err()
+		case <-bq.done:
+		case <-bq.notEmpty:
+		}
+		bq.takeWait.Add(-1)
+	}
+}
+
+// 从队列的头部弹出一个元素
+//
+// 如果队列为空, 则返回 `defValue` 参数表示的默认值及 `false` 值
+func (bq *RingBlockQueue[T]) Poll(defVal T) (T, bool) {
+	val, ok := bq.dequeue()
+	if !ok {
+		return defVal, false
+	}
+
+	bq.afterDequeue()
+	return val, true
+}
+
+// 从队列的头部删除一个元素
+//
+// 如果队列为空, 则返回 `false` 值
+func (bq *RingBlockQueue[T]) Remove() bool {
+	if _, ok := bq.dequeue(); !ok {
+		return false
+	}
+
+	bq.afterDequeue()
+	return true
+}
+
+// 获取队列的头部元素, 但不从队列中删除该元素
+//
+// 如果队列为空, 则返回 `defValue` 参数表示的默认值及 `false` 值
+//
+// 在并发出队的情况下, 返回的元素可能在返回时已经出队
+func (bq *RingBlockQueue[T]) Peek(defVal T) (T, bool) {
+	for {
+		head := bq.head.Load()
+		if head >= bq.tail.Load() {
+			return defVal, false
+		}
+		if val, ok := bq.load(head); ok {
+			return val, true
+		}
+
+		// 头部元素已经出队, 或已占用位置但尚未写入, 重新读取出队位置
+		if bq.head.Load() == head {
+			// 头部位置未变化, 说明元素尚未写入完成, 视为队列为空
+			return defVal, false
+		}
+	}
+}
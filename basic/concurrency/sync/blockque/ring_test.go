@@ -0,0 +1,173 @@
+package blockque_test
+
+import (
+	"context"
+	slices2 "study/basic/builtin/slices"
+	"study/basic/concurrency/sync/blockque"
+	"study/basic/testing/assertion"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试环形缓冲区队列的入队和出队
+func TestRingBlockQueue_OfferAndPoll(t *testing.T) {
+	que := blockque.NewRing[int](10)
+
+	// 前 10 个元素入队不会失败
+	for i := range 10 {
+		assert.True(t, que.TryOffer(i))
+	}
+	assert.Equal(t, 10, que.Len())
+	assert.Equal(t, slices2.Range(0, 10, 1), que.List())
+
+	// 队列已满, 入队失败
+	assert.False(t, que.TryOffer(10))
+
+	// 查看并弹出队列头部元素
+	val, ok := que.Peek(-1)
+	assert.True(t, ok)
+	assert.Equal(t, 0, val)
+
+	val, ok = que.Poll(-1)
+	assert.True(t, ok)
+	assert.Equal(t, 0, val)
+
+	// 出队后, 入队位置回绕到缓冲区开头
+	assert.True(t, que.TryOffer(10))
+	assert.Equal(t, slices2.Range(1, 11, 1), que.List())
+
+	// 取出全部元素
+	for range 10 {
+		assert.True(t, que.Remove())
+	}
+	assert.True(t, que.Empty())
+
+	_, ok = que.Poll(-1)
+	assert.False(t, ok)
+}
+
+// 测试环形缓冲区队列的阻塞入队和出队
+func TestRingBlockQueue_Block(t *testing.T) {
+	que := blockque.NewRing[int](1)
+
+	// 启动一个 goroutine, 在 100ms 后入队一个元素
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		que.Offer(context.Background(), 1)
+	}()
+
+	start := time.Now()
+
+	// 队列为空, 出队操作阻塞, 直到 100ms 后有元素入队
+	val, err := que.Take(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, val)
+	assertion.DurationMatch(t, 100*time.Millisecond, time.Since(start))
+
+	que.TryOffer(2)
+
+	// 队列已满, 入队操作等待 100ms 后超时
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start = time.Now()
+	assert.ErrorIs(t, que.Put(ctx, 3), context.DeadlineExceeded)
+	assertion.DurationMatch(t, 100*time.Millisecond, time.Since(start))
+
+	// 关闭队列后, 入队失败, 剩余元素仍可出队
+	que.Close()
+	assert.ErrorIs(t, que.Put(context.Background(), 3), blockque.ErrQueueClosed)
+
+	val, err = que.Take(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, val)
+
+	_, err = que.Take(context.Background())
+	assert.ErrorIs(t, err, blockque.ErrQueueClosed)
+}
+
+// 测试多个生产者和消费者并发操作环形缓冲区队列
+func TestRingBlockQueue_Concurrent(t *testing.T) {
+	que := blockque.NewRing[int](8)
+
+	var wg sync.WaitGroup
+
+	// 启动 4 个生产者, 每个生产者入队 1000 个元素
+	for p := range 4 {
+		wg.Go(func() {
+			for i := range 1000 {
+				assert.True(t, que.Offer(context.Background(), p*1000+i))
+			}
+		})
+	}
+
+	// 启动 4 个消费者, 消费队列中的元素, 直到队列关闭
+	rs := make(chan []int, 4)
+	for range 4 {
+		go func() {
+			vals := make([]int, 0, 1000)
+			for {
+				val, err := que.Take(context.Background())
+				if err != nil {
+					break
+				}
+				vals = append(vals, val)
+			}
+			rs <- vals
+		}()
+	}
+
+	// 等待生产者结束后关闭队列
+	wg.Wait()
+	que.Close()
+
+	vals := make([]int, 0, 4000)
+	for range 4 {
+		vals = append(vals, <-rs...)
+	}
+
+	// 确认所有元素均被消费且只消费一次
+	assert.ElementsMatch(t, slices2.Range(0, 4000, 1), vals)
+}
+
+// 测试并发入队和出队时调用 `Peek` 和 `List` 方法, 不会产生数据竞争, 且结果中的元素均为入队的元素
+func TestRingBlockQueue_ConcurrentView(t *testing.T) {
+	que := blockque.NewRing[int](8)
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for i := range 2000 {
+			que.Offer(context.Background(), i+1)
+		}
+		que.Close()
+	})
+	wg.Go(func() {
+		for {
+			if _, err := que.Take(context.Background()); err != nil {
+				return
+			}
+		}
+	})
+
+	for !que.Closed() || !que.Empty() {
+		if val, ok := que.Peek(0); ok {
+			assert.Positive(t, val)
+		}
+
+		vals := que.List()
+		assert.LessOrEqual(t, len(vals), 8)
+		for _, val := range vals {
+			assert.Positive(t, val)
+		}
+	}
+	wg.Wait()
+}
+
+// 测试队列长度必须为正数
+func TestRingBlockQueue_InvalidSize(t *testing.T) {
+	assert.Panics(t, func() { blockque.NewRing[int](0) })
+	assert.Panics(t, func() { blockque.NewRing[int](-1) })
+}
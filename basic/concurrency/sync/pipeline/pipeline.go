@@ -0,0 +1,162 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 阶段之间缓冲区的默认长度
+const DEFAULT_BUFFER = 16
+
+// 流水线类型
+//
+// 流水线由若干阶段 (Stage) 组成, 阶段之间通过有界的阻塞队列 (`Stream`) 连接, 上游阶段将元素放入队列,
+// 下游阶段从队列中取出元素进行处理; 队列已满时上游阶段阻塞, 从而实现背压
+//
+// 任意阶段返回错误后, 流水线的上下文即被取消, 所有阶段随之结束, 该错误通过 `Wait` 方法返回
+type Pipeline struct {
+	ctx    context.Context         // 流水线上下文, 流水线出错时被取消
+	cancel context.CancelCauseFunc // 取消流水线上下文的函数
+	wg     sync.WaitGroup          // 等待所有阶段结束的等待组
+	mux    sync.Mutex              // 保护阶段列表
+	stages []*stage                // 流水线中的阶段列表, 按创建顺序排列
+}
+
+// 创建流水线实例
+//
+// `ctx` 参数结束时, 流水线的所有阶段随之结束
+func New(ctx context.Context) *Pipeline {
+	p := &Pipeline{}
+	p.ctx, p.cancel = context.WithCancelCause(ctx)
+	return p
+}
+
+// 获取流水线上下文
+func (p *Pipeline) Context() context.Context { return p.ctx }
+
+// 令流水线出错, 取消流水线上下文, 只有第一个错误会被记录
+func (p *Pipeline) fail(err error) {
+	p.cancel(err)
+}
+
+// 在流水线中创建一个阶段, 并在新的 goroutine 中执行阶段函数
+//
+// 阶段函数返回的错误会令流水线出错
+func (p *Pipeline) spawn(name string, fn func(st *stage) error) *stage {
+	st := &stage{p: p, name: name}
+
+	p.mux.Lock()
+	p.stages = append(p.stages, st)
+	p.mux.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		st.start.Store(time.Now().UnixNano())
+		defer func() { st.end.Store(time.Now().UnixNano()) }()
+
+		if err := fn(st); err != nil {
+			st.fail(err)
+		}
+	}()
+	return st
+}
+
+// 等待流水线的所有阶段结束
+//
+// 如果流水线出错或参数上下文结束, 则返回对应的错误
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+
+	err := context.Cause(p.ctx)
+	p.cancel(nil)
+	return err
+}
+
+// 获取流水线中各个阶段的统计信息, 按阶段创建顺序排列
+func (p *Pipeline) Stats() []Stats {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	stats := make([]Stats, 0, len(p.stages))
+	for _, st := range p.stages {
+		stats = append(stats, st.stats())
+	}
+	return stats
+}
+
+// 阶段执行出错时产生的错误
+type StageError struct {
+	Stage string // 出错的阶段名称
+	Err   error  // 阶段返回的错误
+}
+
+// 获取错误信息
+func (e *StageError) Error() string {
+	return "stage " + e.Stage + ": " + e.Err.Error()
+}
+
+// 获取阶段返回的错误
+func (e *StageError) Unwrap() error { return e.Err }
+
+// 流水线阶段类型, 记录阶段的统计信息
+type stage struct {
+	p      *Pipeline    // 阶段所属的流水线
+	name   string       // 阶段名称
+	in     atomic.Int64 // 进入阶段的元素数量
+	out    atomic.Int64 // 离开阶段的元素数量
+	errors atomic.Int64 // 阶段处理元素时出错的次数
+	start  atomic.Int64 // 阶段开始执行的时间 (纳秒时间戳)
+	end    atomic.Int64 // 阶段结束执行的时间 (纳秒时间戳), 为 `0` 表示阶段尚未结束
+}
+
+// 记录阶段出错, 并令流水线出错
+//
+// 流水线已经结束后产生的错误 (通常是由于流水线结束而产生的上下文错误) 会被忽略
+func (st *stage) fail(err error) {
+	if st.p.ctx.Err() != nil {
+		return
+	}
+
+	st.errors.Add(1)
+	st.p.fail(&StageError{Stage: st.name, Err: err})
+}
+
+// 获取阶段的统计信息
+func (st *stage) stats() Stats {
+	s := Stats{
+		Name:   st.name,
+		In:     st.in.Load(),
+		Out:    st.out.Load(),
+		Errors: st.errors.Load(),
+	}
+
+	if start := st.start.Load(); start != 0 {
+		end := st.end.Load()
+		if end == 0 {
+			end = time.Now().UnixNano()
+		}
+		s.Elapsed = time.Duration(end - start)
+	}
+	return s
+}
+
+// 阶段统计信息
+type Stats struct {
+	Name    string        // 阶段名称
+	In      int64         // 进入阶段的元素数量
+	Out     int64         // 离开阶段的元素数量
+	Errors  int64         // 阶段处理元素时出错的次数
+	Elapsed time.Duration // 阶段执行的时长, 阶段尚未结束时为截至目前的时长
+}
+
+// 获取阶段的吞吐量, 即每秒离开阶段的元素数量
+func (s Stats) Throughput() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Out) / s.Elapsed.Seconds()
+}
@@ -0,0 +1,230 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	slices2 "study/basic/builtin/slices"
+	"study/basic/concurrency/sync/pipeline"
+	pool "study/basic/concurrency/sync/pools/worker_pool"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试流水线无序并发处理元素
+func TestPipeline_Map(t *testing.T) {
+	p := pipeline.New(context.Background())
+
+	src := pipeline.FromSlice(p, "source", slices2.Range(1, 101, 1))
+	doubled := pipeline.Map(src, "double", func(ctx context.Context, n int) (int, error) {
+		return n * 2, nil
+	}, pipeline.WithWorkers(4))
+	strs := pipeline.Map(doubled, "itoa", func(ctx context.Context, n int) (string, error) {
+		return strconv.Itoa(n), nil
+	})
+
+	rs, err := pipeline.Collect(strs)
+	assert.NoError(t, err)
+
+	expected := make([]string, 0, 100)
+	for _, n := range slices2.Range(2, 201, 2) {
+		expected = append(expected, strconv.Itoa(n))
+	}
+	assert.ElementsMatch(t, expected, rs)
+
+	// 查看各阶段的统计信息
+	stats := p.Stats()
+	assert.Len(t, stats, 3)
+
+	assert.Equal(t, "source", stats[0].Name)
+	assert.Equal(t, int64(0), stats[0].In)
+	assert.Equal(t, int64(100), stats[0].Out)
+
+	for _, s := range stats[1:] {
+		assert.Equal(t, int64(100), s.In)
+		assert.Equal(t, int64(100), s.Out)
+		assert.Equal(t, int64(0), s.Errors)
+		assert.Positive(t, s.Elapsed)
+		assert.Positive(t, s.Throughput())
+	}
+}
+
+// 测试流水线并发处理元素, 并保持元素的输入顺序
+func TestPipeline_MapOrdered(t *testing.T) {
+	p := pipeline.New(context.Background())
+
+	src := pipeline.FromSlice(p, "source", slices2.Range(0, 50, 1))
+	out := pipeline.Map(src, "sleep", func(ctx context.Context, n int) (int, error) {
+		// 处理时长随机, 后输入的元素可能先处理完毕
+		time.Sleep(time.Duration(rand.IntN(5)) * time.Millisecond)
+		return n, nil
+	}, pipeline.WithWorkers(8), pipeline.WithOrdered())
+
+	rs, err := pipeline.Collect(out)
+	assert.NoError(t, err)
+	assert.Equal(t, slices2.Range(0, 50, 1), rs)
+}
+
+// 测试某个阶段出错后, 整个流水线被取消
+func TestPipeline_Error(t *testing.T) {
+	errBad := errors.New("bad number")
+
+	for _, ordered := range []bool{false, true} {
+		p := pipeline.New(context.Background())
+
+		// 源阶段不断产生元素, 直到流水线结束
+		src := pipeline.Generate(p, "source", func(ctx context.Context, emit func(int) bool) error {
+			for n := 0; emit(n); n++ {
+			}
+			return nil
+		})
+
+		opts := []pipeline.Option{pipeline.WithWorkers(4)}
+		if ordered {
+			opts = append(opts, pipeline.WithOrdered())
+		}
+
+		out := pipeline.Map(src, "check", func(ctx context.Context, n int) (int, error) {
+			if n == 50 {
+				return 0, errBad
+			}
+			return n, nil
+		}, opts...)
+
+		_, err := pipeline.Collect(out)
+		assert.ErrorIs(t, err, errBad)
+
+		var se *pipeline.StageError
+		assert.ErrorAs(t, err, &se)
+		assert.Equal(t, "check", se.Stage)
+
+		assert.Equal(t, int64(1), p.Stats()[1].Errors)
+	}
+}
+
+// 测试处理函数发生 panic, 流水线出错
+func TestPipeline_Panic(t *testing.T) {
+	p := pipeline.New(context.Background())
+
+	src := pipeline.FromSlice(p, "source", []int{1, 2, 0, 4})
+	out := pipeline.Map(src, "div", func(ctx context.Context, n int) (int, error) {
+		return 100 / n, nil
+	})
+
+	_, err := pipeline.Collect(out)
+
+	var pe *pool.PanicError
+	assert.ErrorAs(t, err, &pe)
+}
+
+// 测试流水线的上下文被取消
+func TestPipeline_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := pipeline.New(ctx)
+
+	src := pipeline.Generate(p, "source", func(ctx context.Context, emit func(int) bool) error {
+		for n := 0; emit(n); n++ {
+			time.Sleep(time.Millisecond)
+		}
+		return nil
+	})
+
+	var count atomic.Int32
+	pipeline.ForEach(src, "count", func(ctx context.Context, n int) error {
+		count.Add(1)
+		return nil
+	})
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	err := p.Wait()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Positive(t, count.Load())
+}
+
+// 测试扇出和扇入
+func TestPipeline_FanOutFanIn(t *testing.T) {
+	p := pipeline.New(context.Background())
+
+	src := pipeline.FromSlice(p, "source", slices2.Range(0, 100, 1))
+
+	// 分发到 3 个数据流, 每个数据流单独处理后再合并
+	outs := pipeline.FanOut(src, "fan-out", 3)
+	for i, out := range outs {
+		outs[i] = pipeline.Map(out, "square-"+strconv.Itoa(i), func(ctx context.Context, n int) (int, error) {
+			return n * n, nil
+		})
+	}
+	merged := pipeline.Merge("fan-in", outs)
+
+	rs, err := pipeline.Collect(merged)
+	assert.NoError(t, err)
+
+	expected := make([]int, 0, 100)
+	for n := range 100 {
+		expected = append(expected, n*n)
+	}
+	assert.ElementsMatch(t, expected, rs)
+
+	// 扇出阶段和扇入阶段的元素数量
+	stats := p.Stats()
+	assert.Equal(t, "fan-out", stats[1].Name)
+	assert.Equal(t, int64(100), stats[1].Out)
+	assert.Equal(t, "fan-in", stats[5].Name)
+	assert.Equal(t, int64(100), stats[5].In)
+}
+
+// 测试扇出阶段和扇入阶段的参数校验
+func TestPipeline_FanOutFanInInvalid(t *testing.T) {
+	p := pipeline.New(context.Background())
+	src := pipeline.FromSlice(p, "source", []int{1, 2, 3})
+
+	assert.PanicsWithValue(t, "pipeline: invalid fan out count 0", func() {
+		pipeline.FanOut(src, "fan-out", 0)
+	})
+	assert.PanicsWithValue(t, "pipeline: merge requires at least one input stream", func() {
+		pipeline.Merge[int]("fan-in", nil)
+	})
+
+	// 参数校验失败不影响流水线
+	rs, err := pipeline.Collect(src)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, rs)
+}
+
+// 测试基于通道的处理阶段
+func TestPipeline_Pipe(t *testing.T) {
+	p := pipeline.New(context.Background())
+
+	ch := make(chan int)
+	go func() {
+		defer close(ch)
+		for n := range 20 {
+			ch <- n
+		}
+	}()
+
+	src := pipeline.FromChan(p, "source", ch)
+
+	// 只保留偶数
+	evens := pipeline.Pipe(src, "even", func(ctx context.Context, in <-chan int, out chan<- int) error {
+		for n := range in {
+			if n%2 == 0 {
+				out <- n
+			}
+		}
+		return nil
+	})
+
+	rs, err := pipeline.Collect(evens)
+	assert.NoError(t, err)
+	assert.Equal(t, slices2.Range(0, 20, 2), rs)
+
+	stats := p.Stats()
+	assert.Equal(t, int64(20), stats[1].In)
+	assert.Equal(t, int64(10), stats[1].Out)
+}
@@ -0,0 +1,366 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	pool "study/basic/concurrency/sync/pools/worker_pool"
+	"sync"
+)
+
+// 阶段的可选参数
+type options struct {
+	workers int  // 并发处理元素的 worker 数量
+	buffer  int  // 阶段输出数据流的缓冲区长度
+	ordered bool // 并发处理元素时, 是否保持元素的输入顺序
+}
+
+// 用于设置阶段可选参数的回调类型
+type Option = func(*options)
+
+// 设置并发处理元素的 worker 数量 (扇出), 默认为 `1`
+func WithWorkers(n int) Option {
+	return func(o *options) {
+		o.workers = n
+	}
+}
+
+// 设置阶段输出数据流的缓冲区长度, 默认为 `DEFAULT_BUFFER`
+func WithBuffer(n int) Option {
+	return func(o *options) {
+		o.buffer = n
+	}
+}
+
+// 设置并发处理元素时保持元素的输入顺序
+//
+// 默认情况下, 先处理完毕的元素先输出; 设置该参数后, 元素按输入顺序输出, 处理较慢的元素会阻塞其后元素的输出
+func WithOrdered() Option {
+	return func(o *options) {
+		o.ordered = true
+	}
+}
+
+// 根据可选参数回调创建可选参数实例
+func newOptions(opts []Option) options {
+	o := options{
+		workers: 1,
+		buffer:  DEFAULT_BUFFER,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	o.workers = max(o.workers, 1)
+	return o
+}
+
+// 创建源阶段, 通过 `gen` 函数产生元素
+//
+// `gen` 函数通过 `emit` 参数输出元素, 当 `emit` 返回 `false` 时, 表示流水线已结束, `gen` 函数应立即返回;
+// `gen` 函数返回后, 输出数据流即被关闭, 其返回的错误会令流水线出错
+func Generate[T any](p *Pipeline, name string, gen func(ctx context.Context, emit func(T) bool) error, opts ...Option) *Stream[T] {
+	o := newOptions(opts)
+	out := newStream[T](p, o.buffer)
+
+	p.spawn(name, func(st *stage) error {
+		defer out.close()
+
+		return gen(p.ctx, func(val T) bool {
+			if !out.put(val) {
+				return false
+			}
+			st.out.Add(1)
+			return true
+		})
+	})
+	return out
+}
+
+// 创建源阶段, 依次输出切片中的元素
+func FromSlice[T any](p *Pipeline, name string, vals []T, opts ...Option) *Stream[T] {
+	return Generate(p, name, func(ctx context.Context, emit func(T) bool) error {
+		for _, val := range vals {
+			if !emit(val) {
+				break
+			}
+		}
+		return nil
+	}, opts...)
+}
+
+// 创建源阶段, 输出通道中的元素, 直到通道关闭
+func FromChan[T any](p *Pipeline, name string, ch <-chan T, opts ...Option) *Stream[T] {
+	return Generate(p, name, func(ctx context.Context, emit func(T) bool) error {
+		for {
+			select {
+			case val, ok := <-ch:
+				if !ok || !emit(val) {
+					return nil
+				}
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}, opts...)
+}
+
+// 创建处理阶段, 通过 `fn` 函数将输入数据流中的每个元素转换为输出数据流中的元素
+//
+// 阶段内部通过 `worker_pool.TaskPool` 执行 `fn` 函数, 可通过 `WithWorkers` 参数设置并发数量,
+// 通过 `WithOrdered` 参数设置是否保持元素的输入顺序; `fn` 函数返回错误或发生 panic 时, 流水线出错
+func Map[T, R any](in *Stream[T], name string, fn func(ctx context.Context, val T) (R, error), opts ...Option) *Stream[R] {
+	o := newOptions(opts)
+	out := newStream[R](in.p, o.buffer)
+
+	in.p.spawn(name, func(st *stage) error {
+		defer out.close()
+
+		process(st, in, o, fn, out.put)
+		return nil
+	})
+	return out
+}
+
+// 创建终止阶段, 通过 `fn` 函数处理输入数据流中的每个元素
+//
+// 和 `Map` 函数一致, 可通过 `WithWorkers` 以及 `WithOrdered` 参数设置并发方式
+func ForEach[T any](in *Stream[T], name string, fn func(ctx context.Context, val T) error, opts ...Option) {
+	o := newOptions(opts)
+
+	in.p.spawn(name, func(st *stage) error {
+		process(st, in, o, func(ctx context.Context, val T) (struct{}, error) {
+			return struct{}{}, fn(ctx, val)
+		}, func(struct{}) bool { return true })
+		return nil
+	})
+}
+
+// 通过任务池处理输入数据流中的元素, 并通过 `emit` 函数输出处理结果
+func process[T, R any](st *stage, in *Stream[T], o options, fn pool.ContextHandler[T, R], emit func(R) bool) {
+	// 输出处理结果
+	output := func(r R) {
+		if emit(r) {
+			st.out.Add(1)
+		}
+	}
+
+	if o.ordered {
+		processOrdered(st, in, o, fn, output)
+		return
+	}
+
+	// 任务执行完毕后直接输出结果, 任务出错时 (包括 panic) 通过事件令流水线出错
+	tp := pool.NewTaskPool(
+		o.workers,
+		pool.WithHandler(func(ctx context.Context, val T) (R, error) {
+			r, err := fn(ctx, val)
+			if err == nil {
+				output(r)
+			}
+			return r, err
+		}),
+		pool.WithHook[T, R](func(e pool.Event) {
+			if e.Kind == pool.EVENT_FAILED {
+				st.fail(e.Err)
+			}
+		}),
+	)
+	defer tp.CloseAndWait()
+
+	for {
+		val, ok := in.take()
+		if !ok {
+			return
+		}
+
+		st.in.Add(1)
+		tp.Submit(st.p.ctx, val)
+	}
+}
+
+// 通过任务池处理输入数据流中的元素, 并按元素的输入顺序输出处理结果
+func processOrdered[T, R any](st *stage, in *Stream[T], o options, fn pool.ContextHandler[T, R], output func(R)) {
+	tp := pool.NewTaskPool(o.workers, pool.WithHandler(fn))
+	defer tp.CloseAndWait()
+
+	// 按输入顺序存储任务结果的通道, 其长度限制了等待输出的结果数量
+	futures := make(chan *pool.Future[R], o.workers)
+
+	// 在新的 goroutine 中提交任务
+	go func() {
+		defer close(futures)
+
+		for {
+			val, ok := in.take()
+			if !ok {
+				return
+			}
+
+			st.in.Add(1)
+			futures <- tp.Submit(st.p.ctx, val)
+		}
+	}()
+
+	// 按顺序等待任务结果并输出, 任务的上下文即流水线上下文, 所以流水线结束后任务也会立即结束
+	for f := range futures {
+		r, err := f.Await(context.Background())
+		if err != nil {
+			st.fail(err)
+			continue
+		}
+		output(r)
+	}
+}
+
+// 创建处理阶段, 通过通道处理元素
+//
+// 用于包装基于通道编写的处理函数, `fn` 函数从 `in` 通道中读取元素, 并将结果写入 `out` 通道,
+// 输入数据流结束后 `in` 通道会被关闭; `fn` 函数返回后, 输出数据流即被关闭, 所以 `fn` 函数不能关闭 `out` 通道
+func Pipe[T, R any](in *Stream[T], name string, fn func(ctx context.Context, in <-chan T, out chan<- R) error, opts ...Option) *Stream[R] {
+	o := newOptions(opts)
+	out := newStream[R](in.p, o.buffer)
+
+	in.p.spawn(name, func(st *stage) error {
+		defer out.close()
+
+		inCh, outCh := make(chan T), make(chan R)
+		done, drained := make(chan struct{}), make(chan struct{})
+
+		// 将输入数据流中的元素写入 `in` 通道, `fn` 函数返回后停止写入
+		go func() {
+			defer close(inCh)
+
+			for {
+				val, ok := in.take()
+				if !ok {
+					return
+				}
+
+				st.in.Add(1)
+				select {
+				case inCh <- val:
+				case <-done:
+					return
+				}
+			}
+		}()
+
+		// 将 `out` 通道中的元素写入输出数据流
+		go func() {
+			defer close(drained)
+
+			for r := range outCh {
+				if out.put(r) {
+					st.out.Add(1)
+				}
+			}
+		}()
+
+		err := fn(in.p.ctx, inCh, outCh)
+
+		close(done)
+		close(outCh)
+		<-drained
+		return err
+	})
+	return out
+}
+
+// 创建扇入阶段, 将多个数据流中的元素合并到一个数据流中
+//
+// 元素的输出顺序不确定, 所有输入数据流均结束后, 输出数据流才会关闭; `ins` 参数为空时 panic
+func Merge[T any](name string, ins []*Stream[T], opts ...Option) *Stream[T] {
+	if len(ins) == 0 {
+		panic("pipeline: merge requires at least one input stream")
+	}
+
+	p := ins[0].p
+
+	o := newOptions(opts)
+	out := newStream[T](p, o.buffer)
+
+	p.spawn(name, func(st *stage) error {
+		defer out.close()
+
+		var wg sync.WaitGroup
+		for _, in := range ins {
+			wg.Go(func() {
+				for {
+					val, ok := in.take()
+					if !ok {
+						return
+					}
+
+					st.in.Add(1)
+					if out.put(val) {
+						st.out.Add(1)
+					}
+				}
+			})
+		}
+
+		wg.Wait()
+		return nil
+	})
+	return out
+}
+
+// 创建扇出阶段, 将一个数据流中的元素分发到 `n` 个数据流中
+//
+// 每个元素只会被分发到一个输出数据流中, 空闲的输出数据流优先获得元素, 输入数据流结束后, 所有输出数据流均会关闭;
+// `n` 参数不为正数时 panic (否则输入数据流中的元素无法被消费, 导致上游阶段阻塞)
+func FanOut[T any](in *Stream[T], name string, n int, opts ...Option) []*Stream[T] {
+	if n <= 0 {
+		panic(fmt.Sprintf("pipeline: invalid fan out count %d", n))
+	}
+
+	o := newOptions(opts)
+
+	outs := make([]*Stream[T], 0, n)
+	for range n {
+		outs = append(outs, newStream[T](in.p, o.buffer))
+	}
+
+	in.p.spawn(name, func(st *stage) error {
+		var wg sync.WaitGroup
+		for _, out := range outs {
+			wg.Go(func() {
+				defer out.close()
+
+				for {
+					val, ok := in.take()
+					if !ok {
+						return
+					}
+
+					st.in.Add(1)
+					if out.put(val) {
+						st.out.Add(1)
+					}
+				}
+			})
+		}
+
+		wg.Wait()
+		return nil
+	})
+
+	// 返回切片的副本, 防止调用方修改切片影响阶段的执行
+	return slices.Clone(outs)
+}
+
+// 读取数据流中的全部元素, 并等待流水线结束
+//
+// 返回读取到的元素以及流水线的错误, 流水线出错时, 返回的元素可能不完整
+func Collect[T any](s *Stream[T]) ([]T, error) {
+	vals := make([]T, 0)
+	for {
+		val, ok := s.take()
+		if !ok {
+			break
+		}
+		vals = append(vals, val)
+	}
+	return vals, s.p.Wait()
+}
@@ -0,0 +1,40 @@
+package pipeline
+
+import (
+	"study/basic/concurrency/sync/blockque"
+)
+
+// 连接流水线阶段的有界数据流
+//
+// 数据流内部通过 `blockque.BlockQueue` 缓存元素, 上游阶段结束后关闭数据流, 下游阶段取完剩余元素后结束
+type Stream[T any] struct {
+	p     *Pipeline               // 数据流所属的流水线
+	queue *blockque.BlockQueue[T] // 缓存元素的阻塞队列
+}
+
+// 创建数据流实例
+func newStream[T any](p *Pipeline, buffer int) *Stream[T] {
+	return &Stream[T]{
+		p:     p,
+		queue: blockque.New[T](int64(max(buffer, 1))),
+	}
+}
+
+// 获取数据流所属的流水线
+func (s *Stream[T]) Pipeline() *Pipeline { return s.p }
+
+// 将元素放入数据流, 如果流水线已结束则返回 `false`
+func (s *Stream[T]) put(val T) bool {
+	return s.queue.Put(s.p.ctx, val) == nil
+}
+
+// 从数据流中取出元素, 如果数据流已关闭且为空, 或流水线已结束, 则返回 `false`
+func (s *Stream[T]) take() (T, bool) {
+	val, err := s.queue.Take(s.p.ctx)
+	return val, err == nil
+}
+
+// 关闭数据流, 表示上游阶段不会再放入元素
+func (s *Stream[T]) close() {
+	s.queue.Close()
+}
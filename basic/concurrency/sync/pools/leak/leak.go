@@ -0,0 +1,226 @@
+package leak
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"study/basic/runtime/callerstate"
+	"sync/atomic"
+	"time"
+)
+
+// 定义泄漏类型
+type Kind int
+
+const (
+	KIND_COLLECTED      Kind = iota // 借出的元素未释放即被垃圾回收
+	KIND_HELD_TOO_LONG              // 借出的元素超过阈值时长仍未释放
+	KIND_DOUBLE_RELEASE             // 借出的元素被重复释放
+)
+
+// 泄漏类型转字符串
+func (k Kind) String() string {
+	switch k {
+	case KIND_COLLECTED:
+		return "COLLECTED"
+	case KIND_HELD_TOO_LONG:
+		return "HELD_TOO_LONG"
+	case KIND_DOUBLE_RELEASE:
+		return "DOUBLE_RELEASE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// 泄漏报告
+type Report struct {
+	Kind         Kind                       // 泄漏类型
+	BorrowedAt   time.Time                  // 元素借出的时间
+	Held         time.Duration              // 发现泄漏时, 元素已借出的时长
+	BorrowStack  []*callerstate.CallerState // 借出元素时的调用栈
+	ReleaseStack []*callerstate.CallerState // 重复释放元素时的调用栈, 仅 `KIND_DOUBLE_RELEASE` 类型具备
+}
+
+// 将报告转为字符串
+func (r *Report) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "pool element leak detected: %v, held %v\n", r.Kind, r.Held)
+	if len(r.ReleaseStack) > 0 {
+		sb.WriteString("released at:\n")
+		for _, cs := range r.ReleaseStack {
+			fmt.Fprintf(&sb, "\t%v\n", cs)
+		}
+	}
+
+	sb.WriteString("borrowed at:\n")
+	for _, cs := range r.BorrowStack {
+		fmt.Fprintf(&sb, "\t%v\n", cs)
+	}
+	return sb.String()
+}
+
+// 泄漏检测器的可选参数
+type option struct {
+	threshold  time.Duration // 元素借出的最长时长, 为 `0` 表示不检测
+	depth      uint          // 记录调用栈的最大层数
+	handler    func(*Report) // 处理泄漏报告的函数
+	panicTwice bool          // 重复释放时是否引发 panic
+}
+
+// 用于设置泄漏检测器可选参数的回调类型
+type Option = func(*option)
+
+// 设置元素借出的最长时长, 超过该时长仍未释放的元素会被报告
+func WithThreshold(d time.Duration) Option {
+	return func(o *option) {
+		o.threshold = d
+	}
+}
+
+// 设置记录调用栈的最大层数
+func WithStackDepth(depth uint) Option {
+	return func(o *option) {
+		o.depth = depth
+	}
+}
+
+// 设置处理泄漏报告的函数, 默认通过 `log` 包输出报告
+func WithHandler(handler func(*Report)) Option {
+	return func(o *option) {
+		o.handler = handler
+	}
+}
+
+// 设置重复释放元素时引发 panic, 而不是调用处理报告的函数
+func WithPanicOnDoubleRelease() Option {
+	return func(o *option) {
+		o.panicTwice = true
+	}
+}
+
+// 泄漏检测器
+//
+// 泄漏检测器用于池的调试模式, 在元素借出时记录调用栈, 并在以下情况下报告:
+//   - 借出的元素未释放即被垃圾回收 (通过 `runtime.AddCleanup` 检测);
+//   - 借出的元素超过阈值时长仍未释放 (通过定时器检测);
+//   - 借出的元素被重复释放;
+//
+// 由于记录调用栈和设置定时器均有额外开销, 所以仅应在调试或测试时使用
+type Detector struct {
+	opts option
+}
+
+// 创建泄漏检测器实例
+func New(opts ...Option) *Detector {
+	def := option{
+		depth: 16,
+		handler: func(r *Report) {
+			log.Print(r)
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&def)
+	}
+	return &Detector{opts: def}
+}
+
+// 获取调用栈, 跳过 `skip` 层调用帧
+func (d *Detector) stack(skip int) []*callerstate.CallerState {
+	// 额外跳过 `stack` 函数自身
+	skip++
+
+	cs := callerstate.ListStackInfo(d.opts.depth + uint(skip))
+	return cs[min(skip, len(cs)):]
+}
+
+// 借出元素的跟踪记录
+type Record struct {
+	d          *Detector                  // 所属的泄漏检测器
+	released   atomic.Bool                // 元素是否已经释放
+	borrowedAt time.Time                  // 元素借出的时间
+	stack      []*callerstate.CallerState // 借出元素时的调用栈
+	timer      *time.Timer                // 检测元素借出时长的定时器
+	cleanup    runtime.Cleanup            // 检测元素被垃圾回收的清理函数句柄
+}
+
+// 开始跟踪一个借出的元素
+//
+// `ptr` 参数为借出的元素指针, 用于检测元素是否未释放即被垃圾回收;
+// `skip` 参数为记录调用栈时需要跳过的调用帧数量 (`0` 表示从调用 `Track` 的函数开始记录);
+// `reclaim` 参数为元素未释放即被垃圾回收时的回调函数, 用于池回收元素占用的容量, 可以为 `nil`
+//
+// 注意: `reclaim` 函数不能引用 `ptr` 参数表示的元素, 否则元素永远不会被垃圾回收
+func Track[T any](d *Detector, ptr *T, skip int, reclaim func()) *Record {
+	r := &Record{
+		d:          d,
+		borrowedAt: time.Now(),
+		stack:      d.stack(skip + 1),
+	}
+
+	// 元素借出超过阈值时长时, 如果元素尚未释放, 则报告泄漏
+	if d.opts.threshold > 0 {
+		r.timer = time.AfterFunc(d.opts.threshold, func() {
+			if !r.released.Load() {
+				r.report(KIND_HELD_TOO_LONG, nil)
+			}
+		})
+	}
+
+	// 元素被垃圾回收时, 如果元素尚未释放, 则报告泄漏
+	r.cleanup = runtime.AddCleanup(ptr, func(r *Record) {
+		if r.released.CompareAndSwap(false, true) {
+			if r.timer != nil {
+				r.timer.Stop()
+			}
+
+			r.report(KIND_COLLECTED, nil)
+			if reclaim != nil {
+				reclaim()
+			}
+		}
+	}, r)
+	return r
+}
+
+// 生成泄漏报告并进行处理
+func (r *Record) report(kind Kind, releaseStack []*callerstate.CallerState) {
+	r.d.opts.handler(&Report{
+		Kind:         kind,
+		BorrowedAt:   r.borrowedAt,
+		Held:         time.Since(r.borrowedAt),
+		BorrowStack:  r.stack,
+		ReleaseStack: releaseStack,
+	})
+}
+
+// 结束跟踪, 表示元素已经释放
+//
+// 如果元素已经释放过, 则报告重复释放并返回 `false`, 如果检测器设置了 `WithPanicOnDoubleRelease` 参数,
+// 则引发 panic; `skip` 参数为记录重复释放调用栈时需要跳过的调用帧数量
+func (r *Record) Release(skip int) bool {
+	if !r.released.CompareAndSwap(false, true) {
+		report := &Report{
+			Kind:         KIND_DOUBLE_RELEASE,
+			BorrowedAt:   r.borrowedAt,
+			Held:         time.Since(r.borrowedAt),
+			BorrowStack:  r.stack,
+			ReleaseStack: r.d.stack(skip + 1),
+		}
+		if r.d.opts.panicTwice {
+			panic(report)
+		}
+
+		r.d.opts.handler(report)
+		return false
+	}
+
+	// 停止定时器和垃圾回收检测
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.cleanup.Stop()
+	return true
+}
@@ -0,0 +1,115 @@
+package leak_test
+
+import (
+	"runtime"
+	"study/basic/concurrency/sync/pools/leak"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 用于测试的元素结构体
+type Object struct {
+	Value int
+}
+
+// 收集泄漏报告
+type collector struct {
+	mux     sync.Mutex
+	reports []*leak.Report
+}
+
+func (c *collector) handle(r *leak.Report) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.reports = append(c.reports, r)
+}
+
+func (c *collector) kinds() []leak.Kind {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	ks := make([]leak.Kind, 0, len(c.reports))
+	for _, r := range c.reports {
+		ks = append(ks, r.Kind)
+	}
+	return ks
+}
+
+// 测试报告借出时间过长的元素
+func TestDetector_HeldTooLong(t *testing.T) {
+	var c collector
+	d := leak.New(leak.WithThreshold(50*time.Millisecond), leak.WithHandler(c.handle))
+
+	obj := &Object{}
+	rec := leak.Track(d, obj, 0, nil)
+
+	// 超过阈值时长后, 报告元素未释放
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, []leak.Kind{leak.KIND_HELD_TOO_LONG}, c.kinds())
+
+	// 报告中记录了借出元素时的调用栈
+	assert.Equal(t, "study/basic/concurrency/sync/pools/leak_test.TestDetector_HeldTooLong", c.reports[0].BorrowStack[0].FuncName)
+	assert.True(t, rec.Release(0))
+
+	runtime.KeepAlive(obj)
+}
+
+// 测试报告未释放即被垃圾回收的元素
+func TestDetector_Collected(t *testing.T) {
+	var c collector
+	reclaimed := make(chan struct{})
+
+	d := leak.New(leak.WithHandler(c.handle))
+
+	// 借出元素后不释放, 并丢弃元素的引用
+	//
+	// 注意: 不包含指针的小对象 (小于 16 字节) 会通过 tiny allocator 和其它对象合并分配, 其清理函数可能永远不会执行,
+	// 所以这里分配一个较大的元素
+	func() {
+		leak.Track(d, &[4]Object{}, 0, func() { close(reclaimed) })
+	}()
+
+	// 触发垃圾回收, 等待清理函数执行
+	deadline := time.After(time.Second)
+	for done := false; !done; {
+		runtime.GC()
+
+		select {
+		case <-reclaimed:
+			done = true
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			assert.Fail(t, "element not collected")
+			done = true
+		}
+	}
+	assert.Equal(t, []leak.Kind{leak.KIND_COLLECTED}, c.kinds())
+}
+
+// 测试报告重复释放的元素
+func TestDetector_DoubleRelease(t *testing.T) {
+	var c collector
+	d := leak.New(leak.WithHandler(c.handle))
+
+	obj := &Object{}
+	rec := leak.Track(d, obj, 0, nil)
+
+	assert.True(t, rec.Release(0))
+	assert.False(t, rec.Release(0))
+
+	// 报告中记录了重复释放元素时的调用栈
+	assert.Equal(t, []leak.Kind{leak.KIND_DOUBLE_RELEASE}, c.kinds())
+	assert.Equal(t, "study/basic/concurrency/sync/pools/leak_test.TestDetector_DoubleRelease", c.reports[0].ReleaseStack[0].FuncName)
+
+	// 设置重复释放时引发 panic
+	d = leak.New(leak.WithPanicOnDoubleRelease())
+
+	rec = leak.Track(d, obj, 0, nil)
+	rec.Release(0)
+
+	assert.Panics(t, func() { rec.Release(0) })
+}
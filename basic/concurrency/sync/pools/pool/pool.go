@@ -1,22 +1,56 @@
 package pool
 
-import "sync"
+import (
+	"study/basic/concurrency/sync/pools/leak"
+	"sync"
+	"sync/atomic"
+)
 
 // 定义一个对象池元素类型
 // 该类型对象存储在对象池中, 起到管理对象的作用
 type Elem[T any] struct {
-	elem T          // 内容实例
-	pool *sync.Pool // 池实例指针
+	elem     T            // 内容实例
+	pool     *Pool[T]     // 池实例指针
+	released atomic.Bool  // 元素是否已经返回池
+	rec      *leak.Record // 泄漏检测记录, 仅在开启泄漏检测时具备
 }
 
 // 释放对象, 令对象返回对象池
-func (pe *Elem[T]) Release() { pe.pool.Put(pe) }
+//
+// 重复释放同一个对象会被忽略 (否则该对象会在池中存在两份, 进而同时被两处借出),
+// 如果开启了泄漏检测, 则重复释放会被报告
+func (pe *Elem[T]) Release() {
+	if pe.rec != nil {
+		if !pe.rec.Release(1) {
+			return
+		}
+	}
+	if !pe.released.CompareAndSwap(false, true) {
+		return
+	}
+	pe.pool.pool.Put(pe)
+}
 
 // 获取池元素中存储的实例
 func (pe *Elem[T]) Get() T { return pe.elem }
 
 // 基于 `sync.Pool` 类型设置新类型
-type Pool[T any] sync.Pool
+type Pool[T any] struct {
+	pool     sync.Pool      // 存储池元素的池实例
+	detector *leak.Detector // 泄漏检测器, 为 `nil` 表示不进行泄漏检测
+}
+
+// 用于设置池可选参数的回调类型
+type Option[T any] = func(*Pool[T])
+
+// 开启泄漏检测
+//
+// 开启后, 借出元素时会记录调用栈, 并通过泄漏检测器报告未释放或重复释放的元素, 仅应在调试或测试时使用
+func WithLeakDetector[T any](d *leak.Detector) Option[T] {
+	return func(p *Pool[T]) {
+		p.detector = d
+	}
+}
 
 // 创建实例, 设置创建内容实例
 //
@@ -25,22 +59,40 @@ type Pool[T any] sync.Pool
 // 该函数会被池在需要创建新实例时调用
 //
 // 该函数返回一个 `Pool` 对象, 该对象是基于 `sync.Pool` 类型设置的新类型
-func New[T any](new func() T) *Pool[T] {
-	var pool sync.Pool
+func New[T any](new func() T, opts ...Option[T]) *Pool[T] {
+	pool := &Pool[T]{}
+
+	// 设置可选参数
+	for _, opt := range opts {
+		opt(pool)
+	}
 
 	// 设置池的实例创建函数
-	pool.New = func() any {
+	pool.pool.New = func() any {
 		return &Elem[T]{
 			elem: new(),
-			pool: &pool,
+			pool: pool,
 		}
 	}
-	return (*Pool[T])(&pool)
+	return pool
 }
 
 // 从池中获取池元素实例
 //
 // 该函数返回一个池元素实例, 该实例中存储了内容实例
+//
+// 未开启泄漏检测时, 池元素实例和内容实例一起被复用, 以免每次获取都分配内存, 所以释放后不能再使用之前获取的池元素实例;
+// 开启泄漏检测时, 每次获取都返回新的池元素实例, 之前获取的池元素实例已被释放, 继续调用其 `Release` 方法会被忽略并报告,
+// 不会将当前获取者正在使用的内容实例返回池
 func (p *Pool[T]) Get() *Elem[T] {
-	return (*sync.Pool)(p).Get().(*Elem[T])
+	elem := p.pool.Get().(*Elem[T])
+	if p.detector == nil {
+		elem.released.Store(false)
+		return elem
+	}
+
+	// 开启泄漏检测时, 使用新的池元素实例, 并记录借出元素的调用栈
+	elem = &Elem[T]{elem: elem.elem, pool: p}
+	elem.rec = leak.Track(p.detector, elem, 1, nil)
+	return elem
 }
@@ -1,11 +1,14 @@
 package pool_test
 
 import (
+	"study/basic/concurrency/sync/pools/leak"
 	"study/basic/concurrency/sync/pools/pool"
 	"study/basic/testing/assertion"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 // 实例状态
@@ -89,3 +92,72 @@ func TestPool_PoolElem(t *testing.T) {
 	// 确认产生的实例总数不超过 20, 实际使用实例次数为 100
 	assertion.Between(t, len(objs), 1, 20)
 }
+
+// 测试重复释放池元素
+//
+// 重复释放的池元素会被忽略, 开启泄漏检测后, 重复释放会被报告
+func TestPool_DoubleRelease(t *testing.T) {
+	reports := make([]*leak.Report, 0)
+
+	pool := pool.New(
+		func() *Object { return &Object{state: NEW} },
+		pool.WithLeakDetector[*Object](leak.New(leak.WithHandler(func(r *leak.Report) {
+			reports = append(reports, r)
+		}))),
+	)
+
+	elem := pool.Get()
+	elem.Release()
+	elem.Release()
+
+	// 重复释放被报告
+	assert.Len(t, reports, 1)
+	assert.Equal(t, leak.KIND_DOUBLE_RELEASE, reports[0].Kind)
+	assert.Equal(t, "study/basic/concurrency/sync/pools/pool_test.TestPool_DoubleRelease", reports[0].BorrowStack[0].FuncName)
+
+	// 元素只返回池一次, 两次获取的元素不会相同
+	e1 := pool.Get()
+	e2 := pool.Get()
+	assert.NotSame(t, e1.Get(), e2.Get())
+}
+
+// 测试已释放的池元素被其它调用者重新获取后, 通过旧的池元素实例再次释放, 不会影响其它调用者
+//
+// 无论重新获取的是否为同一个内容实例, 旧的池元素实例再次释放只会被报告为重复释放,
+// 其它调用者之后释放自己的池元素实例时不会被报告
+func TestPool_StaleRelease(t *testing.T) {
+	reports := make([]*leak.Report, 0)
+
+	p := pool.New(
+		func() *Object { return &Object{state: NEW} },
+		pool.WithLeakDetector[*Object](leak.New(leak.WithHandler(func(r *leak.Report) {
+			reports = append(reports, r)
+		}))),
+	)
+
+	stale := p.Get()
+	stale.Release()
+
+	// 其它调用者重新获取池元素, 获取到的池元素实例和旧的实例不同
+	elem := p.Get()
+	assert.NotSame(t, stale, elem)
+
+	// 通过旧的池元素实例释放, 被报告为重复释放
+	stale.Release()
+	assert.Len(t, reports, 1)
+
+	// 当前调用者释放池元素, 不会被报告
+	elem.Release()
+	assert.Len(t, reports, 1)
+	assert.Equal(t, leak.KIND_DOUBLE_RELEASE, reports[0].Kind)
+}
+
+// 测试获取和释放池元素的性能, 未开启泄漏检测时, 池元素实例被复用, 不会分配内存
+func BenchmarkPool_Get(b *testing.B) {
+	p := pool.New(func() *Object { return &Object{state: NEW} })
+
+	b.ReportAllocs()
+	for range b.N {
+		p.Get().Release()
+	}
+}
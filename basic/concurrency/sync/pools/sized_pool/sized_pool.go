@@ -2,57 +2,189 @@ package sized_pool
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"study/basic/concurrency/sync/pools/leak"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"golang.org/x/sync/semaphore"
 )
 
+var (
+	// 池已关闭错误
+	ErrPoolClosed = errors.New("pool closed")
+)
+
 // 定义一个池中实例的类型
 type Elem[T any] struct {
-	elem T             // 内容实例
-	pool *SizedPool[T] // 池实例指针
+	elem     T             // 内容实例
+	pool     *SizedPool[T] // 池实例指针
+	idleAt   time.Time     // 元素返回池的时间
+	released atomic.Bool   // 元素是否已经返回池
+	rec      *leak.Record  // 泄漏检测记录, 仅在开启泄漏检测时具备
 }
 
 // 将元素返回池
-func (p *Elem[T]) Release() { p.pool.put(p) }
+//
+// 重复释放同一个元素会被忽略 (否则会破坏池的借出计数和信号量), 如果开启了泄漏检测, 则重复释放会被报告
+func (p *Elem[T]) Release() {
+	if p.rec != nil {
+		if !p.rec.Release(1) {
+			return
+		}
+	}
+	if !p.released.CompareAndSwap(false, true) {
+		return
+	}
+	p.pool.put(p)
+}
 
 // 获取池元素中存储的实例
 func (pe *Elem[T]) Get() T { return pe.elem }
 
 func (pe *Elem[T]) String() string { return fmt.Sprintf("%v", pe.elem) }
 
+// 池的可选参数
+type options[T any] struct {
+	validate    func(T) bool   // 借出元素前校验元素是否可用的函数
+	onEvict     func(T)        // 元素被淘汰时的清理函数
+	maxIdleTime time.Duration  // 元素在池中的最长空闲时间
+	minIdle     int            // 池中最少保持的空闲元素数量
+	detector    *leak.Detector // 泄漏检测器, 为 `nil` 表示不进行泄漏检测
+}
+
+// 用于设置池可选参数的回调类型
+type Option[T any] = func(*options[T])
+
+// 设置借出元素前校验元素是否可用的函数
+//
+// 从池中借出元素前会调用该函数, 如果函数返回 `false`, 则该元素被淘汰, 并继续从池中获取下一个元素 (或创建新元素)
+func WithValidate[T any](validate func(T) bool) Option[T] {
+	return func(o *options[T]) {
+		o.validate = validate
+	}
+}
+
+// 设置元素被淘汰时的清理函数
+//
+// 元素校验失败, 空闲超时或池关闭时, 会调用该函数清理元素, 例如关闭连接
+func WithOnEvict[T any](onEvict func(T)) Option[T] {
+	return func(o *options[T]) {
+		o.onEvict = onEvict
+	}
+}
+
+// 设置元素在池中的最长空闲时间
+//
+// 空闲时间超过该值的元素会被淘汰, 但池中至少会保留 `minIdle` 个空闲元素
+func WithMaxIdleTime[T any](d time.Duration) Option[T] {
+	return func(o *options[T]) {
+		o.maxIdleTime = d
+	}
+}
+
+// 设置池中最少保持的空闲元素数量
+//
+// 创建池时会预先创建该数量的元素 (预热), 且空闲超时淘汰时, 池中至少会保留该数量的空闲元素
+func WithMinIdle[T any](n int) Option[T] {
+	return func(o *options[T]) {
+		o.minIdle = n
+	}
+}
+
+// 开启泄漏检测
+//
+// 开启后, 借出元素时会记录调用栈, 并通过泄漏检测器报告未释放或重复释放的元素;
+// 未释放即被垃圾回收的元素所占用的容量会被池回收, 仅应在调试或测试时使用
+func WithLeakDetector[T any](d *leak.Detector) Option[T] {
+	return func(o *options[T]) {
+		o.detector = d
+	}
+}
+
 // 定义固定大小的池类型
 //
 // Go 语言的 `sync.Pool` 类型并未对池的大小设置上限, 这会导致如果有一波处理峰值, 就有可能瞬间将池中的实例消耗光,
 // 进而继续通过池创建大量的实例
 //
 // 所以限制池的大小, 可以在处理数据峰值的时候, 起到限流的作用
+//
+// 另外, `sync.Pool` 中的实例可能被 GC 随时回收, 无法用于管理数据库连接等需要显式清理的资源,
+// 所以这里通过切片自行保存空闲实例 (后进先出, 以便最近使用的实例优先被复用, 长时间未使用的实例被淘汰)
+//
+// 为了支持运行时调整池的容量, 信号量的总量设置为一个极大值, 其中超出池容量的部分被池自身预先占用 (保留值),
+// 扩容时释放保留值, 缩容时重新占用信号量值; 如果缩容时借出的元素过多, 无法立即占用足够的信号量值,
+// 则记录欠缺的数量 (`debt`), 在元素返回池时不再释放信号量值, 直到欠缺的数量归零
 type SizedPool[T any] struct {
-	pool     sync.Pool           // 池, 用于存储指定类型的实例
+	creator  func() T            // 创建实例的函数
+	idle     []*Elem[T]          // 空闲实例栈, 栈底为空闲时间最长的实例
+	mux      sync.Mutex          // 保护空闲实例栈的互斥锁
+	timer    *time.Timer         // 淘汰空闲超时实例的定时器
+	closed   bool                // 池是否已关闭
 	weighted *semaphore.Weighted // 信号量, 用于限制池的最大尺寸
-	size     int64               // 池的当前大小
-	maxSize  int64               // 池的最大容积
+	debt     int64               // 缩容时尚未占用的信号量值
+	borrowed atomic.Int64        // 借出的元素数量
+	maxSize  atomic.Int64        // 池的最大容积
+	stats    stats               // 池的统计数据
+	opts     options[T]          // 池的可选参数
+}
+
+// 池的统计计数器
+type stats struct {
+	created  atomic.Int64 // 创建元素的总数
+	evicted  atomic.Int64 // 淘汰元素的总数
+	waits    atomic.Int64 // 获取元素时发生等待的次数
+	waitTime atomic.Int64 // 获取元素时等待的总时长 (纳秒)
+	timeouts atomic.Int64 // 获取元素时等待超时 (上下文结束) 的次数
+}
+
+// 池的统计数据
+type Stats struct {
+	MaxSize   int           // 池的最大容量
+	Borrowed  int           // 当前借出的元素数量
+	Idle      int           // 当前空闲的元素数量
+	Created   int64         // 创建元素的总数
+	Evicted   int64         // 淘汰元素的总数
+	WaitCount int64         // 获取元素时发生等待的次数
+	WaitTime  time.Duration // 获取元素时等待的总时长
+	Timeouts  int64         // 获取元素时等待超时 (上下文结束) 的次数
 }
 
 // 创建实例
 //
-// 设置池的对最大容量, 并设置创建池中实例的函数
-func New[T any](size int, creator func() T) *SizedPool[T] {
+// 设置池的对最大容量, 并设置创建池中实例的函数, 可通过 `opts` 参数设置池的可选参数; `size` 参数不能为负数, 否则引发 panic
+func New[T any](size int, creator func() T, opts ...Option[T]) *SizedPool[T] {
+	if size < 0 {
+		panic(fmt.Sprintf("sized_pool: invalid pool size %d", size))
+	}
+
 	pool := SizedPool[T]{
-		weighted: semaphore.NewWeighted(int64(size)),
-		size:     int64(size),
-		maxSize:  int64(size),
+		creator:  creator,
+		weighted: semaphore.NewWeighted(math.MaxInt64),
 	}
 
-	// 设定池的 New 函数, 用于创建实例
-	pool.pool.New = func() any {
-		return &Elem[T]{
-			elem: creator(),
-			pool: &pool,
-		}
+	// 预先占用超出池容量部分的信号量值
+	pool.weighted.TryAcquire(math.MaxInt64 - int64(size))
+	pool.maxSize.Store(int64(size))
+
+	// 设置可选参数
+	for _, opt := range opts {
+		opt(&pool.opts)
+	}
+
+	// 预热, 预先创建最少空闲数量的实例
+	now := time.Now()
+	for range min(pool.opts.minIdle, size) {
+		pool.idle = append(pool.idle, &Elem[T]{
+			elem:   pool.create(),
+			pool:   &pool,
+			idleAt: now,
+		})
 	}
+	pool.scheduleEvict()
 
 	return &pool
 }
@@ -61,42 +193,302 @@ func New[T any](size int, creator func() T) *SizedPool[T] {
 func (p *SizedPool[T]) TryGet() (elem *Elem[T], ok bool) {
 	// 尝试消费一个信号量值
 	if p.weighted.TryAcquire(1) {
-		ok = true
-
 		// 如果信号量消费成功, 则从池中获取一个实例
-		elem = p.pool.Get().(*Elem[T])
-
-		// 减少池当前大小
-		atomic.AddInt64(&p.size, -1)
+		if elem, err := p.borrow(); err == nil {
+			return elem, true
+		}
 	}
-	return
+	return nil, false
 }
 
 // 尝试从池中获取一个池元素实例
 //
 // 这里可以通过 `Context` 实例限制池空后, 等待元素返回池的最长超时时间
 func (p *SizedPool[T]) Get(ctx context.Context) (*Elem[T], error) {
-	if err := p.weighted.Acquire(ctx, 1); err != nil {
+	// 池中有可用的元素, 无需等待
+	if p.weighted.TryAcquire(1) {
+		return p.borrow()
+	}
+
+	// 池已空, 等待元素返回池, 并记录等待次数和时长
+	p.stats.waits.Add(1)
+
+	start := time.Now()
+	err := p.weighted.Acquire(ctx, 1)
+	p.stats.waitTime.Add(int64(time.Since(start)))
+
+	if err != nil {
+		p.stats.timeouts.Add(1)
 		return nil, err
 	}
+	return p.borrow()
+}
+
+// 创建实例, 并记录创建次数
+func (p *SizedPool[T]) create() T {
+	p.stats.created.Add(1)
+	return p.creator()
+}
+
+// 在消费信号量值后, 从空闲实例栈中获取一个可用的实例, 如果没有可用的实例, 则创建新实例
+func (p *SizedPool[T]) borrow() (*Elem[T], error) {
+	var elem *Elem[T]
+
+	for {
+		p.mux.Lock()
+		if p.closed {
+			p.mux.Unlock()
+			p.release()
+			return nil, ErrPoolClosed
+		}
+
+		// 从栈顶取出一个空闲实例
+		elem = nil
+		if n := len(p.idle); n > 0 {
+			elem = p.idle[n-1]
+			p.idle[n-1] = nil
+			p.idle = p.idle[:n-1]
+		}
+		p.mux.Unlock()
+
+		// 没有空闲实例, 创建新实例
+		if elem == nil {
+			elem = &Elem[T]{elem: p.create(), pool: p}
+			break
+		}
+
+		// 淘汰空闲超时的实例
+		if p.opts.maxIdleTime > 0 && time.Since(elem.idleAt) > p.opts.maxIdleTime {
+			p.evict(elem)
+			continue
+		}
+
+		// 淘汰校验失败的实例
+		if p.opts.validate != nil && !p.opts.validate(elem.elem) {
+			p.evict(elem)
+			continue
+		}
+
+		// 每次借出都使用新的池元素实例包装内容实例, 之前借出时得到的池元素实例已被释放,
+		// 继续调用其 `Release` 方法会被忽略, 不会将当前借出者正在使用的实例返回池
+		elem = &Elem[T]{elem: elem.elem, pool: p}
+		break
+	}
 
-	elem := p.pool.Get().(*Elem[T])
-	atomic.AddInt64(&p.size, -1)
+	// 增加借出的元素数量
+	p.borrowed.Add(1)
+
+	// 开启泄漏检测时, 记录借出元素的调用栈, 跳过 `borrow` 以及 `Get`/`TryGet` 调用帧
+	if p.opts.detector != nil {
+		elem.rec = leak.Track(p.opts.detector, elem, 2, p.reclaim)
+	}
 	return elem, nil
 }
 
 // 将元素返回池
 func (p *SizedPool[T]) put(elem *Elem[T]) {
-	p.pool.Put(elem)
-	atomic.AddInt64(&p.size, 1)
+	p.mux.Lock()
+	if p.closed {
+		// 池已关闭, 直接淘汰实例
+		p.mux.Unlock()
+		p.evict(elem)
+	} else {
+		elem.idleAt = time.Now()
+		p.idle = append(p.idle, elem)
+		p.mux.Unlock()
+	}
+
+	p.borrowed.Add(-1)
+	p.release()
+
+	p.scheduleEvict()
+}
+
+// 回收未释放即被垃圾回收的元素所占用的容量
+func (p *SizedPool[T]) reclaim() {
+	p.borrowed.Add(-1)
+	p.release()
+}
+
+// 释放一个信号量值, 如果缩容尚有欠缺的信号量值, 则将其抵扣欠缺的数量
+func (p *SizedPool[T]) release() {
+	p.mux.Lock()
+	if p.debt > 0 {
+		p.debt--
+		p.mux.Unlock()
+		return
+	}
+	p.mux.Unlock()
 
 	p.weighted.Release(1)
 }
 
+// 调整池的最大容量
+//
+// 扩容时, 等待获取元素的 goroutine 会立即被唤醒; 缩容时, 已借出的元素不受影响,
+// 如果借出的元素数量超过新的容量, 则超出部分在返回池时不再可用, 直到借出的元素数量低于新的容量
+//
+// `size` 参数不能为负数, 否则引发 panic
+func (p *SizedPool[T]) Resize(size int) {
+	if size < 0 {
+		panic(fmt.Sprintf("sized_pool: invalid pool size %d", size))
+	}
+
+	var expired []*Elem[T]
+
+	p.mux.Lock()
+	defer func() {
+		p.mux.Unlock()
+
+		// 在锁外清理实例, 避免清理函数耗时过长阻塞池
+		for _, elem := range expired {
+			p.evict(elem)
+		}
+	}()
+
+	old := p.maxSize.Swap(int64(size))
+	delta := int64(size) - old
+
+	switch {
+	case delta > 0:
+		// 扩容, 先抵扣缩容时欠缺的信号量值, 再释放剩余的保留值
+		paid := min(delta, p.debt)
+		p.debt -= paid
+
+		if delta > paid {
+			p.weighted.Release(delta - paid)
+		}
+	case delta < 0:
+		// 缩容, 按空闲的信号量值 (原容量减去借出的数量和欠缺的数量) 一次性占用, 无法占用的部分记为欠缺的数量;
+		// 借出元素时, 借出数量在占用信号量值之后才增加, 所以估算的空闲值可能偏大, 此时全部记为欠缺的数量
+		n := min(-delta, max(old-p.debt-p.borrowed.Load(), 0))
+		if n > 0 && p.weighted.TryAcquire(n) {
+			delta += n
+		}
+		p.debt -= delta
+
+		// 淘汰超出新容量的空闲元素
+		if n := len(p.idle) - size; n > 0 {
+			expired = make([]*Elem[T], n)
+			copy(expired, p.idle[:n])
+			p.idle = append(p.idle[:0], p.idle[n:]...)
+		}
+	}
+}
+
+// 淘汰实例, 调用实例的清理函数
+func (p *SizedPool[T]) evict(elem *Elem[T]) {
+	p.stats.evicted.Add(1)
+
+	if p.opts.onEvict != nil {
+		p.opts.onEvict(elem.elem)
+	}
+}
+
+// 设置定时器, 在栈底实例空闲超时时淘汰空闲超时的实例
+//
+// 如果未设置最长空闲时间, 或定时器已在运行, 或没有可淘汰的实例, 则不设置定时器
+func (p *SizedPool[T]) scheduleEvict() {
+	if p.opts.maxIdleTime <= 0 {
+		return
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.closed || p.timer != nil || len(p.idle) <= p.opts.minIdle {
+		return
+	}
+
+	// 栈底实例空闲时间最长, 在其超时后执行淘汰
+	wait := p.opts.maxIdleTime - time.Since(p.idle[0].idleAt)
+	p.timer = time.AfterFunc(max(wait, 0), func() {
+		p.mux.Lock()
+		p.timer = nil
+		p.mux.Unlock()
+
+		p.evictIdle()
+		p.scheduleEvict()
+	})
+}
+
+// 淘汰空闲超时的实例, 但至少保留 `minIdle` 个空闲实例
+func (p *SizedPool[T]) evictIdle() {
+	p.mux.Lock()
+
+	// 从栈底开始查找空闲超时的实例
+	n := 0
+	for n < len(p.idle)-p.opts.minIdle && time.Since(p.idle[n].idleAt) > p.opts.maxIdleTime {
+		n++
+	}
+
+	expired := make([]*Elem[T], n)
+	copy(expired, p.idle[:n])
+	p.idle = append(p.idle[:0], p.idle[n:]...)
+
+	p.mux.Unlock()
+
+	// 在锁外清理实例, 避免清理函数耗时过长阻塞池
+	for _, elem := range expired {
+		p.evict(elem)
+	}
+}
+
+// 关闭池
+//
+// 关闭后, 池中的空闲实例全部被淘汰, 借出的实例在返回池时被淘汰, 之后从池中获取实例会返回 `ErrPoolClosed` 错误
+func (p *SizedPool[T]) Close() {
+	p.mux.Lock()
+	if p.closed {
+		p.mux.Unlock()
+		return
+	}
+	p.closed = true
+
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+
+	idle := p.idle
+	p.idle = nil
+	p.mux.Unlock()
+
+	for _, elem := range idle {
+		p.evict(elem)
+	}
+}
+
 // 获取池当前大小
+//
+// 池当前大小表示还可以从池中获取多少个元素, 即池的最大容量减去借出的元素数量
 func (p *SizedPool[T]) Size() int {
-	return int(atomic.LoadInt64(&p.size))
+	return int(max(p.maxSize.Load()-p.borrowed.Load(), 0))
+}
+
+// 获取池中空闲实例的数量
+//
+// 池当前大小 (`Size`) 表示还可以从池中获取多少个实例, 其中一部分为已创建的空闲实例, 其余的实例在获取时创建
+func (p *SizedPool[T]) Idle() int {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	return len(p.idle)
 }
 
 // 获取池的最大容量
-func (p *SizedPool[T]) MaxSize() int { return int(p.maxSize) }
+func (p *SizedPool[T]) MaxSize() int { return int(p.maxSize.Load()) }
+
+// 获取池的统计数据
+func (p *SizedPool[T]) Stats() Stats {
+	return Stats{
+		MaxSize:   p.MaxSize(),
+		Borrowed:  int(p.borrowed.Load()),
+		Idle:      p.Idle(),
+		Created:   p.stats.created.Load(),
+		Evicted:   p.stats.evicted.Load(),
+		WaitCount: p.stats.waits.Load(),
+		WaitTime:  time.Duration(p.stats.waitTime.Load()),
+		Timeouts:  p.stats.timeouts.Load(),
+	}
+}
@@ -3,7 +3,9 @@ package sized_pool_test
 import (
 	"context"
 	"fmt"
+	"runtime"
 	slices2 "study/basic/builtin/slices"
+	"study/basic/concurrency/sync/pools/leak"
 	sp "study/basic/concurrency/sync/pools/sized_pool"
 	"study/basic/testing/assertion"
 	"sync"
@@ -119,6 +121,12 @@ func TestSizedPool_Get(t *testing.T) {
 	// 共从池中获取 10 个元素
 	assert.Len(t, rs, pool.MaxSize())
 
+	// 记录取出的内容实例
+	vals := make([]*Value, 0, len(rs))
+	for _, elem := range rs {
+		vals = append(vals, elem.Get())
+	}
+
 	// 目前池为空, 继续获取元素会导致阻塞或超时
 	_, ok := pool.TryGet()
 	assert.False(t, ok)
@@ -153,8 +161,8 @@ func TestSizedPool_Get(t *testing.T) {
 			elem, err := pool.Get(ctx)
 
 			assert.Nil(t, err)
-			// 确认取出的元素包含在上次取出元素集合中
-			assert.Contains(t, rs, elem)
+			// 确认取出的内容实例包含在上次取出的实例集合中 (每次借出的池元素实例均为新实例)
+			assert.Contains(t, vals, elem.Get())
 		}()
 	}
 
@@ -167,3 +175,337 @@ func TestSizedPool_Get(t *testing.T) {
 	// 确认第二次获取全部池元素消耗的时间
 	assertion.DurationMatch(t, 10*10*time.Millisecond, time.Since(start))
 }
+
+// 用于测试的连接结构体
+type Conn struct {
+	Id     int
+	Broken bool
+	Closed bool
+}
+
+// 测试借出元素前校验元素是否可用
+//
+// 校验失败的元素会被淘汰, 并调用淘汰清理函数
+func TestSizedPool_Validate(t *testing.T) {
+	lastId := 0
+	evicted := make([]int, 0)
+
+	pool := sp.New(
+		2,
+		func() *Conn {
+			lastId++
+			return &Conn{Id: lastId}
+		},
+		sp.WithValidate(func(c *Conn) bool { return !c.Broken }),
+		sp.WithOnEvict(func(c *Conn) {
+			c.Closed = true
+			evicted = append(evicted, c.Id)
+		}),
+	)
+
+	// 借出一个元素, 将其标记为损坏后返回池
+	elem, err := pool.Get(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, elem.Get().Id)
+
+	conn := elem.Get()
+	conn.Broken = true
+	elem.Release()
+	assert.Equal(t, 1, pool.Idle())
+
+	// 再次借出元素, 损坏的元素被淘汰, 并创建新元素
+	elem, err = pool.Get(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, elem.Get().Id)
+	assert.True(t, conn.Closed)
+	assert.Equal(t, []int{1}, evicted)
+
+	elem.Release()
+}
+
+// 测试淘汰空闲超时的元素
+//
+// 空闲超时的元素会被定时淘汰, 但池中至少保留 `minIdle` 个空闲元素
+func TestSizedPool_MaxIdleTime(t *testing.T) {
+	var evicted atomic.Int32
+
+	pool := sp.New(
+		10,
+		func() *Value { return &Value{} },
+		sp.WithOnEvict(func(*Value) { evicted.Add(1) }),
+		sp.WithMaxIdleTime[*Value](50*time.Millisecond),
+		sp.WithMinIdle[*Value](2),
+	)
+
+	// 创建池时预先创建 2 个空闲元素
+	assert.Equal(t, 2, pool.Idle())
+
+	// 借出 5 个元素并返回池
+	elems := make([]*sp.Elem[*Value], 0, 5)
+	for range 5 {
+		elem, _ := pool.TryGet()
+		elems = append(elems, elem)
+	}
+	for _, elem := range elems {
+		elem.Release()
+	}
+	assert.Equal(t, 5, pool.Idle())
+	assert.Equal(t, 10, pool.Size())
+
+	// 等待空闲超时, 超时元素被淘汰, 保留 2 个空闲元素
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 2, pool.Idle())
+	assert.Equal(t, int32(3), evicted.Load())
+
+	// 池的大小不受影响
+	assert.Equal(t, 10, pool.Size())
+}
+
+// 测试关闭池
+func TestSizedPool_Close(t *testing.T) {
+	var evicted atomic.Int32
+
+	pool := sp.New(
+		10,
+		func() *Value { return &Value{} },
+		sp.WithOnEvict(func(*Value) { evicted.Add(1) }),
+		sp.WithMinIdle[*Value](3),
+	)
+
+	// 借出一个元素后关闭池
+	elem, ok := pool.TryGet()
+	assert.True(t, ok)
+
+	pool.Close()
+
+	// 关闭池时空闲元素被淘汰
+	assert.Equal(t, 0, pool.Idle())
+	assert.Equal(t, int32(2), evicted.Load())
+
+	// 关闭池后, 无法从池中获取元素
+	_, err := pool.Get(context.Background())
+	assert.ErrorIs(t, err, sp.ErrPoolClosed)
+
+	// 借出的元素返回池时被淘汰
+	elem.Release()
+	assert.Equal(t, int32(3), evicted.Load())
+	assert.Equal(t, 10, pool.Size())
+}
+
+// 测试池的容量不能为负数
+func TestSizedPool_InvalidSize(t *testing.T) {
+	assert.PanicsWithValue(t, "sized_pool: invalid pool size -1", func() {
+		sp.New(-1, func() *Value { return &Value{} })
+	})
+
+	pool := sp.New(2, func() *Value { return &Value{} })
+	assert.Panics(t, func() { pool.Resize(-1) })
+	assert.Equal(t, 2, pool.MaxSize())
+}
+
+// 测试缩容时一次性占用全部空闲的容量
+func TestSizedPool_ShrinkIdle(t *testing.T) {
+	pool := sp.New(10, func() *Value { return &Value{} })
+
+	elem, ok := pool.TryGet()
+	assert.True(t, ok)
+
+	// 缩容为 3, 空闲的容量足够, 无需记录欠缺的数量, 可以再获取 2 个元素
+	pool.Resize(3)
+	assert.Equal(t, 2, pool.Size())
+
+	for range 2 {
+		_, ok := pool.TryGet()
+		assert.True(t, ok)
+	}
+	_, ok = pool.TryGet()
+	assert.False(t, ok)
+
+	// 返回元素后, 容量立即可用
+	elem.Release()
+	_, ok = pool.TryGet()
+	assert.True(t, ok)
+}
+
+// 测试运行时调整池的容量
+//
+// 缩容时不影响已借出的元素, 超出新容量的元素在返回池后不再可用
+func TestSizedPool_Resize(t *testing.T) {
+	pool := sp.New(4, func() *Value { return &Value{} })
+
+	// 借出 3 个元素
+	elems := make([]*sp.Elem[*Value], 0, 3)
+	for range 3 {
+		elem, ok := pool.TryGet()
+		assert.True(t, ok)
+		elems = append(elems, elem)
+	}
+	assert.Equal(t, 1, pool.Size())
+
+	// 缩容为 2, 此时借出的元素数量超过容量, 无法再获取元素
+	pool.Resize(2)
+	assert.Equal(t, 2, pool.MaxSize())
+	assert.Equal(t, 0, pool.Size())
+
+	_, ok := pool.TryGet()
+	assert.False(t, ok)
+
+	// 返回 2 个元素后, 借出的元素数量为 1, 可以再获取 1 个元素
+	elems[0].Release()
+	_, ok = pool.TryGet()
+	assert.False(t, ok)
+
+	elems[1].Release()
+	assert.Equal(t, 1, pool.Size())
+
+	elem, ok := pool.TryGet()
+	assert.True(t, ok)
+	elems[1] = elem
+
+	_, ok = pool.TryGet()
+	assert.False(t, ok)
+
+	// 启动 goroutine 等待获取元素, 扩容后立即获取成功
+	ch := make(chan error)
+	go func() {
+		_, err := pool.Get(context.Background())
+		ch <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	pool.Resize(4)
+	assert.Nil(t, <-ch)
+
+	// 借出 3 个元素, 还可以再获取 1 个元素
+	assert.Equal(t, 1, pool.Size())
+	assert.Equal(t, 3, pool.Stats().Borrowed)
+
+	_, ok = pool.TryGet()
+	assert.True(t, ok)
+	_, ok = pool.TryGet()
+	assert.False(t, ok)
+}
+
+// 测试池的统计数据
+func TestSizedPool_Stats(t *testing.T) {
+	pool := sp.New(1, func() *Value { return &Value{} })
+
+	elem, err := pool.Get(context.Background())
+	assert.Nil(t, err)
+
+	// 池已空, 获取元素等待 50ms 后超时
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = pool.Get(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 启动 goroutine, 在 50ms 后将元素返回池
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		elem.Release()
+	}()
+
+	// 等待元素返回池后获取成功
+	elem, err = pool.Get(context.Background())
+	assert.Nil(t, err)
+
+	stats := pool.Stats()
+	assert.Equal(t, 1, stats.MaxSize)
+	assert.Equal(t, 1, stats.Borrowed)
+	assert.Equal(t, 0, stats.Idle)
+	assert.Equal(t, int64(1), stats.Created)
+	assert.Equal(t, int64(2), stats.WaitCount)
+	assert.Equal(t, int64(1), stats.Timeouts)
+	assertion.DurationMatch(t, 100*time.Millisecond, stats.WaitTime)
+
+	elem.Release()
+	assert.Equal(t, 1, pool.Stats().Idle)
+}
+
+// 测试重复释放池元素
+//
+// 重复释放的池元素会被忽略, 不会破坏池的大小
+func TestSizedPool_DoubleRelease(t *testing.T) {
+	pool := sp.New(2, func() *Value { return &Value{} })
+
+	elem, _ := pool.TryGet()
+	elem.Release()
+	elem.Release()
+
+	assert.Equal(t, 2, pool.Size())
+	assert.Equal(t, 1, pool.Idle())
+
+	// 开启泄漏检测, 设置重复释放时引发 panic
+	pool = sp.New(
+		2,
+		func() *Value { return &Value{} },
+		sp.WithLeakDetector[*Value](leak.New(leak.WithPanicOnDoubleRelease())),
+	)
+
+	elem, _ = pool.TryGet()
+	elem.Release()
+
+	assert.Panics(t, func() { elem.Release() })
+	assert.Equal(t, 2, pool.Size())
+}
+
+// 测试已释放的池元素被其它调用者重新借出后, 通过旧的池元素实例再次释放, 不会影响其它调用者
+func TestSizedPool_StaleRelease(t *testing.T) {
+	pool := sp.New(1, func() *Value { return &Value{} })
+
+	stale, _ := pool.TryGet()
+	stale.Release()
+
+	// 重新借出同一个内容实例, 但池元素实例不同
+	elem, ok := pool.TryGet()
+	assert.True(t, ok)
+	assert.Same(t, stale.Get(), elem.Get())
+	assert.NotSame(t, stale, elem)
+
+	// 通过旧的池元素实例释放被忽略, 池中仍没有可借出的元素
+	stale.Release()
+	assert.Equal(t, 0, pool.Size())
+
+	_, ok = pool.TryGet()
+	assert.False(t, ok)
+
+	elem.Release()
+	assert.Equal(t, 1, pool.Size())
+}
+
+// 测试检测未释放即被垃圾回收的池元素
+//
+// 未释放即被垃圾回收的元素会被报告, 且其占用的容量会被池回收
+func TestSizedPool_LeakCollected(t *testing.T) {
+	reports := make(chan *leak.Report, 1)
+
+	pool := sp.New(
+		2,
+		func() *Value { return &Value{} },
+		sp.WithLeakDetector[*Value](leak.New(leak.WithHandler(func(r *leak.Report) {
+			reports <- r
+		}))),
+	)
+
+	// 借出元素后不释放, 并丢弃元素的引用
+	func() {
+		_, ok := pool.TryGet()
+		assert.True(t, ok)
+	}()
+	assert.Equal(t, 1, pool.Size())
+
+	// 触发垃圾回收, 等待泄漏报告
+	runtime.GC()
+
+	select {
+	case r := <-reports:
+		assert.Equal(t, leak.KIND_COLLECTED, r.Kind)
+		assert.Contains(t, r.BorrowStack[0].FuncName, "sized_pool_test.TestSizedPool_LeakCollected")
+	case <-time.After(time.Second):
+		assert.Fail(t, "element not collected")
+	}
+
+	// 泄漏元素占用的容量被回收
+	assert.Equal(t, 2, pool.Size())
+}
@@ -0,0 +1,44 @@
+package pool
+
+import "time"
+
+// 任务生命周期事件类型
+type EventKind int
+
+const (
+	EVENT_QUEUED    EventKind = iota // 任务提交到任务池等待执行, 随后被拒绝的任务会再产生 `EVENT_FAILED` 事件
+	EVENT_STARTED                    // 任务开始执行 (每次执行都会产生该事件)
+	EVENT_SUCCEEDED                  // 任务执行成功
+	EVENT_FAILED                     // 任务以错误结束, 包括执行失败, 被取消, 超时以及被拒绝
+	EVENT_RETRIED                    // 任务执行失败, 将在等待后重试
+)
+
+// 事件类型转字符串
+func (k EventKind) String() string {
+	switch k {
+	case EVENT_QUEUED:
+		return "QUEUED"
+	case EVENT_STARTED:
+		return "STARTED"
+	case EVENT_SUCCEEDED:
+		return "SUCCEEDED"
+	case EVENT_FAILED:
+		return "FAILED"
+	case EVENT_RETRIED:
+		return "RETRIED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// 任务生命周期事件
+type Event struct {
+	Kind     EventKind     // 事件类型
+	TaskId   int64         // 任务 id, 即 `Task.Id`
+	Priority int           // 任务优先级
+	Attempt  int           // 任务的执行次数, 任务尚未执行时为 `0`
+	Time     time.Time     // 事件发生的时间
+	Elapsed  time.Duration // 从任务提交到事件发生经过的时长
+	Delay    time.Duration // 重试前的等待时间, 仅 `EVENT_RETRIED` 事件具备
+	Err      error         // 任务的错误, 仅 `EVENT_FAILED` 和 `EVENT_RETRIED` 事件具备
+}
@@ -0,0 +1,50 @@
+package pool
+
+import "context"
+
+// 表示一个异步任务的执行结果
+//
+// 任务提交后立即返回 `Future` 实例, 任务完成 (成功, 失败, 被取消或超时) 后, 可通过 `Await` 方法获取结果
+type Future[R any] struct {
+	done   chan struct{}      // 任务完成后关闭的通道
+	result R                  // 任务的执行结果
+	err    error              // 任务的错误信息
+	cancel context.CancelFunc // 取消任务的函数
+}
+
+// 创建实例
+func newFuture[R any](cancel context.CancelFunc) *Future[R] {
+	return &Future[R]{
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+}
+
+// 设置任务结果, 并通知等待结果的 goroutine
+//
+// 该方法只能调用一次, 由任务保证
+func (f *Future[R]) resolve(result R, err error) {
+	f.result, f.err = result, err
+	close(f.done)
+}
+
+// 等待任务结束并返回任务结果
+//
+// 如果 `ctx` 参数在任务完成前结束, 则返回 `ctx` 的错误, 但任务本身不会被取消
+func (f *Future[R]) Await(ctx context.Context) (R, error) {
+	select {
+	case <-f.done:
+		return f.result, f.err
+	case <-ctx.Done():
+		var zero R
+		return zero, ctx.Err()
+	}
+}
+
+// 获取任务完成时关闭的通道, 用于在 `select` 中等待任务结束
+func (f *Future[R]) Done() <-chan struct{} { return f.done }
+
+// 取消任务
+//
+// 尚未开始执行的任务会被直接丢弃; 正在执行的任务, 其上下文会被取消, 任务结果为 `context.Canceled` 错误
+func (f *Future[R]) Cancel() { f.cancel() }
@@ -0,0 +1,77 @@
+package pool
+
+import (
+	"math"
+	"time"
+)
+
+// 任务重试策略
+//
+// 任务执行失败后, 如果执行次数未达到 `MaxAttempts` 且错误可重试, 则在等待一段时间后重新执行任务,
+// 等待时间从 `Backoff` 开始, 每次重试后乘以 `Multiplier`, 但不超过 `MaxBackoff`
+type RetryPolicy struct {
+	MaxAttempts int              // 最多执行次数 (包括第一次执行), 不大于 `1` 表示不重试
+	Backoff     time.Duration    // 第一次重试前的等待时间
+	MaxBackoff  time.Duration    // 重试前的最长等待时间, 为 `0` 表示不限制
+	Multiplier  float64          // 每次重试后等待时间的倍数, 为 `0` 时按 `2` 计算, 为 `1` 表示每次等待相同时间, 小于 `1` 时按 `1` 计算
+	Retryable   func(error) bool // 判断错误是否可重试, 为 `nil` 表示所有错误均可重试
+}
+
+// 判断第 `attempt` 次执行失败后, 是否可以重试
+func (rp *RetryPolicy) allow(attempt int, err error) bool {
+	if attempt >= rp.MaxAttempts {
+		return false
+	}
+	return rp.Retryable == nil || rp.Retryable(err)
+}
+
+// 计算第 `attempt` 次执行失败后, 重试前需要等待的时间
+func (rp *RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := rp.Multiplier
+	switch {
+	case multiplier == 0:
+		multiplier = 2
+	case multiplier < 1:
+		multiplier = 1
+	}
+
+	d := float64(rp.Backoff) * math.Pow(multiplier, float64(attempt-1))
+	if rp.MaxBackoff > 0 && d > float64(rp.MaxBackoff) {
+		return rp.MaxBackoff
+	}
+	if d > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(d)
+}
+
+// 单个任务的可选参数
+type taskOptions struct {
+	priority int            // 任务优先级
+	retry    *RetryPolicy   // 任务重试策略, 为 `nil` 表示使用任务池的重试策略
+	timeout  *time.Duration // 任务执行的超时时间, 为 `nil` 表示使用任务池的超时时间
+}
+
+// 用于设置单个任务可选参数的回调类型
+type TaskOption = func(*taskOptions)
+
+// 设置任务优先级, 优先级较大的任务优先执行, 优先级相同的任务按提交顺序执行, 默认为 `0`
+func WithPriority(priority int) TaskOption {
+	return func(o *taskOptions) {
+		o.priority = priority
+	}
+}
+
+// 设置任务每次执行的超时时间, 覆盖任务池通过 `WithTaskTimeout` 设置的超时时间, 为 `0` 表示不超时
+func WithTimeout(timeout time.Duration) TaskOption {
+	return func(o *taskOptions) {
+		o.timeout = &timeout
+	}
+}
+
+// 设置任务的重试策略, 覆盖任务池通过 `WithRetryPolicy` 设置的重试策略
+func WithRetry(policy RetryPolicy) TaskOption {
+	return func(o *taskOptions) {
+		o.retry = &policy
+	}
+}
@@ -0,0 +1,62 @@
+package pool
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试重试策略判断是否可以重试
+func TestRetryPolicy_Allow(t *testing.T) {
+	errTemp := errors.New("temporary")
+
+	rp := RetryPolicy{MaxAttempts: 3}
+	assert.True(t, rp.allow(1, errTemp))
+	assert.True(t, rp.allow(2, errTemp))
+	assert.False(t, rp.allow(3, errTemp))
+
+	// 未设置最多执行次数, 不重试
+	rp = RetryPolicy{}
+	assert.False(t, rp.allow(1, errTemp))
+
+	// 只重试指定的错误
+	rp = RetryPolicy{
+		MaxAttempts: 3,
+		Retryable:   func(err error) bool { return errors.Is(err, errTemp) },
+	}
+	assert.True(t, rp.allow(1, errTemp))
+	assert.False(t, rp.allow(1, errors.New("permanent")))
+}
+
+// 测试重试策略计算重试前的等待时间
+func TestRetryPolicy_Backoff(t *testing.T) {
+	rp := RetryPolicy{Backoff: 10 * time.Millisecond}
+
+	// 默认按 2 倍递增
+	assert.Equal(t, 10*time.Millisecond, rp.backoff(1))
+	assert.Equal(t, 20*time.Millisecond, rp.backoff(2))
+	assert.Equal(t, 40*time.Millisecond, rp.backoff(3))
+
+	// 指定倍数和最长等待时间
+	rp = RetryPolicy{Backoff: 10 * time.Millisecond, Multiplier: 3, MaxBackoff: 50 * time.Millisecond}
+	assert.Equal(t, 10*time.Millisecond, rp.backoff(1))
+	assert.Equal(t, 30*time.Millisecond, rp.backoff(2))
+	assert.Equal(t, 50*time.Millisecond, rp.backoff(3))
+
+	// 倍数为 1 时, 每次等待相同时间
+	rp = RetryPolicy{Backoff: 10 * time.Millisecond, Multiplier: 1}
+	assert.Equal(t, 10*time.Millisecond, rp.backoff(1))
+	assert.Equal(t, 10*time.Millisecond, rp.backoff(2))
+	assert.Equal(t, 10*time.Millisecond, rp.backoff(5))
+
+	// 倍数小于 1 时按 1 计算, 等待时间不会缩短
+	rp = RetryPolicy{Backoff: 10 * time.Millisecond, Multiplier: 0.5}
+	assert.Equal(t, 10*time.Millisecond, rp.backoff(3))
+
+	// 等待时间溢出
+	rp = RetryPolicy{Backoff: time.Hour}
+	assert.Equal(t, time.Duration(math.MaxInt64), rp.backoff(100))
+}
@@ -1,10 +1,17 @@
 package pool
 
 import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"runtime/debug"
+	"study/basic/concurrency/sync/blockque"
 	"study/basic/logs"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 var (
@@ -12,6 +19,13 @@ var (
 	logger     *logs.Logger
 )
 
+var (
+	ErrNoHandler   = errors.New("no task handler")
+	ErrPoolClosed  = errors.New("task pool closed")
+	ErrPoolFull    = errors.New("task pool full")
+	ErrTaskDropped = errors.New("task dropped")
+)
+
 // 初始化任务池
 func init() {
 	// 初始化日志实例
@@ -22,110 +36,566 @@ func init() {
 // 任务处理函数类型
 type TaskHandler[T, R any] func(arg T) (R, error)
 
+// 带上下文的任务处理函数类型
+//
+// 任务被取消或超时后, `ctx` 参数会结束, 处理函数应及时检查并返回
+type ContextHandler[T, R any] func(ctx context.Context, arg T) (R, error)
+
+// 任务执行时发生 panic 产生的错误
+type PanicError struct {
+	Value any    // 引发 panic 的值
+	Stack []byte // 引发 panic 时的调用栈
+}
+
+// 获取错误信息
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("task panic: %v", e.Value)
+}
+
 // 任务类型
 type Task[T, R any] struct {
-	Id        int64             // 任务 id, 用于任务追踪
-	handler   TaskHandler[T, R] // 执行任务的函数
-	Argument  T                 // 任务参数
-	onSuccess func(R)           // 任务成功的回调函数
-	onError   func(error)       // 任务失败的回调函数
+	Id        int64                // 任务 id, 用于任务追踪
+	Argument  T                    // 任务参数
+	Priority  int                  // 任务优先级, 优先级较大的任务优先执行
+	ctx       context.Context      // 任务上下文
+	cancel    context.CancelFunc   // 取消任务上下文的函数
+	stop      func() bool          // 注销任务上下文结束时回调的函数
+	handler   ContextHandler[T, R] // 执行任务的函数
+	complete  func(R, error)       // 任务结束的回调函数
+	done      atomic.Bool          // 任务是否已经结束
+	attempt   atomic.Int32         // 任务的执行次数
+	retry     RetryPolicy          // 任务的重试策略
+	timeout   time.Duration        // 任务每次执行的超时时间, 为 `0` 表示不超时
+	createdAt time.Time            // 任务的创建时间
+	hooks     []func(Event)        // 处理任务生命周期事件的函数
+}
+
+// 产生任务生命周期事件, 并调用处理事件的函数
+func (t *Task[T, R]) emit(kind EventKind, err error, delay time.Duration) {
+	if len(t.hooks) == 0 {
+		return
+	}
+
+	now := time.Now()
+	e := Event{
+		Kind:     kind,
+		TaskId:   t.Id,
+		Priority: t.Priority,
+		Attempt:  int(t.attempt.Load()),
+		Time:     now,
+		Elapsed:  now.Sub(t.createdAt),
+		Delay:    delay,
+		Err:      err,
+	}
+	for _, hook := range t.hooks {
+		hook(e)
+	}
+}
+
+// 结束任务, 并通过回调函数通知任务结果
+//
+// 任务可能因执行完毕, 被取消, 超时或被拒绝而结束, 只有第一次调用有效
+func (t *Task[T, R]) finish(r R, err error) {
+	// 注销任务上下文结束时的回调, 否则之后调用 `t.cancel` 会触发回调, 额外启动一个 goroutine
+	t.stop()
+	t.end(r, err)
+}
+
+// 结束任务, 和 `finish` 方法相同, 但不注销任务上下文结束时的回调, 由该回调本身调用
+func (t *Task[T, R]) end(r R, err error) {
+	if !t.done.CompareAndSwap(false, true) {
+		return
+	}
+
+	if err == nil {
+		t.emit(EVENT_SUCCEEDED, nil, 0)
+	} else {
+		t.emit(EVENT_FAILED, err, 0)
+	}
+
+	t.complete(r, err)
+	t.cancel()
+}
+
+// 执行任务处理函数, 并将处理函数中的 panic 转为 `PanicError` 错误
+func (t *Task[T, R]) execute(ctx context.Context) (r R, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			logger.Debug("task %d panic: %v", t.Id, v)
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+
+	return t.handler(ctx, t.Argument)
+}
+
+// 任务队列已满时的拒绝策略
+type RejectPolicy int
+
+const (
+	REJECT_BLOCK       RejectPolicy = iota // 阻塞提交任务的 goroutine, 直到任务进入队列或任务上下文结束
+	REJECT_FAIL_FAST                       // 立即以 `ErrPoolFull` 错误结束任务
+	REJECT_CALLER_RUNS                     // 在提交任务的 goroutine 中直接执行任务
+	REJECT_DROP_OLDEST                     // 以 `ErrTaskDropped` 错误结束队列中最早进入队列的任务 (和任务优先级无关), 再将任务加入队列
+)
+
+// 拒绝策略转字符串
+func (rp RejectPolicy) String() string {
+	switch rp {
+	case REJECT_BLOCK:
+		return "BLOCK"
+	case REJECT_FAIL_FAST:
+		return "FAIL_FAST"
+	case REJECT_CALLER_RUNS:
+		return "CALLER_RUNS"
+	case REJECT_DROP_OLDEST:
+		return "DROP_OLDEST"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// 任务池的可选参数
+type options[T, R any] struct {
+	handler     ContextHandler[T, R] // `Submit` 方法使用的任务处理函数
+	timeout     time.Duration        // 每个任务执行的超时时间, 为 `0` 表示不超时
+	minWorkers  int                  // 最少保留的 worker 数量
+	maxWorkers  int                  // 最多同时运行的 worker 数量
+	queueSize   int                  // 任务队列的长度
+	idleTimeout time.Duration        // 超出最少数量的 worker 空闲多久后退出
+	policy      RejectPolicy         // 任务队列已满时的拒绝策略
+	retry       RetryPolicy          // 任务的默认重试策略
+	hooks       []func(Event)        // 处理任务生命周期事件的函数
+}
+
+// 用于设置任务池可选参数的回调类型
+type Option[T, R any] = func(*options[T, R])
+
+// 设置 `Submit` 方法使用的任务处理函数
+func WithHandler[T, R any](handler ContextHandler[T, R]) Option[T, R] {
+	return func(o *options[T, R]) {
+		o.handler = handler
+	}
+}
+
+// 设置每个任务执行的超时时间
+//
+// 超时时间从任务开始执行时计算, 任务超时后, 其结果为 `context.DeadlineExceeded` 错误,
+// 此时处理函数的 `ctx` 参数也会结束; 可通过 `WithTimeout` 参数为单个任务设置超时时间
+func WithTaskTimeout[T, R any](timeout time.Duration) Option[T, R] {
+	return func(o *options[T, R]) {
+		o.timeout = timeout
+	}
+}
+
+// 设置最少保留的 worker 数量, 任务池创建时即启动这些 worker, 且这些 worker 空闲时不会退出
+func WithMinWorkers[T, R any](n int) Option[T, R] {
+	return func(o *options[T, R]) {
+		o.minWorkers = n
+	}
+}
+
+// 设置最多同时运行的 worker 数量, 当没有空闲的 worker 时, 提交任务会启动新的 worker, 直到达到该数量
+func WithMaxWorkers[T, R any](n int) Option[T, R] {
+	return func(o *options[T, R]) {
+		o.maxWorkers = n
+	}
+}
+
+// 设置任务队列的长度, 至少为 `1`
+func WithQueueSize[T, R any](n int) Option[T, R] {
+	return func(o *options[T, R]) {
+		o.queueSize = n
+	}
+}
+
+// 设置超出最少数量的 worker 空闲多久后退出
+func WithIdleTimeout[T, R any](timeout time.Duration) Option[T, R] {
+	return func(o *options[T, R]) {
+		o.idleTimeout = timeout
+	}
+}
+
+// 设置任务队列已满时的拒绝策略, 默认为 `REJECT_BLOCK`
+func WithRejectPolicy[T, R any](policy RejectPolicy) Option[T, R] {
+	return func(o *options[T, R]) {
+		o.policy = policy
+	}
+}
+
+// 设置任务的默认重试策略, 可通过 `WithRetry` 参数为单个任务设置重试策略
+//
+// 重试前的等待期间, 执行任务的 worker 会被占用
+func WithRetryPolicy[T, R any](policy RetryPolicy) Option[T, R] {
+	return func(o *options[T, R]) {
+		o.retry = policy
+	}
+}
+
+// 添加处理任务生命周期事件的函数, 可添加多个
+//
+// 事件处理函数在产生事件的 goroutine (提交任务或执行任务的 goroutine) 中同步调用, 所以应尽快返回,
+// 且需保证并发安全; 可用于将任务的执行情况转为日志或监控指标
+func WithHook[T, R any](hook func(Event)) Option[T, R] {
+	return func(o *options[T, R]) {
+		o.hooks = append(o.hooks, hook)
+	}
 }
 
 // 任务池类型
 type TaskPool[T, R any] struct {
-	ch  chan *Task[T, R] // 输送任务实例的通道
-	wg  sync.WaitGroup   // 等待任务结束的等待组
-	mux sync.Mutex
+	queue   *blockque.PriorityBlockQueue[*Task[T, R]] // 存储等待执行任务的队列, 按任务优先级出队
+	wg      sync.WaitGroup                            // 等待任务结束的等待组
+	mux     sync.RWMutex                              // 保证任务池关闭后不再有任务入队以及启动 worker
+	closed  bool                                      // 任务池是否已关闭
+	workers atomic.Int32                              // 当前运行的 worker 数量
+	idle    atomic.Int32                              // 当前空闲的 worker 数量
+	opts    options[T, R]                             // 可选参数
 }
 
 // 创建任务池实例
-func NewTaskPool[T, R any](size int) *TaskPool[T, R] {
+//
+// `size` 参数为默认的 worker 数量以及任务队列长度, 即默认情况下, 任务池会固定运行 `size` 个 worker,
+// 可通过 `WithMinWorkers`, `WithMaxWorkers` 以及 `WithQueueSize` 参数分别修改
+func NewTaskPool[T, R any](size int, opts ...Option[T, R]) *TaskPool[T, R] {
 	// 创建任务池实例
 	pool := TaskPool[T, R]{
-		ch: make(chan *Task[T, R], size),
+		opts: options[T, R]{
+			minWorkers:  size,
+			maxWorkers:  size,
+			queueSize:   size,
+			idleTimeout: time.Minute,
+		},
 	}
 
-	// 启动指定数量的 goroutine, 执行异步任务
-	for i := 0; i < size; i++ {
-		pool.wg.Add(1)
+	// 设置可选参数
+	for _, opt := range opts {
+		opt(&pool.opts)
+	}
 
-		go func() {
-			defer pool.wg.Done()
+	pool.opts.maxWorkers = max(pool.opts.maxWorkers, pool.opts.minWorkers, 1)
+	pool.queue = blockque.NewPriority(int64(max(pool.opts.queueSize, 1)), func(a, b *Task[T, R]) int {
+		return cmp.Compare(b.Priority, a.Priority)
+	})
 
-			logger.Debug("worker %d starting", i)
+	// 启动最少数量的 goroutine, 执行异步任务
+	for range pool.opts.minWorkers {
+		pool.spawn()
+	}
 
-			// 在循环中获取任务并执行
-			for {
-				ch := pool.channel()
-				if ch == nil {
-					logger.Debug("worker %d canceled", i)
-					return
-				}
+	return &pool
+}
 
-				// 获取一个任务实例
-				t, ok := <-ch
-				if !ok {
-					logger.Debug("worker %d canceled", i)
-					return
-				}
-				logger.Debug("new task incoming, id: %d", t.Id)
+// 启动一个新的 worker, 如果 worker 数量已达上限则返回 `false`
+//
+// 调用该方法前, 需持有读锁且确认任务池未关闭
+func (p *TaskPool[T, R]) spawn() bool {
+	for {
+		n := p.workers.Load()
+		if int(n) >= p.opts.maxWorkers {
+			return false
+		}
+		if p.workers.CompareAndSwap(n, n+1) {
+			break
+		}
+	}
+
+	p.wg.Add(1)
+	go p.work()
+	return true
+}
+
+// 令一个空闲的 worker 退出, 如果 worker 数量已不多于最少数量则返回 `false`
+func (p *TaskPool[T, R]) retire() bool {
+	for {
+		n := p.workers.Load()
+		if int(n) <= p.opts.minWorkers {
+			return false
+		}
+		if p.workers.CompareAndSwap(n, n-1) {
+			return true
+		}
+	}
+}
+
+// worker 的执行过程, 在循环中获取任务并执行, 直到任务池关闭, 或空闲超时且 worker 数量多于最少数量
+func (p *TaskPool[T, R]) work() {
+	defer p.wg.Done()
+
+	logger.Debug("worker starting, %d workers running", p.workers.Load())
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), p.opts.idleTimeout)
+
+		// 获取一个任务实例
+		p.idle.Add(1)
+		t, err := p.queue.Take(ctx)
+		p.idle.Add(-1)
+		cancel()
+
+		if err != nil {
+			if errors.Is(err, blockque.ErrQueueClosed) {
+				p.workers.Add(-1)
+				logger.Debug("worker canceled")
+				return
+			}
 
-				// 执行任务, 并返回结果
-				r, err := t.handler(t.Argument)
+			// 空闲超时, 如果 worker 数量多于最少数量, 则退出
+			if p.retire() {
+				logger.Debug("worker retired after idle %v", p.opts.idleTimeout)
 
-				// 根据任务执行是否成功调用不同的回调函数
-				if err == nil {
-					t.onSuccess(r)
-				} else {
-					t.onError(err)
+				// 提交任务时该 worker 可能仍被计为空闲, 所以提交的任务不会启动新的 worker,
+				// 如果退出前队列中已有任务, 则启动新的 worker 执行 (当前 worker 尚未结束, 所以等待组计数不为零)
+				if p.queue.Len() > 0 {
+					p.spawn()
 				}
+				return
 			}
-		}()
+			continue
+		}
+
+		logger.Debug("new task incoming, id: %d", t.Id)
+		p.run(t)
 	}
+}
 
-	return &pool
+// 执行任务, 任务执行失败时, 根据重试策略进行重试
+func (p *TaskPool[T, R]) run(t *Task[T, R]) {
+	for {
+		// 任务在等待执行或等待重试期间已被取消, 直接丢弃
+		if t.ctx.Err() != nil {
+			logger.Debug("task %d canceled before running", t.Id)
+			return
+		}
+
+		attempt := int(t.attempt.Add(1))
+		t.emit(EVENT_STARTED, nil, 0)
+
+		r, err := p.attempt(t, attempt)
+		if err == nil || t.ctx.Err() != nil || !t.retry.allow(attempt, err) {
+			t.finish(r, err)
+			return
+		}
+
+		// 等待一段时间后重试
+		delay := t.retry.backoff(attempt)
+		logger.Debug("task %d failed at attempt %d, retry after %v: %v", t.Id, attempt, delay, err)
+		t.emit(EVENT_RETRIED, err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-t.ctx.Done():
+			timer.Stop()
+		}
+	}
 }
 
-func (p *TaskPool[T, R]) channel() chan *Task[T, R] {
-	p.mux.Lock()
-	defer p.mux.Unlock()
-	ch := p.ch
-	return ch
+// 执行一次任务
+func (p *TaskPool[T, R]) attempt(t *Task[T, R], attempt int) (R, error) {
+	ctx := t.ctx
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+
+		// 如果超时后不能再重试, 则超时后立即结束任务, 不必等待处理函数返回
+		if !t.retry.allow(attempt, context.DeadlineExceeded) {
+			stop := context.AfterFunc(ctx, func() {
+				var zero R
+				t.finish(zero, ctx.Err())
+			})
+			defer stop()
+		}
+	}
+
+	// 执行任务, 并返回结果
+	return t.execute(ctx)
+}
+
+// 创建任务实例
+func (p *TaskPool[T, R]) newTask(ctx context.Context, handler ContextHandler[T, R], arg T, opts []TaskOption) *Task[T, R] {
+	to := taskOptions{}
+	for _, opt := range opts {
+		opt(&to)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	t := &Task[T, R]{
+		Id:        lastTaskId.Add(1),
+		Argument:  arg,
+		Priority:  to.priority,
+		ctx:       ctx,
+		cancel:    cancel,
+		handler:   handler,
+		retry:     p.opts.retry,
+		timeout:   p.opts.timeout,
+		createdAt: time.Now(),
+		hooks:     p.opts.hooks,
+	}
+	if to.retry != nil {
+		t.retry = *to.retry
+	}
+	if to.timeout != nil {
+		t.timeout = *to.timeout
+	}
+
+	logger.Debug("new task created, id: %v", t.Id)
+	return t
+}
+
+// 将任务实例加入任务队列
+//
+// 任务上下文结束 (被取消或超时) 时, 任务会立即以上下文的错误结束;
+// 任务池已关闭或任务被拒绝时, 任务会立即以相应的错误结束
+func (p *TaskPool[T, R]) enqueue(t *Task[T, R]) {
+	// 回调可能在 `t.stop` 赋值前执行, 所以回调中不能调用 `finish` 方法
+	t.stop = context.AfterFunc(t.ctx, func() {
+		var zero R
+		t.end(zero, t.ctx.Err())
+
+		// 如果任务仍在队列中, 则将其删除, 释放其占用的队列空间
+		if p.queue.RemoveFunc(func(qt *Task[T, R]) bool { return qt == t }) {
+			logger.Debug("canceled task %d removed from queue", t.Id)
+		}
+	})
+
+	// 拒绝策略为 `REJECT_CALLER_RUNS` 时, 在当前 goroutine 中执行任务
+	if p.offer(t) {
+		p.run(t)
+	}
+}
+
+// 将任务实例加入任务队列, 如果任务需要在当前 goroutine 中执行, 则返回 `true`
+func (p *TaskPool[T, R]) offer(t *Task[T, R]) bool {
+	var zero R
+
+	// 持有读锁, 防止任务入队期间任务池被关闭
+	p.mux.RLock()
+	locked := true
+	defer func() {
+		if locked {
+			p.mux.RUnlock()
+		}
+	}()
+
+	if p.closed {
+		t.finish(zero, ErrPoolClosed)
+		return false
+	}
+
+	t.emit(EVENT_QUEUED, nil, 0)
+
+	// 没有空闲的 worker 时, 尝试启动新的 worker
+	if p.idle.Load() == 0 {
+		p.spawn()
+	}
+
+	if p.queue.TryOffer(t) {
+		// 空闲的 worker 可能在任务入队前因空闲超时退出, 此时没有 worker 执行任务, 需启动新的 worker
+		if p.workers.Load() == 0 {
+			p.spawn()
+		}
+		return false
+	}
+
+	// 任务队列已满, 根据拒绝策略处理任务
+	logger.Debug("task pool full, reject task %d by %v policy", t.Id, p.opts.policy)
+
+	switch p.opts.policy {
+	case REJECT_FAIL_FAST:
+		t.finish(zero, ErrPoolFull)
+	case REJECT_CALLER_RUNS:
+		return true
+	case REJECT_DROP_OLDEST:
+		// 按入队顺序丢弃等待最久的任务, 和任务优先级无关
+		for !p.queue.TryOffer(t) {
+			if old, ok := p.queue.PollOldest(nil); ok {
+				old.finish(zero, ErrTaskDropped)
+			}
+		}
+	default:
+		// 释放读锁后再阻塞等待, 否则任务池无法关闭; 任务池关闭时, 队列会唤醒阻塞的入队操作并返回 `ErrQueueClosed` 错误,
+		// 上下文结束时, 任务已通过 `context.AfterFunc` 结束
+		p.mux.RUnlock()
+		locked = false
+
+		if err := p.queue.Put(t.ctx, t); errors.Is(err, blockque.ErrQueueClosed) {
+			t.finish(zero, ErrPoolClosed)
+		}
+	}
+	return false
 }
 
 // 执行一个任务
 func (p *TaskPool[T, R]) Worker(handler TaskHandler[T, R]) func(T, func(R), func(error)) {
+	h := func(_ context.Context, arg T) (R, error) {
+		return handler(arg)
+	}
+
 	return func(arg T, onSuccess func(R), onError func(error)) {
-		// 创建任务实例
-		t := &Task[T, R]{
-			Id:        lastTaskId.Add(1),
-			handler:   handler,
-			Argument:  arg,
-			onSuccess: onSuccess,
-			onError:   onError,
+		t := p.newTask(context.Background(), h, arg, nil)
+		t.complete = func(r R, err error) {
+			// 根据任务执行是否成功调用不同的回调函数
+			if err == nil {
+				onSuccess(r)
+			} else {
+				onError(err)
+			}
 		}
-		logger.Debug("new task created, id: %v", t.Id)
+		p.enqueue(t)
+	}
+}
 
-		// 将任务实例发送到通道
-		p.ch <- t
+// 通过 `WithHandler` 设置的处理函数提交一个任务, 并返回表示任务结果的 `Future` 实例
+//
+// 任务在 `ctx` 结束或 `Future.Cancel` 方法调用后被取消, 如需为任务设置超时, 可通过 `context.WithTimeout` 设置 `ctx`;
+// 可通过 `WithPriority` 以及 `WithRetry` 参数设置任务的优先级和重试策略;
+// 当任务队列已满时, 根据 `WithRejectPolicy` 设置的拒绝策略处理任务; 任务池关闭后, 任务的结果为 `ErrPoolClosed` 错误
+func (p *TaskPool[T, R]) Submit(ctx context.Context, arg T, opts ...TaskOption) *Future[R] {
+	if p.opts.handler == nil {
+		f := newFuture[R](func() {})
+		f.resolve(*new(R), ErrNoHandler)
+		return f
 	}
+	return p.SubmitFunc(ctx, p.opts.handler, arg, opts...)
 }
 
+// 通过指定的处理函数提交一个任务, 并返回表示任务结果的 `Future` 实例
+func (p *TaskPool[T, R]) SubmitFunc(ctx context.Context, handler ContextHandler[T, R], arg T, opts ...TaskOption) *Future[R] {
+	t := p.newTask(ctx, handler, arg, opts)
+
+	f := newFuture[R](t.cancel)
+	t.complete = f.resolve
+
+	p.enqueue(t)
+	return f
+}
+
+// 获取当前运行的 worker 数量
+func (p *TaskPool[T, R]) Workers() int { return int(p.workers.Load()) }
+
+// 获取等待执行的任务数量
+func (p *TaskPool[T, R]) Pending() int { return p.queue.Len() }
+
 func (p *TaskPool[T, R]) close() bool {
 	p.mux.Lock()
 	defer p.mux.Unlock()
 
-	if p.ch == nil {
+	if p.closed {
 		return false
 	}
 
-	close(p.ch)
-	p.ch = nil
+	p.closed = true
+	p.queue.Close()
 
 	return true
 }
 
 // 关闭任务池
+//
+// 关闭后, 已进入队列的任务仍会被执行, 新提交的任务以 `ErrPoolClosed` 错误结束
 func (p *TaskPool[T, R]) Close() {
 	p.close()
 }
@@ -1,9 +1,13 @@
 package pool
 
 import (
+	"context"
+	"errors"
 	"strconv"
 	slices2 "study/basic/builtin/slices"
+	"study/basic/testing/assertion"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -69,37 +73,698 @@ func TestTaskPool_Execute(t *testing.T) {
 
 // 测试任务池的优雅关闭
 //
-// 任务池关闭后, 可等待所有 goroutine 结束
+// 任务池关闭后, 可等待所有 goroutine 结束, 已进入队列的任务仍会被执行, 之后提交的任务则以错误结束
 func TestTaskPool_CloseAndWait(t *testing.T) {
 	// 创建任务池, 共 10 个并发任务
 	pool := NewTaskPool[string, int](10)
 
+	// 记录执行成功的任务数量
+	var count atomic.Int32
+
+	// 创建可执行任务
+	exec := pool.Worker(
+		func(arg string) (int, error) {
+			// 阻塞任务
+			time.Sleep(100 * time.Millisecond)
+			return strconv.Atoi(arg)
+		},
+	)
+
+	// 执行 10 个任务
+	for i := range 10 {
+		exec(
+			strconv.Itoa(i+1),
+			func(result int) { count.Add(1) },
+			func(err error) { assert.Fail(t, "cannot run here") },
+		)
+	}
+
 	// 关闭任务池并等待当前任务结束
-	defer pool.CloseAndWait()
+	pool.CloseAndWait()
+	assert.Equal(t, int32(10), count.Load())
+	assert.Equal(t, 0, pool.Workers())
 
-	// 启动 goroutine, 分配任务
-	go func() {
-		// 创建可执行任务
-		exec := pool.Worker(
-			func(arg string) (int, error) {
-				// 阻塞任务
-				time.Sleep(1 * time.Second)
-				return strconv.Atoi(arg)
+	// 任务池关闭后, 共执行 100 个任务, 任务均以错误结束
+	var wg sync.WaitGroup
+	wg.Add(100)
+
+	for i := range 100 {
+		exec(
+			strconv.Itoa(i+1),
+			func(result int) {
+				assert.Fail(t, "cannot run here")
+			},
+			func(err error) {
+				assert.ErrorIs(t, err, ErrPoolClosed)
+				wg.Done()
 			},
 		)
+	}
+	wg.Wait()
 
-		// 共执行 100 个任务
-		for i := range 100 {
-			// 执行任务
-			exec(
-				strconv.Itoa(i+1),
-				func(result int) {
-					assert.Fail(t, "cannot run here")
-				},
-				func(err error) {
-					assert.Fail(t, "cannot run here")
-				},
-			)
+	// 通过 `Submit` 提交的任务同样以错误结束
+	_, err := pool.SubmitFunc(context.Background(), func(ctx context.Context, arg string) (int, error) {
+		return 0, nil
+	}, "1").Await(context.Background())
+	assert.ErrorIs(t, err, ErrPoolClosed)
+}
+
+// 测试通过 `Submit` 方法提交任务, 并通过 `Future` 获取任务结果
+func TestTaskPool_Submit(t *testing.T) {
+	pool := NewTaskPool(10, WithHandler(func(ctx context.Context, arg string) (int, error) {
+		return strconv.Atoi(arg)
+	}))
+	defer pool.CloseAndWait()
+
+	ctx := context.Background()
+
+	// 提交 100 个任务
+	fs := make([]*Future[int], 0, 100)
+	for i := range 100 {
+		fs = append(fs, pool.Submit(ctx, strconv.Itoa(i+1)))
+	}
+
+	// 依次获取任务结果
+	rs := make([]int, 0, 100)
+	for _, f := range fs {
+		r, err := f.Await(ctx)
+		assert.NoError(t, err)
+		rs = append(rs, r)
+	}
+	assert.Equal(t, slices2.Range(1, 101, 1), rs)
+
+	// 任务执行失败, 返回错误
+	_, err := pool.Submit(ctx, "x").Await(ctx)
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+}
+
+// 测试未设置处理函数时, 通过 `Submit` 方法提交任务
+func TestTaskPool_SubmitWithoutHandler(t *testing.T) {
+	pool := NewTaskPool[string, int](1)
+	defer pool.CloseAndWait()
+
+	_, err := pool.Submit(context.Background(), "1").Await(context.Background())
+	assert.ErrorIs(t, err, ErrNoHandler)
+}
+
+// 测试任务处理函数发生 panic, 不影响任务池继续执行任务
+func TestTaskPool_Panic(t *testing.T) {
+	pool := NewTaskPool(1, WithHandler(func(ctx context.Context, arg string) (int, error) {
+		if arg == "" {
+			panic("empty argument")
 		}
-	}()
+		return strconv.Atoi(arg)
+	}))
+	defer pool.CloseAndWait()
+
+	ctx := context.Background()
+
+	// panic 转为 `PanicError` 错误
+	_, err := pool.Submit(ctx, "").Await(ctx)
+
+	var pe *PanicError
+	assert.True(t, errors.As(err, &pe))
+	assert.Equal(t, "empty argument", pe.Value)
+	assert.NotEmpty(t, pe.Stack)
+
+	// 唯一的 worker 仍可继续执行任务
+	r, err := pool.Submit(ctx, "100").Await(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, r)
+
+	// 通过回调函数执行任务时, panic 转为错误并传递给 `onError` 回调
+	errCh := make(chan error, 1)
+	pool.Worker(func(arg string) (int, error) { panic(arg) })(
+		"boom",
+		func(int) { assert.Fail(t, "cannot run here") },
+		func(err error) { errCh <- err },
+	)
+	assert.ErrorAs(t, <-errCh, &pe)
+	assert.Equal(t, "boom", pe.Value)
+}
+
+// 测试取消尚在队列中等待执行的任务
+func TestTaskPool_CancelQueued(t *testing.T) {
+	var runs atomic.Int32
+
+	// 阻塞唯一 worker 的通道
+	block := make(chan struct{})
+
+	pool := NewTaskPool(2, WithHandler(func(ctx context.Context, arg int) (int, error) {
+		runs.Add(1)
+		<-block
+		return arg, nil
+	}))
+	defer pool.CloseAndWait()
+
+	ctx := context.Background()
+
+	// 前两个任务占用全部 worker
+	f1 := pool.Submit(ctx, 1)
+	f2 := pool.Submit(ctx, 2)
+	assert.Eventually(t, func() bool { return runs.Load() == 2 }, time.Second, time.Millisecond)
+
+	// 后两个任务在队列中等待
+	cctx, cancel := context.WithCancel(ctx)
+	f3 := pool.Submit(ctx, 3)
+	f4 := pool.Submit(cctx, 4)
+	assert.Equal(t, 2, pool.Pending())
+
+	// 通过 `Future` 取消等待中的任务, 任务立即结束并从队列中删除
+	f3.Cancel()
+
+	_, err := f3.Await(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Eventually(t, func() bool { return pool.Pending() == 1 }, time.Second, time.Millisecond)
+
+	// 通过 `ctx` 取消等待中的任务
+	cancel()
+
+	_, err = f4.Await(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Eventually(t, func() bool { return pool.Pending() == 0 }, time.Second, time.Millisecond)
+
+	close(block)
+
+	for i, f := range []*Future[int]{f1, f2} {
+		r, err := f.Await(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, i+1, r)
+	}
+
+	// 被取消的任务不会被执行
+	pool.CloseAndWait()
+	assert.Equal(t, int32(2), runs.Load())
+}
+
+// 测试被取消的等待中任务释放其占用的队列空间
+func TestTaskPool_CancelQueuedFreesSlot(t *testing.T) {
+	ctx := context.Background()
+
+	var runs atomic.Int32
+	block := make(chan struct{})
+
+	pool := NewTaskPool(
+		1,
+		WithHandler(blockingHandler(block, &runs)),
+		WithQueueSize[int, int](1),
+		WithRejectPolicy[int, int](REJECT_FAIL_FAST),
+	)
+	defer pool.CloseAndWait()
+
+	// 第一个任务占用 worker, 第二个任务占满队列
+	f1 := pool.Submit(ctx, 1)
+	assert.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, time.Millisecond)
+
+	f2 := pool.Submit(ctx, 2)
+
+	_, err := pool.Submit(ctx, 3).Await(ctx)
+	assert.ErrorIs(t, err, ErrPoolFull)
+
+	// 取消队列中的任务后, 新任务可以进入队列
+	f2.Cancel()
+	assert.Eventually(t, func() bool { return pool.Pending() == 0 }, time.Second, time.Millisecond)
+
+	f4 := pool.Submit(ctx, 4)
+	assert.Equal(t, 1, pool.Pending())
+
+	close(block)
+	for i, f := range []*Future[int]{f1, f4} {
+		r, err := f.Await(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 4}[i], r)
+	}
+}
+
+// 测试任务执行超时
+func TestTaskPool_Timeout(t *testing.T) {
+	pool := NewTaskPool(
+		1,
+		WithHandler(func(ctx context.Context, arg time.Duration) (time.Duration, error) {
+			select {
+			case <-time.After(arg):
+				return arg, nil
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		}),
+		WithTaskTimeout[time.Duration, time.Duration](100*time.Millisecond),
+	)
+	defer pool.CloseAndWait()
+
+	ctx := context.Background()
+
+	// 任务在超时前完成
+	r, err := pool.Submit(ctx, 10*time.Millisecond).Await(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Millisecond, r)
+
+	// 任务超时
+	start := time.Now()
+
+	_, err = pool.Submit(ctx, time.Second).Await(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	// 等待结果超时, 不影响任务本身
+	f := pool.Submit(ctx, 50*time.Millisecond)
+
+	actx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	_, err = f.Await(actx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	r, err = f.Await(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 50*time.Millisecond, r)
+
+	// 为单个任务设置超时时间, 覆盖任务池的超时时间
+	_, err = pool.Submit(ctx, 50*time.Millisecond, WithTimeout(10*time.Millisecond)).Await(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	r, err = pool.Submit(ctx, 200*time.Millisecond, WithTimeout(0)).Await(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 200*time.Millisecond, r)
+}
+
+// 创建一个阻塞的任务处理函数, 函数在 `block` 通道关闭前不会返回, 并通过 `runs` 参数记录开始执行的任务数量
+func blockingHandler(block <-chan struct{}, runs *atomic.Int32) ContextHandler[int, int] {
+	return func(ctx context.Context, arg int) (int, error) {
+		runs.Add(1)
+		<-block
+		return arg, nil
+	}
+}
+
+// 测试任务池根据负载增加和减少 worker
+func TestTaskPool_Scaling(t *testing.T) {
+	var runs atomic.Int32
+	block := make(chan struct{})
+
+	pool := NewTaskPool(
+		10,
+		WithHandler(blockingHandler(block, &runs)),
+		WithMinWorkers[int, int](1),
+		WithMaxWorkers[int, int](4),
+		WithIdleTimeout[int, int](50*time.Millisecond),
+	)
+	defer pool.CloseAndWait()
+
+	// 启动时只运行最少数量的 worker
+	assert.Equal(t, 1, pool.Workers())
+
+	ctx := context.Background()
+
+	// 提交 10 个任务, worker 数量增加到上限, 其余任务在队列中等待
+	fs := make([]*Future[int], 0, 10)
+	for i := range 10 {
+		fs = append(fs, pool.Submit(ctx, i))
+	}
+	assert.Eventually(t, func() bool { return runs.Load() == 4 }, time.Second, time.Millisecond)
+	assert.Equal(t, 4, pool.Workers())
+	assert.Equal(t, 6, pool.Pending())
+
+	close(block)
+	for i, f := range fs {
+		r, err := f.Await(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, i, r)
+	}
+
+	// 空闲超时后, worker 数量减少到最少数量
+	assert.Eventually(t, func() bool { return pool.Workers() == 1 }, time.Second, 10*time.Millisecond)
+}
+
+// 测试不保留 worker 时, 提交的任务不会因 worker 空闲超时退出而无法执行
+func TestTaskPool_ZeroMinWorkers(t *testing.T) {
+	pool := NewTaskPool(
+		1,
+		WithHandler(func(ctx context.Context, arg int) (int, error) { return arg, nil }),
+		WithMinWorkers[int, int](0),
+		WithIdleTimeout[int, int](time.Millisecond),
+	)
+	defer pool.CloseAndWait()
+
+	// 提交任务的时机接近 worker 空闲超时的时机
+	for i := range 100 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+
+		r, err := pool.Submit(ctx, i).Await(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, i, r)
+		cancel()
+
+		time.Sleep(time.Duration(i%3) * time.Millisecond)
+	}
+}
+
+// 测试任务队列已满时的各种拒绝策略
+func TestTaskPool_RejectPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	// 创建只有一个 worker 且队列长度为 1 的任务池, 并令其处于已满状态
+	newFullPool := func(policy RejectPolicy) (*TaskPool[int, int], []*Future[int], chan struct{}) {
+		var runs atomic.Int32
+		block := make(chan struct{})
+
+		pool := NewTaskPool(1, WithHandler(blockingHandler(block, &runs)), WithRejectPolicy[int, int](policy))
+
+		// 第一个任务占用 worker, 第二个任务占满队列
+		f1 := pool.Submit(ctx, 1)
+		assert.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, time.Millisecond)
+		f2 := pool.Submit(ctx, 2)
+
+		return pool, []*Future[int]{f1, f2}, block
+	}
+
+	t.Run("block", func(t *testing.T) {
+		pool, fs, block := newFullPool(REJECT_BLOCK)
+		defer pool.CloseAndWait()
+
+		// 提交任务阻塞, 直到上下文超时
+		tctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := pool.Submit(tctx, 3).Await(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assertion.DurationMatch(t, 50*time.Millisecond, time.Since(start))
+
+		close(block)
+		for i, f := range fs {
+			r, err := f.Await(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, i+1, r)
+		}
+	})
+
+	t.Run("block until closed", func(t *testing.T) {
+		pool, fs, block := newFullPool(REJECT_BLOCK)
+
+		// 提交任务阻塞期间关闭任务池, 关闭操作不会被阻塞, 阻塞的任务以 `ErrPoolClosed` 错误结束
+		submitted := make(chan *Future[int])
+		go func() { submitted <- pool.Submit(ctx, 3) }()
+		time.Sleep(50 * time.Millisecond)
+
+		pool.Close()
+
+		_, err := (<-submitted).Await(ctx)
+		assert.ErrorIs(t, err, ErrPoolClosed)
+
+		// 已进入队列的任务仍会被执行
+		close(block)
+		pool.CloseAndWait()
+		for i, f := range fs {
+			r, err := f.Await(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, i+1, r)
+		}
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		pool, fs, block := newFullPool(REJECT_FAIL_FAST)
+		defer pool.CloseAndWait()
+
+		_, err := pool.Submit(ctx, 3).Await(ctx)
+		assert.ErrorIs(t, err, ErrPoolFull)
+
+		close(block)
+		for _, f := range fs {
+			_, err := f.Await(ctx)
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("caller runs", func(t *testing.T) {
+		pool, fs, block := newFullPool(REJECT_CALLER_RUNS)
+		defer pool.CloseAndWait()
+
+		// 任务在当前 goroutine 中执行, 所以 `SubmitFunc` 返回时任务已经结束
+		f := pool.SubmitFunc(ctx, func(ctx context.Context, arg int) (int, error) {
+			return arg * 10, nil
+		}, 3)
+
+		select {
+		case <-f.Done():
+		default:
+			assert.Fail(t, "task should be done")
+		}
+
+		r, err := f.Await(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 30, r)
+
+		close(block)
+		for _, f := range fs {
+			_, err := f.Await(ctx)
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		pool, fs, block := newFullPool(REJECT_DROP_OLDEST)
+		defer pool.CloseAndWait()
+
+		// 队列中最早的任务 (第二个任务) 被丢弃
+		f3 := pool.Submit(ctx, 3)
+
+		_, err := fs[1].Await(ctx)
+		assert.ErrorIs(t, err, ErrTaskDropped)
+
+		close(block)
+		for i, f := range []*Future[int]{fs[0], f3} {
+			r, err := f.Await(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, i*2+1, r)
+		}
+	})
+}
+
+// 测试使用任务优先级时, 丢弃的是队列中最早的任务, 而不是优先级最高或最低的任务
+func TestTaskPool_DropOldestWithPriority(t *testing.T) {
+	ctx := context.Background()
+
+	var runs atomic.Int32
+	block := make(chan struct{})
+
+	pool := NewTaskPool(
+		1,
+		WithHandler(blockingHandler(block, &runs)),
+		WithQueueSize[int, int](2),
+		WithRejectPolicy[int, int](REJECT_DROP_OLDEST),
+	)
+	defer pool.CloseAndWait()
+
+	// 第一个任务占用 worker
+	f1 := pool.Submit(ctx, 1)
+	assert.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, time.Millisecond)
+
+	// 依次提交中等优先级, 高优先级的任务占满队列, 再提交低优先级的任务
+	f2 := pool.Submit(ctx, 2, WithPriority(5))
+	f3 := pool.Submit(ctx, 3, WithPriority(10))
+	f4 := pool.Submit(ctx, 4, WithPriority(1))
+
+	// 最早进入队列的第二个任务被丢弃
+	_, err := f2.Await(ctx)
+	assert.ErrorIs(t, err, ErrTaskDropped)
+
+	// 再提交一个任务, 此时最早进入队列的是优先级最高的第三个任务
+	f5 := pool.Submit(ctx, 5, WithPriority(1))
+
+	_, err = f3.Await(ctx)
+	assert.ErrorIs(t, err, ErrTaskDropped)
+
+	close(block)
+	for i, f := range []*Future[int]{f1, f4, f5} {
+		r, err := f.Await(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 4, 5}[i], r)
+	}
+}
+
+// 测试任务执行失败后按照重试策略重试
+func TestTaskPool_Retry(t *testing.T) {
+	errTemp := errors.New("temporary")
+	errPerm := errors.New("permanent")
+
+	// 记录每个任务参数的执行次数
+	var mux sync.Mutex
+	attempts := make(map[int]int)
+
+	pool := NewTaskPool(
+		2,
+		WithHandler(func(ctx context.Context, arg int) (int, error) {
+			mux.Lock()
+			defer mux.Unlock()
+
+			attempts[arg]++
+			if arg < 0 {
+				return 0, errPerm
+			}
+			if attempts[arg] <= arg {
+				return 0, errTemp
+			}
+			return arg, nil
+		}),
+		WithRetryPolicy[int, int](RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     10 * time.Millisecond,
+			Retryable:   func(err error) bool { return errors.Is(err, errTemp) },
+		}),
+	)
+	defer pool.CloseAndWait()
+
+	ctx := context.Background()
+
+	// 失败两次后第三次执行成功, 两次重试共等待 10ms + 20ms
+	start := time.Now()
+
+	r, err := pool.Submit(ctx, 2).Await(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, r)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	// 超过最多执行次数, 返回最后一次执行的错误
+	_, err = pool.Submit(ctx, 5).Await(ctx)
+	assert.ErrorIs(t, err, errTemp)
+
+	// 不可重试的错误, 只执行一次
+	_, err = pool.Submit(ctx, -1).Await(ctx)
+	assert.ErrorIs(t, err, errPerm)
+
+	// 为任务单独设置重试策略
+	r, err = pool.Submit(ctx, 4, WithRetry(RetryPolicy{MaxAttempts: 5})).Await(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, r)
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	assert.Equal(t, map[int]int{2: 3, 5: 3, -1: 1, 4: 5}, attempts)
+}
+
+// 测试任务按优先级执行
+func TestTaskPool_Priority(t *testing.T) {
+	var runs atomic.Int32
+	block := make(chan struct{})
+
+	// 记录任务的执行顺序
+	var mux sync.Mutex
+	order := make([]int, 0)
+
+	pool := NewTaskPool(
+		1,
+		WithQueueSize[int, int](10),
+		WithHandler(func(ctx context.Context, arg int) (int, error) {
+			if arg == 0 {
+				return blockingHandler(block, &runs)(ctx, arg)
+			}
+
+			mux.Lock()
+			defer mux.Unlock()
+
+			order = append(order, arg)
+			return arg, nil
+		}),
+	)
+
+	ctx := context.Background()
+
+	// 第一个任务占用唯一的 worker, 其余任务在队列中等待
+	pool.Submit(ctx, 0)
+	assert.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, time.Millisecond)
+
+	pool.Submit(ctx, 1, WithPriority(1))
+	pool.Submit(ctx, 2, WithPriority(3))
+	pool.Submit(ctx, 3)
+	pool.Submit(ctx, 4, WithPriority(3))
+	pool.Submit(ctx, 5, WithPriority(2))
+
+	close(block)
+	pool.CloseAndWait()
+
+	// 优先级较大的任务先执行, 优先级相同的任务按提交顺序执行
+	assert.Equal(t, []int{2, 4, 5, 1, 3}, order)
+}
+
+// 测试任务生命周期事件
+func TestTaskPool_Hook(t *testing.T) {
+	var mux sync.Mutex
+	events := make(map[int64][]Event)
+
+	// 记录每个任务产生的事件
+	hook := func(e Event) {
+		mux.Lock()
+		defer mux.Unlock()
+
+		events[e.TaskId] = append(events[e.TaskId], e)
+	}
+
+	// 获取任务产生的事件类型
+	kinds := func(id int64) []EventKind {
+		mux.Lock()
+		defer mux.Unlock()
+
+		ks := make([]EventKind, 0)
+		for _, e := range events[id] {
+			ks = append(ks, e.Kind)
+		}
+		return ks
+	}
+
+	errTemp := errors.New("temporary")
+
+	pool := NewTaskPool(
+		1,
+		WithHandler(func(ctx context.Context, arg int) (int, error) {
+			if arg > 0 {
+				return 0, errTemp
+			}
+			return arg, nil
+		}),
+		WithRetryPolicy[int, int](RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}),
+		WithHook[int, int](hook),
+	)
+	defer pool.CloseAndWait()
+
+	ctx := context.Background()
+
+	// 任务执行成功
+	id := lastTaskId.Load() + 1
+
+	_, err := pool.Submit(ctx, 0, WithPriority(5)).Await(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []EventKind{EVENT_QUEUED, EVENT_STARTED, EVENT_SUCCEEDED}, kinds(id))
+
+	mux.Lock()
+	e := events[id][2]
+	mux.Unlock()
+
+	assert.Equal(t, 5, e.Priority)
+	assert.Equal(t, 1, e.Attempt)
+	assert.Positive(t, e.Elapsed)
+
+	// 任务执行失败并重试
+	id = lastTaskId.Load() + 1
+
+	_, err = pool.Submit(ctx, 1).Await(ctx)
+	assert.ErrorIs(t, err, errTemp)
+	assert.Equal(t, []EventKind{
+		EVENT_QUEUED,
+		EVENT_STARTED,
+		EVENT_RETRIED,
+		EVENT_STARTED,
+		EVENT_FAILED,
+	}, kinds(id))
+
+	mux.Lock()
+	retried, failed := events[id][2], events[id][4]
+	mux.Unlock()
+
+	assert.Equal(t, 1, retried.Attempt)
+	assert.Equal(t, time.Millisecond, retried.Delay)
+	assert.ErrorIs(t, retried.Err, errTemp)
+	assert.Equal(t, 2, failed.Attempt)
+	assert.ErrorIs(t, failed.Err, errTemp)
 }
@@ -0,0 +1,158 @@
+package bimap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+)
+
+var (
+	// 反序列化时, 多个 key 对应同一个 value 的错误
+	ErrDuplicateValue = errors.New("duplicate value")
+)
+
+// 确认 `BiMap` 类型实现了 JSON 序列化接口
+var (
+	_ json.Marshaler   = (*BiMap[string, int])(nil)
+	_ json.Unmarshaler = (*BiMap[string, int])(nil)
+)
+
+// 双向 map 类型
+//
+// key 和 value 之间是一一对应的关系, 既可以通过 key 查找 value, 也可以通过 value 查找 key;
+// 内部通过正向和反向两个 map 实现, 所以两个方向的查找时间复杂度均为 `O(1)`
+//
+// 和内置的 map 类型一样, key 的顺序不确定
+type BiMap[K, V comparable] struct {
+	forward map[K]V // 从 key 到 value 的 map
+	inverse map[V]K // 从 value 到 key 的 map
+}
+
+// 创建 `BiMap` 对象
+func New[K, V comparable]() *BiMap[K, V] {
+	bm := &BiMap[K, V]{}
+
+	// 初始化对象
+	bm.Init()
+	return bm
+}
+
+// 初始化 `BiMap` 对象, 清空所有的 key/value
+func (bm *BiMap[K, V]) Init() {
+	bm.forward = make(map[K]V)
+	bm.inverse = make(map[V]K)
+}
+
+// 存储一对 key/value
+//
+// 为保持一一对应的关系, 如果 key 已存在, 则其原有的 value 被删除; 如果 value 已对应其它的 key, 则该 key 被删除
+func (bm *BiMap[K, V]) Put(key K, value V) {
+	if v, ok := bm.forward[key]; ok {
+		delete(bm.inverse, v)
+	}
+	if k, ok := bm.inverse[value]; ok {
+		delete(bm.forward, k)
+	}
+
+	bm.forward[key] = value
+	bm.inverse[value] = key
+}
+
+// 当 key 和 value 均不存在时, 存储一对 key/value, 返回是否存储成功
+func (bm *BiMap[K, V]) PutIfAbsent(key K, value V) bool {
+	if bm.ContainsKey(key) || bm.ContainsValue(value) {
+		return false
+	}
+
+	bm.forward[key] = value
+	bm.inverse[value] = key
+	return true
+}
+
+// 根据 key 获取 value
+func (bm *BiMap[K, V]) Get(key K) (value V, ok bool) {
+	value, ok = bm.forward[key]
+	return
+}
+
+// 根据 value 获取 key
+func (bm *BiMap[K, V]) GetKey(value V) (key K, ok bool) {
+	key, ok = bm.inverse[value]
+	return
+}
+
+// 判断 key 是否存在
+func (bm *BiMap[K, V]) ContainsKey(key K) bool {
+	_, ok := bm.forward[key]
+	return ok
+}
+
+// 判断 value 是否存在
+func (bm *BiMap[K, V]) ContainsValue(value V) bool {
+	_, ok := bm.inverse[value]
+	return ok
+}
+
+// 根据 key 删除一对 key/value, 返回被删除的 value
+func (bm *BiMap[K, V]) Remove(key K) (value V, ok bool) {
+	if value, ok = bm.forward[key]; ok {
+		delete(bm.forward, key)
+		delete(bm.inverse, value)
+	}
+	return
+}
+
+// 根据 value 删除一对 key/value, 返回被删除的 key
+func (bm *BiMap[K, V]) RemoveValue(value V) (key K, ok bool) {
+	if key, ok = bm.inverse[value]; ok {
+		delete(bm.inverse, value)
+		delete(bm.forward, key)
+	}
+	return
+}
+
+// 获取 key/value 的个数
+func (bm *BiMap[K, V]) Len() int { return len(bm.forward) }
+
+// 获取反向的 `BiMap` 对象, 即以 value 为 key, 以 key 为 value
+//
+// 反向对象和当前对象共享数据, 对其中一个对象的修改会反映到另一个对象上
+func (bm *BiMap[K, V]) Inverse() *BiMap[V, K] {
+	return &BiMap[V, K]{forward: bm.inverse, inverse: bm.forward}
+}
+
+// 迭代所有的 key
+func (bm *BiMap[K, V]) Keys() iter.Seq[K] { return maps.Keys(bm.forward) }
+
+// 迭代所有的 value
+func (bm *BiMap[K, V]) Values() iter.Seq[V] { return maps.Keys(bm.inverse) }
+
+// 迭代所有的 key/value
+func (bm *BiMap[K, V]) All() iter.Seq2[K, V] { return maps.All(bm.forward) }
+
+// 将集合序列化为 JSON 对象
+//
+// key 的编码方式以及字段的顺序和 `encoding/json` 对 map 的处理方式一致 (按 key 编码后的字符串排序)
+func (bm *BiMap[K, V]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(bm.forward)
+}
+
+// 从 JSON 对象反序列化集合, 集合中原有的 key/value 会被清空
+//
+// 如果多个 key 对应同一个 value, 则返回 `ErrDuplicateValue` 错误
+func (bm *BiMap[K, V]) UnmarshalJSON(data []byte) error {
+	var m map[K]V
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+
+	bm.Init()
+	for k, v := range m {
+		if !bm.PutIfAbsent(k, v) {
+			return fmt.Errorf("%w: %v", ErrDuplicateValue, v)
+		}
+	}
+	return nil
+}
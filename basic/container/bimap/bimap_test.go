@@ -0,0 +1,116 @@
+package bimap
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试双向查找
+func TestBiMap_PutAndGet(t *testing.T) {
+	bm := New[string, int]()
+	bm.Put("A", 1)
+	bm.Put("B", 2)
+
+	v, ok := bm.Get("A")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	k, ok := bm.GetKey(2)
+	assert.True(t, ok)
+	assert.Equal(t, "B", k)
+
+	_, ok = bm.GetKey(3)
+	assert.False(t, ok)
+
+	assert.True(t, bm.ContainsKey("A"))
+	assert.True(t, bm.ContainsValue(1))
+	assert.Equal(t, 2, bm.Len())
+}
+
+// 测试存储 key/value 时保持一一对应的关系
+func TestBiMap_OneToOne(t *testing.T) {
+	bm := New[string, int]()
+	bm.Put("A", 1)
+	bm.Put("B", 2)
+
+	// 更新 key 的 value, 原有的 value 被删除
+	bm.Put("A", 3)
+	assert.False(t, bm.ContainsValue(1))
+
+	// value 已对应其它的 key, 该 key 被删除
+	bm.Put("C", 2)
+	assert.False(t, bm.ContainsKey("B"))
+	assert.Equal(t, map[string]int{"A": 3, "C": 2}, maps.Collect(bm.All()))
+
+	// key 或 value 存在时, 不进行存储
+	assert.False(t, bm.PutIfAbsent("A", 4))
+	assert.False(t, bm.PutIfAbsent("D", 2))
+	assert.True(t, bm.PutIfAbsent("D", 4))
+	assert.Equal(t, 3, bm.Len())
+}
+
+// 测试删除 key/value
+func TestBiMap_Remove(t *testing.T) {
+	bm := New[string, int]()
+	bm.Put("A", 1)
+	bm.Put("B", 2)
+
+	v, ok := bm.Remove("A")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.False(t, bm.ContainsValue(1))
+
+	k, ok := bm.RemoveValue(2)
+	assert.True(t, ok)
+	assert.Equal(t, "B", k)
+	assert.False(t, bm.ContainsKey("B"))
+
+	_, ok = bm.Remove("A")
+	assert.False(t, ok)
+	assert.Equal(t, 0, bm.Len())
+}
+
+// 测试反向集合
+func TestBiMap_Inverse(t *testing.T) {
+	bm := New[string, int]()
+	bm.Put("A", 1)
+
+	inv := bm.Inverse()
+	k, _ := inv.Get(1)
+	assert.Equal(t, "A", k)
+
+	// 反向集合和原集合共享数据
+	inv.Put(2, "B")
+	v, _ := bm.Get("B")
+	assert.Equal(t, 2, v)
+
+	assert.ElementsMatch(t, []string{"A", "B"}, slices.Collect(bm.Keys()))
+	assert.ElementsMatch(t, []int{1, 2}, slices.Collect(bm.Values()))
+}
+
+// 测试集合的 JSON 序列化和反序列化
+func TestBiMap_JSON(t *testing.T) {
+	bm := New[string, int]()
+	bm.Put("B", 2)
+	bm.Put("A", 1)
+
+	data, err := json.Marshal(bm)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"A":1,"B":2}`, string(data))
+
+	var v struct {
+		Codes BiMap[string, int] `json:"codes"`
+	}
+	err = json.Unmarshal([]byte(`{"codes":{"OK":200,"NotFound":404}}`), &v)
+	assert.Nil(t, err)
+	k, _ := v.Codes.GetKey(404)
+	assert.Equal(t, "NotFound", k)
+
+	// 多个 key 对应同一个 value 时返回错误
+	err = json.Unmarshal([]byte(`{"A":1,"B":1}`), bm)
+	assert.ErrorIs(t, err, ErrDuplicateValue)
+}
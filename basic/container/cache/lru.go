@@ -0,0 +1,293 @@
+package cache
+
+import (
+	"iter"
+	"study/basic/container/lists"
+	"sync"
+	"time"
+)
+
+// 定义缓存项被淘汰的原因
+type EvictReason int
+
+const (
+	EVICT_CAPACITY EvictReason = iota // 缓存项数量超过缓存容量, 最久未使用的缓存项被淘汰
+	EVICT_EXPIRED                     // 缓存项已过期
+)
+
+// 淘汰原因转字符串
+func (r EvictReason) String() string {
+	switch r {
+	case EVICT_CAPACITY:
+		return "CAPACITY"
+	case EVICT_EXPIRED:
+		return "EXPIRED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// 缓存的可选参数
+type options[K comparable, V any] struct {
+	ttl     time.Duration                            // 缓存项的默认存活时长, 为 `0` 表示永不过期
+	onEvict func(key K, value V, reason EvictReason) // 缓存项被淘汰时的回调函数
+	now     func() time.Time                         // 获取当前时间的函数
+}
+
+// 用于设置缓存可选参数的回调类型
+type Option[K comparable, V any] = func(*options[K, V])
+
+// 设置缓存项的默认存活时长
+//
+// 缓存项写入后超过该时长即过期, 过期的缓存项在被访问或调用 `RemoveExpired` 方法时被淘汰
+func WithTTL[K comparable, V any](ttl time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.ttl = ttl
+	}
+}
+
+// 设置缓存项被淘汰时的回调函数
+//
+// 回调函数在缓存的锁之外调用, 所以可以在回调函数中访问缓存; 通过 `Remove` 方法主动删除的缓存项不会触发回调
+func WithOnEvict[K comparable, V any](fn func(key K, value V, reason EvictReason)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.onEvict = fn
+	}
+}
+
+// 缓存项
+type entry[K comparable, V any] struct {
+	key      K
+	value    V
+	expireAt time.Time // 过期时间, 零值表示永不过期
+}
+
+// 判断缓存项在指定时间是否已过期
+func (e *entry[K, V]) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && !now.Before(e.expireAt)
+}
+
+// 被淘汰的缓存项, 用于在锁外调用淘汰回调函数
+type eviction[K comparable, V any] struct {
+	entry  *entry[K, V]
+	reason EvictReason
+}
+
+// 最近最少使用 (LRU) 缓存
+//
+// 缓存项按访问顺序存储在链表中, 链表首元素为最近访问的缓存项, 末尾元素为最久未访问的缓存项;
+// 当缓存项数量超过缓存容量时, 淘汰链表末尾的缓存项. 同时通过 Map 记录每个 Key 对应的链表元素,
+// 所以读写操作的时间复杂度均为 `O(1)`
+//
+// 缓存可以同时被多个 goroutine 访问
+type LRU[K comparable, V any] struct {
+	mux   sync.Mutex
+	size  int                                // 缓存容量, 为 `0` 表示不限制容量
+	list  *lists.List[*entry[K, V]]          // 按访问顺序存储缓存项的链表
+	items map[K]*lists.Element[*entry[K, V]] // 缓存项 Key 和链表元素的对应关系
+	opts  options[K, V]                      // 缓存的可选参数
+}
+
+// 创建 LRU 缓存实例
+//
+// `size` 参数为缓存的容量, 为 `0` 表示不限制缓存项的数量 (此时应通过 `WithTTL` 参数令缓存项过期)
+func NewLRU[K comparable, V any](size int, opts ...Option[K, V]) *LRU[K, V] {
+	c := &LRU[K, V]{
+		size:  max(size, 0),
+		list:  lists.New[*entry[K, V]](),
+		items: make(map[K]*lists.Element[*entry[K, V]]),
+		opts:  options[K, V]{now: time.Now},
+	}
+
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	return c
+}
+
+// 调用淘汰回调函数
+func (c *LRU[K, V]) notify(evicted []eviction[K, V]) {
+	if c.opts.onEvict == nil {
+		return
+	}
+	for _, ev := range evicted {
+		c.opts.onEvict(ev.entry.key, ev.entry.value, ev.reason)
+	}
+}
+
+// 从缓存中删除链表元素
+func (c *LRU[K, V]) remove(elem *lists.Element[*entry[K, V]]) *entry[K, V] {
+	e := c.list.Remove(elem)
+	delete(c.items, e.key)
+	return e
+}
+
+// 查找未过期的缓存项, 已过期的缓存项会被删除并记录到 `evicted` 参数中
+func (c *LRU[K, V]) lookup(key K, evicted *[]eviction[K, V]) *lists.Element[*entry[K, V]] {
+	elem, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+
+	if elem.Value.expired(c.opts.now()) {
+		*evicted = append(*evicted, eviction[K, V]{c.remove(elem), EVICT_EXPIRED})
+		return nil
+	}
+	return elem
+}
+
+// 获取缓存项的值, 并将缓存项标记为最近访问
+func (c *LRU[K, V]) Get(key K) (value V, ok bool) {
+	var evicted []eviction[K, V]
+
+	c.mux.Lock()
+	elem := c.lookup(key, &evicted)
+	if elem != nil {
+		c.list.MoveToFront(elem)
+		value, ok = elem.Value.value, true
+	}
+	c.mux.Unlock()
+
+	c.notify(evicted)
+	return
+}
+
+// 获取缓存项的值, 但不改变缓存项的访问顺序
+func (c *LRU[K, V]) Peek(key K) (value V, ok bool) {
+	var evicted []eviction[K, V]
+
+	c.mux.Lock()
+	elem := c.lookup(key, &evicted)
+	if elem != nil {
+		value, ok = elem.Value.value, true
+	}
+	c.mux.Unlock()
+
+	c.notify(evicted)
+	return
+}
+
+// 判断缓存中是否存在未过期的缓存项, 不改变缓存项的访问顺序
+func (c *LRU[K, V]) Contains(key K) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+// 写入缓存项, 缓存项使用默认的存活时长
+//
+// 如果缓存项已存在, 则更新缓存项的值和过期时间; 写入后如果缓存项数量超过缓存容量, 则淘汰最久未访问的缓存项
+func (c *LRU[K, V]) Put(key K, value V) {
+	c.PutWithTTL(key, value, c.opts.ttl)
+}
+
+// 写入缓存项, 并指定缓存项的存活时长, `ttl` 参数为 `0` 表示缓存项永不过期
+func (c *LRU[K, V]) PutWithTTL(key K, value V, ttl time.Duration) {
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = c.opts.now().Add(ttl)
+	}
+
+	var evicted []eviction[K, V]
+
+	c.mux.Lock()
+	if elem, ok := c.items[key]; ok {
+		// 更新已有的缓存项
+		elem.Value.value, elem.Value.expireAt = value, expireAt
+		c.list.MoveToFront(elem)
+	} else {
+		c.items[key] = c.list.PushFront(&entry[K, V]{key: key, value: value, expireAt: expireAt})
+
+		// 淘汰超出容量的缓存项
+		for c.size > 0 && c.list.Len() > c.size {
+			evicted = append(evicted, eviction[K, V]{c.remove(c.list.Back()), EVICT_CAPACITY})
+		}
+	}
+	c.mux.Unlock()
+
+	c.notify(evicted)
+}
+
+// 删除缓存项, 返回缓存项是否存在
+func (c *LRU[K, V]) Remove(key K) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	elem, ok := c.items[key]
+	if ok {
+		c.remove(elem)
+	}
+	return ok
+}
+
+// 删除所有已过期的缓存项, 返回删除的缓存项数量
+func (c *LRU[K, V]) RemoveExpired() int {
+	var evicted []eviction[K, V]
+
+	c.mux.Lock()
+	now := c.opts.now()
+	for elem := range c.list.Elements() {
+		if elem.Value.expired(now) {
+			evicted = append(evicted, eviction[K, V]{c.remove(elem), EVICT_EXPIRED})
+		}
+	}
+	c.mux.Unlock()
+
+	c.notify(evicted)
+	return len(evicted)
+}
+
+// 清空缓存, 不触发淘汰回调函数
+func (c *LRU[K, V]) Clear() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.list.Init()
+	clear(c.items)
+}
+
+// 获取缓存项数量
+//
+// 结果中可能包含已过期但尚未被淘汰的缓存项, 可以先调用 `RemoveExpired` 方法删除过期的缓存项
+func (c *LRU[K, V]) Len() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.list.Len()
+}
+
+// 获取缓存容量
+func (c *LRU[K, V]) Size() int { return c.size }
+
+// 获取从最近访问到最久未访问, 迭代所有未过期缓存项的迭代器
+//
+// 迭代器复制调用时的缓存项进行迭代, 迭代过程不改变缓存项的访问顺序, 且可以在迭代过程中访问缓存
+func (c *LRU[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		c.mux.Lock()
+		now := c.opts.now()
+		entries := make([]entry[K, V], 0, c.list.Len())
+		for e := range c.list.All() {
+			if !e.expired(now) {
+				entries = append(entries, *e)
+			}
+		}
+		c.mux.Unlock()
+
+		for _, e := range entries {
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// 获取从最近访问到最久未访问, 迭代所有未过期缓存项 Key 的迭代器
+func (c *LRU[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range c.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 用于测试的时钟, 可以手动调整当前时间
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// 测试写入和读取缓存
+func TestLRU_PutAndGet(t *testing.T) {
+	c := NewLRU[string, int](3)
+
+	c.Put("A", 1)
+	c.Put("B", 2)
+
+	v, ok := c.Get("A")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	_, ok = c.Get("C")
+	assert.False(t, ok)
+
+	// 更新已有的缓存项
+	c.Put("A", 10)
+	v, _ = c.Get("A")
+	assert.Equal(t, 10, v)
+	assert.Equal(t, 2, c.Len())
+
+	assert.True(t, c.Remove("A"))
+	assert.False(t, c.Remove("A"))
+	assert.False(t, c.Contains("A"))
+	assert.Equal(t, 1, c.Len())
+
+	c.Clear()
+	assert.Equal(t, 0, c.Len())
+}
+
+// 测试超出容量时淘汰最久未访问的缓存项
+func TestLRU_EvictCapacity(t *testing.T) {
+	var evicted []string
+
+	c := NewLRU(3, WithOnEvict(func(k string, v int, reason EvictReason) {
+		assert.Equal(t, EVICT_CAPACITY, reason)
+		evicted = append(evicted, k)
+	}))
+
+	c.Put("A", 1)
+	c.Put("B", 2)
+	c.Put("C", 3)
+
+	// 访问 "A", 令 "B" 成为最久未访问的缓存项
+	c.Get("A")
+
+	// 通过 Peek 访问不改变访问顺序
+	c.Peek("B")
+
+	c.Put("D", 4)
+	assert.Equal(t, []string{"B"}, evicted)
+	assert.Equal(t, []string{"D", "A", "C"}, slices.Collect(c.Keys()))
+
+	c.Put("E", 5)
+	assert.Equal(t, []string{"B", "C"}, evicted)
+	assert.Equal(t, 3, c.Len())
+}
+
+// 测试缓存项过期
+func TestLRU_TTL(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+
+	var evicted []string
+
+	c := NewLRU(0,
+		WithTTL[string, int](time.Minute),
+		WithOnEvict(func(k string, v int, reason EvictReason) {
+			assert.Equal(t, EVICT_EXPIRED, reason)
+			evicted = append(evicted, k)
+		}),
+	)
+	c.opts.now = clock.Now
+
+	c.Put("A", 1)
+	c.PutWithTTL("B", 2, 2*time.Minute)
+	c.PutWithTTL("C", 3, 0)
+
+	clock.Advance(time.Minute)
+
+	// "A" 已过期, 访问时被淘汰
+	_, ok := c.Get("A")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"A"}, evicted)
+
+	v, ok := c.Get("B")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+
+	clock.Advance(time.Minute)
+
+	// 迭代时跳过已过期的缓存项, 但不淘汰
+	assert.Equal(t, []string{"C"}, slices.Collect(c.Keys()))
+	assert.Equal(t, 2, c.Len())
+
+	// 删除所有已过期的缓存项, 永不过期的缓存项被保留
+	assert.Equal(t, 1, c.RemoveExpired())
+	assert.Equal(t, []string{"A", "B"}, evicted)
+	assert.Equal(t, 1, c.Len())
+	assert.True(t, c.Contains("C"))
+}
+
+// 测试通过迭代器遍历缓存
+func TestLRU_All(t *testing.T) {
+	c := NewLRU[string, int](0)
+
+	c.Put("A", 1)
+	c.Put("B", 2)
+	c.Put("C", 3)
+
+	keys, vals := []string{}, []int{}
+	for k, v := range c.All() {
+		keys = append(keys, k)
+		vals = append(vals, v)
+
+		// 迭代过程中可以访问缓存
+		c.Get("A")
+	}
+	assert.Equal(t, []string{"C", "B", "A"}, keys)
+	assert.Equal(t, []int{3, 2, 1}, vals)
+}
@@ -0,0 +1,205 @@
+package counter
+
+import (
+	"cmp"
+	"encoding/json"
+	"iter"
+	"slices"
+)
+
+// 确认 `Counter` 类型实现了 JSON 序列化接口
+var (
+	_ json.Marshaler   = (*Counter[string])(nil)
+	_ json.Unmarshaler = (*Counter[string])(nil)
+)
+
+// 元素的计数
+type count struct {
+	n   int    // 元素的计数值
+	seq uint64 // 元素第一次被计数的序号, 用于在计数值相同时按元素出现的顺序排列
+}
+
+// 元素及其计数值
+type Entry[T comparable] struct {
+	Value T   // 元素
+	Count int // 计数值
+}
+
+// 计数器类型, 用于统计元素出现的次数
+//
+// 类似于 Python 中的 `collections.Counter` 类型, 计数值小于等于 `0` 的元素会被删除
+type Counter[T comparable] struct {
+	m     map[T]*count // 元素和计数的对应关系
+	total int          // 所有元素计数值的和
+	seq   uint64       // 下一个新元素的序号
+}
+
+// 创建 `Counter` 对象
+func New[T comparable]() *Counter[T] {
+	c := &Counter[T]{}
+
+	// 初始化对象
+	c.Init()
+	return c
+}
+
+// 创建 `Counter` 对象, 并统计迭代器中所有元素出现的次数
+func Collect[T comparable](seq iter.Seq[T]) *Counter[T] {
+	c := New[T]()
+	for v := range seq {
+		c.AddN(v, 1)
+	}
+	return c
+}
+
+// 初始化 `Counter` 对象, 清空所有的计数
+func (c *Counter[T]) Init() {
+	c.m = make(map[T]*count)
+	c.total = 0
+	c.seq = 0
+}
+
+// 为元素的计数值加 `1`
+func (c *Counter[T]) Add(values ...T) {
+	for _, v := range values {
+		c.AddN(v, 1)
+	}
+}
+
+// 为元素的计数值加 `n`, 返回元素新的计数值
+//
+// `n` 可以为负数, 计数值小于等于 `0` 的元素会被删除
+func (c *Counter[T]) AddN(value T, n int) int {
+	cnt, ok := c.m[value]
+	if !ok {
+		if n <= 0 {
+			return 0
+		}
+
+		cnt = &count{seq: c.seq}
+		c.seq++
+		c.m[value] = cnt
+	}
+
+	// 计数值不能小于 `0`, 所以元素被删除时, 总数只减去元素原有的计数值
+	n = max(n, -cnt.n)
+	cnt.n += n
+	c.total += n
+
+	if cnt.n == 0 {
+		delete(c.m, value)
+	}
+	return cnt.n
+}
+
+// 合并另一个计数器的计数值
+func (c *Counter[T]) Merge(other *Counter[T]) {
+	for _, e := range other.MostCommon(0) {
+		c.AddN(e.Value, e.Count)
+	}
+}
+
+// 获取元素的计数值, 元素不存在时返回 `0`
+func (c *Counter[T]) Get(value T) int {
+	if cnt, ok := c.m[value]; ok {
+		return cnt.n
+	}
+	return 0
+}
+
+// 删除元素的计数, 返回元素原有的计数值
+func (c *Counter[T]) Remove(value T) int {
+	cnt, ok := c.m[value]
+	if !ok {
+		return 0
+	}
+
+	delete(c.m, value)
+	c.total -= cnt.n
+	return cnt.n
+}
+
+// 获取不同元素的个数
+func (c *Counter[T]) Len() int { return len(c.m) }
+
+// 获取所有元素计数值的和
+func (c *Counter[T]) Total() int { return c.total }
+
+// 获取计数值最大的 `n` 个元素, 按计数值从大到小排列, 计数值相同时按元素第一次出现的顺序排列
+//
+// `n` 小于等于 `0` 或大于元素个数时, 返回所有的元素
+func (c *Counter[T]) MostCommon(n int) []Entry[T] {
+	type item struct {
+		val T
+		*count
+	}
+
+	items := make([]item, 0, len(c.m))
+	for v, cnt := range c.m {
+		items = append(items, item{v, cnt})
+	}
+
+	slices.SortFunc(items, func(a, b item) int {
+		if r := cmp.Compare(b.n, a.n); r != 0 {
+			return r
+		}
+		return cmp.Compare(a.seq, b.seq)
+	})
+
+	if n <= 0 || n > len(items) {
+		n = len(items)
+	}
+
+	es := make([]Entry[T], n)
+	for i := range n {
+		es[i] = Entry[T]{Value: items[i].val, Count: items[i].n}
+	}
+	return es
+}
+
+// 迭代所有的元素
+func (c *Counter[T]) Keys() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range c.m {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// 迭代所有的元素及其计数值
+func (c *Counter[T]) All() iter.Seq2[T, int] {
+	return func(yield func(T, int) bool) {
+		for v, cnt := range c.m {
+			if !yield(v, cnt.n) {
+				return
+			}
+		}
+	}
+}
+
+// 将计数器序列化为 JSON 对象, 字段名为元素, 字段值为计数值
+//
+// 元素的编码方式以及字段的顺序和 `encoding/json` 对 map 的处理方式一致 (按元素编码后的字符串排序)
+func (c *Counter[T]) MarshalJSON() ([]byte, error) {
+	m := make(map[T]int, len(c.m))
+	for v, cnt := range c.m {
+		m[v] = cnt.n
+	}
+	return json.Marshal(m)
+}
+
+// 从 JSON 对象反序列化计数器, 计数器中原有的计数会被清空
+func (c *Counter[T]) UnmarshalJSON(data []byte) error {
+	var m map[T]int
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+
+	c.Init()
+	for v, n := range m {
+		c.AddN(v, n)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package counter
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试统计元素出现的次数
+func TestCounter_Add(t *testing.T) {
+	c := New[string]()
+	c.Add("A", "B", "A")
+
+	assert.Equal(t, 2, c.Get("A"))
+	assert.Equal(t, 1, c.Get("B"))
+	assert.Equal(t, 0, c.Get("C"))
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, 3, c.Total())
+
+	assert.Equal(t, 5, c.AddN("B", 4))
+
+	// 计数值小于等于 0 的元素会被删除
+	assert.Equal(t, 0, c.AddN("A", -3))
+	assert.Equal(t, 0, c.AddN("C", -1))
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, 5, c.Total())
+
+	assert.Equal(t, 5, c.Remove("B"))
+	assert.Equal(t, 0, c.Remove("B"))
+	assert.Equal(t, 0, c.Total())
+}
+
+// 测试获取计数值最大的元素
+func TestCounter_MostCommon(t *testing.T) {
+	c := Collect(slices.Values(strings.Split("abracadabra", "")))
+
+	assert.Equal(t, []Entry[string]{{"a", 5}, {"b", 2}, {"r", 2}}, c.MostCommon(3))
+	assert.Equal(t, []Entry[string]{{"a", 5}, {"b", 2}, {"r", 2}, {"c", 1}, {"d", 1}}, c.MostCommon(0))
+	assert.Equal(t, 5, len(c.MostCommon(10)))
+}
+
+// 测试合并计数器
+func TestCounter_Merge(t *testing.T) {
+	c1 := New[int]()
+	c1.Add(1, 2, 2)
+
+	c2 := New[int]()
+	c2.Add(2, 3)
+
+	c1.Merge(c2)
+	assert.Equal(t, map[int]int{1: 1, 2: 3, 3: 1}, maps.Collect(c1.All()))
+	assert.ElementsMatch(t, []int{1, 2, 3}, slices.Collect(c1.Keys()))
+	assert.Equal(t, 5, c1.Total())
+}
+
+// 测试计数器的 JSON 序列化和反序列化
+func TestCounter_JSON(t *testing.T) {
+	c := New[string]()
+	c.Add("go", "rust", "go")
+
+	data, err := json.Marshal(c)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"go":2,"rust":1}`, string(data))
+
+	var v struct {
+		Hits Counter[int] `json:"hits"`
+	}
+	err = json.Unmarshal([]byte(`{"hits":{"200":10,"404":2,"500":0}}`), &v)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, v.Hits.Get(200))
+	assert.Equal(t, 2, v.Hits.Len())
+	assert.Equal(t, 12, v.Hits.Total())
+}
@@ -0,0 +1,314 @@
+// 持久化的哈希数组映射前缀树 (Hash Array Mapped Trie)
+//
+// 用于 `sets.Set` 以及 `orderedmap.OrderedMap` 的快照: 每次修改只复制从根节点到被修改节点路径上的节点 (路径复制),
+// 其余节点在新旧版本之间共享, 所以旧版本的内容永远不会改变, 可以作为快照被多个 goroutine 同时读取
+package hamt
+
+import (
+	"hash/maphash"
+	"iter"
+	"math/bits"
+)
+
+const (
+	BITS  = 5         // 每层节点消耗的哈希值位数
+	WIDTH = 1 << BITS // 每个节点最多的槽位数
+	MASK  = WIDTH - 1 // 从哈希值中获取槽位下标的掩码
+)
+
+// 所有 `Map` 共用的哈希种子
+var seed = maphash.MakeSeed()
+
+// 节点中的槽位
+//
+// 槽位中存储的可能是一对 key/value, 也可能是子节点 (`child` 字段不为 `nil`)
+type entry[K comparable, V any] struct {
+	hash  uint64      // key 的哈希值; 子节点为冲突节点时, 即冲突节点中所有 key 的哈希值
+	key   K           // 存储的 key
+	value V           // 存储的 value
+	child *node[K, V] // 子节点
+}
+
+// 树节点
+//
+// 普通节点通过位图记录哪些槽位被占用, `entries` 字段只存储被占用的槽位, 所以节点的大小和实际元素个数成正比;
+// 哈希值完全相同的多个 key 存储在冲突节点中, 冲突节点中的槽位只存储 key/value, 按顺序查找
+type node[K comparable, V any] struct {
+	bitmap    uint32        // 槽位的占用位图
+	entries   []entry[K, V] // 被占用的槽位
+	collision bool          // 是否为冲突节点
+}
+
+// 持久化的 Map 类型
+//
+// 该类型的值不可修改, `Set` 和 `Delete` 方法返回修改后的新 Map, 原 Map 保持不变; 新旧 Map 共享未修改的节点,
+// 所以每次修改的时间和内存开销均为 `O(log n)` (树的深度, 以 32 为底)
+//
+// 零值即为空 Map, 可以直接使用
+type Map[K comparable, V any] struct {
+	root *node[K, V] // 根节点, 为 `nil` 表示 Map 为空
+	size int         // 存储 key 的个数
+}
+
+// 获取存储 key 的个数
+func (m Map[K, V]) Len() int { return m.size }
+
+// 根据 key 获取 value
+func (m Map[K, V]) Get(key K) (V, bool) { return m.get(maphash.Comparable(seed, key), key) }
+
+// 存储一对 key/value, 返回修改后的新 Map
+func (m Map[K, V]) Set(key K, value V) Map[K, V] {
+	return m.set(entry[K, V]{hash: maphash.Comparable(seed, key), key: key, value: value})
+}
+
+// 删除一个 key, 返回修改后的新 Map; key 不存在时返回原 Map
+func (m Map[K, V]) Delete(key K) Map[K, V] { return m.delete(maphash.Comparable(seed, key), key) }
+
+// 根据哈希值为 `h` 的 key 获取 value
+func (m Map[K, V]) get(h uint64, key K) (value V, ok bool) {
+	n := m.root
+	for shift := uint(0); n != nil; shift += BITS {
+		if n.collision {
+			if i := n.find(key); i >= 0 {
+				return n.entries[i].value, true
+			}
+			return
+		}
+
+		bit := bitOf(h, shift)
+		if n.bitmap&bit == 0 {
+			return
+		}
+
+		e := &n.entries[n.index(bit)]
+		if e.child == nil {
+			if e.hash == h && e.key == key {
+				return e.value, true
+			}
+			return
+		}
+		n = e.child
+	}
+	return
+}
+
+// 存储 `e` 参数中的 key/value
+func (m Map[K, V]) set(e entry[K, V]) Map[K, V] {
+	root := m.root
+	if root == nil {
+		root = &node[K, V]{}
+	}
+
+	root, added := root.set(0, e)
+	if added {
+		m.size++
+	}
+	m.root = root
+	return m
+}
+
+// 删除哈希值为 `h` 的 key
+func (m Map[K, V]) delete(h uint64, key K) Map[K, V] {
+	if m.root == nil {
+		return m
+	}
+
+	root, removed := m.root.delete(0, h, key)
+	if !removed {
+		return m
+	}
+
+	m.size--
+	if m.size == 0 {
+		root = nil
+	}
+	m.root = root
+	return m
+}
+
+// 迭代所有的 key/value, 迭代的顺序不确定
+func (m Map[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m.root != nil {
+			m.root.walk(yield)
+		}
+	}
+}
+
+// 迭代所有的 key, 迭代的顺序不确定
+func (m Map[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range m.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// 获取哈希值在 `shift` 位移量对应的层中所在槽位的位图
+func bitOf(h uint64, shift uint) uint32 {
+	return 1 << (h >> shift & MASK)
+}
+
+// 获取位图中 `bit` 位对应的槽位在 `entries` 字段中的下标, 即位图中低于 `bit` 位的 1 的个数
+func (n *node[K, V]) index(bit uint32) int {
+	return bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+// 在冲突节点中查找 key 所在的下标, 不存在时返回 `-1`
+func (n *node[K, V]) find(key K) int {
+	for i := range n.entries {
+		if n.entries[i].key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// 复制节点, 并为 `entries` 字段预留 `extra` 个额外的槽位
+func (n *node[K, V]) clone(extra int) *node[K, V] {
+	c := &node[K, V]{bitmap: n.bitmap, collision: n.collision}
+	c.entries = make([]entry[K, V], len(n.entries), len(n.entries)+extra)
+	copy(c.entries, n.entries)
+	return c
+}
+
+// 将 key/value 存入以当前节点为根的子树, 返回复制后的新节点以及是否新增了 key
+//
+// `shift` 参数为当前节点所在层的位移量, `e` 参数为要存储的 key/value 及 key 的哈希值
+func (n *node[K, V]) set(shift uint, e entry[K, V]) (*node[K, V], bool) {
+	if n.collision {
+		// 哈希值不同的 key 不能放入冲突节点, 需以当前节点和新 key 构建新的子树
+		if n.entries[0].hash != e.hash {
+			return merge(shift, entry[K, V]{hash: n.entries[0].hash, child: n}, e), true
+		}
+
+		c := n.clone(1)
+		if i := n.find(e.key); i >= 0 {
+			c.entries[i] = e
+			return c, false
+		}
+		c.entries = append(c.entries, e)
+		return c, true
+	}
+
+	bit := bitOf(e.hash, shift)
+	i := n.index(bit)
+
+	// 槽位为空, 直接插入
+	if n.bitmap&bit == 0 {
+		c := n.clone(1)
+		c.bitmap |= bit
+		c.entries = append(c.entries, entry[K, V]{})
+		copy(c.entries[i+1:], c.entries[i:])
+		c.entries[i] = e
+		return c, true
+	}
+
+	c := n.clone(0)
+	old := &c.entries[i]
+
+	switch {
+	case old.child != nil:
+		// 槽位中为子节点, 递归存入子节点
+		child, added := old.child.set(shift+BITS, e)
+		old.child = child
+		return c, added
+	case old.hash == e.hash && old.key == e.key:
+		// key 已存在, 更新其 value
+		old.value = e.value
+		return c, false
+	default:
+		// 槽位被其它 key 占用, 将两个 key 放入新的子节点 (哈希值相同时为冲突节点, 所以保留哈希值)
+		*old = entry[K, V]{hash: old.hash, child: merge(shift+BITS, *old, e)}
+		return c, true
+	}
+}
+
+// 以两个槽位构建新的子树, `shift` 参数为子树根节点所在层的位移量
+//
+// 槽位可以是 key/value, 也可以是冲突节点; 哈希值完全相同的两个 key 放入冲突节点
+func merge[K comparable, V any](shift uint, a, b entry[K, V]) *node[K, V] {
+	if a.hash == b.hash {
+		return &node[K, V]{entries: []entry[K, V]{a, b}, collision: true}
+	}
+
+	ba, bb := bitOf(a.hash, shift), bitOf(b.hash, shift)
+
+	// 两个哈希值在当前层位于同一个槽位, 继续在下一层区分
+	if ba == bb {
+		return &node[K, V]{bitmap: ba, entries: []entry[K, V]{{child: merge(shift+BITS, a, b)}}}
+	}
+
+	if ba > bb {
+		a, b = b, a
+	}
+	return &node[K, V]{bitmap: ba | bb, entries: []entry[K, V]{a, b}}
+}
+
+// 从以当前节点为根的子树中删除 key, 返回复制后的新节点以及 key 是否存在
+//
+// 删除后如果子节点只剩一个 key/value (或一个冲突节点), 则将其上移到父节点的槽位中, 令树的结构保持紧凑
+func (n *node[K, V]) delete(shift uint, h uint64, key K) (*node[K, V], bool) {
+	if n.collision {
+		i := n.find(key)
+		if i < 0 {
+			return n, false
+		}
+
+		c := &node[K, V]{entries: make([]entry[K, V], 0, len(n.entries)-1), collision: true}
+		c.entries = append(c.entries, n.entries[:i]...)
+		c.entries = append(c.entries, n.entries[i+1:]...)
+		return c, true
+	}
+
+	bit := bitOf(h, shift)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+
+	i := n.index(bit)
+	e := n.entries[i]
+
+	if e.child == nil {
+		if e.hash != h || e.key != key {
+			return n, false
+		}
+
+		// 删除槽位
+		c := &node[K, V]{bitmap: n.bitmap &^ bit, entries: make([]entry[K, V], 0, len(n.entries)-1)}
+		c.entries = append(c.entries, n.entries[:i]...)
+		c.entries = append(c.entries, n.entries[i+1:]...)
+		return c, true
+	}
+
+	child, removed := e.child.delete(shift+BITS, h, key)
+	if !removed {
+		return n, false
+	}
+
+	c := n.clone(0)
+	if len(child.entries) == 1 && (child.collision || child.entries[0].child == nil || child.entries[0].child.collision) {
+		// 子节点只剩一个槽位, 将其上移
+		c.entries[i] = child.entries[0]
+	} else {
+		c.entries[i].child = child
+	}
+	return c, true
+}
+
+// 迭代以当前节点为根的子树中所有的 key/value, 返回是否需要继续迭代
+func (n *node[K, V]) walk(yield func(K, V) bool) bool {
+	for i := range n.entries {
+		e := &n.entries[i]
+		if e.child != nil {
+			if !e.child.walk(yield) {
+				return false
+			}
+		} else if !yield(e.key, e.value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,157 @@
+package hamt
+
+import (
+	"maps"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试存储, 获取和删除 key/value
+func TestMap_SetGetDelete(t *testing.T) {
+	var m Map[string, int]
+	assert.Equal(t, 0, m.Len())
+
+	m = m.Set("A", 1).Set("B", 2).Set("C", 3)
+	assert.Equal(t, 3, m.Len())
+
+	v, ok := m.Get("B")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+
+	// 更新已存在的 key
+	m = m.Set("B", 20)
+	assert.Equal(t, 3, m.Len())
+
+	v, _ = m.Get("B")
+	assert.Equal(t, 20, v)
+
+	// 删除不存在的 key
+	assert.Equal(t, m, m.Delete("X"))
+
+	m = m.Delete("A")
+	assert.Equal(t, 2, m.Len())
+
+	_, ok = m.Get("A")
+	assert.False(t, ok)
+	assert.Equal(t, map[string]int{"B": 20, "C": 3}, maps.Collect(m.All()))
+
+	// 删除全部 key 后, Map 为空
+	m = m.Delete("B").Delete("C")
+	assert.Equal(t, Map[string, int]{}, m)
+}
+
+// 测试修改 Map 后, 之前的版本保持不变
+func TestMap_Persistent(t *testing.T) {
+	var m Map[int, int]
+	for i := range 1000 {
+		m = m.Set(i, i)
+	}
+
+	old := m
+	for i := range 500 {
+		m = m.Delete(i * 2)
+	}
+	m = m.Set(1, -1)
+
+	assert.Equal(t, 1000, old.Len())
+	for i := range 1000 {
+		v, ok := old.Get(i)
+		assert.True(t, ok)
+		assert.Equal(t, i, v)
+	}
+
+	assert.Equal(t, 500, m.Len())
+
+	v, _ := m.Get(1)
+	assert.Equal(t, -1, v)
+
+	_, ok := m.Get(2)
+	assert.False(t, ok)
+}
+
+// 测试随机的修改操作, 结果和内置 map 一致
+func TestMap_Random(t *testing.T) {
+	var m Map[int, int]
+	ref := make(map[int]int)
+
+	for i := range 20000 {
+		k := rand.IntN(2000)
+		if rand.IntN(3) == 0 {
+			m = m.Delete(k)
+			delete(ref, k)
+		} else {
+			m = m.Set(k, i)
+			ref[k] = i
+		}
+	}
+
+	assert.Equal(t, len(ref), m.Len())
+	assert.Equal(t, ref, maps.Collect(m.All()))
+}
+
+// 测试哈希值冲突的 key
+func TestMap_Collision(t *testing.T) {
+	const h = 0x1234
+
+	var m Map[string, int]
+	m = m.set(entry[string, int]{hash: h, key: "A", value: 1})
+	m = m.set(entry[string, int]{hash: h, key: "B", value: 2})
+
+	// 和冲突节点位于同一个槽位, 但哈希值不同的 key
+	m = m.set(entry[string, int]{hash: h | 1<<40, key: "C", value: 3})
+	assert.Equal(t, 3, m.Len())
+
+	for k, v := range map[string]int{"A": 1, "B": 2} {
+		r, ok := m.get(h, k)
+		assert.True(t, ok)
+		assert.Equal(t, v, r)
+	}
+
+	r, ok := m.get(h|1<<40, "C")
+	assert.True(t, ok)
+	assert.Equal(t, 3, r)
+
+	_, ok = m.get(h, "C")
+	assert.False(t, ok)
+
+	// 更新冲突节点中的 key
+	m = m.set(entry[string, int]{hash: h, key: "A", value: 10})
+	assert.Equal(t, 3, m.Len())
+
+	r, _ = m.get(h, "A")
+	assert.Equal(t, 10, r)
+
+	// 删除冲突节点中的 key, 剩余的 key 仍可获取
+	old := m
+	m = m.delete(h, "B")
+	assert.Equal(t, 2, m.Len())
+	assert.Equal(t, map[string]int{"A": 10, "C": 3}, maps.Collect(m.All()))
+
+	r, ok = m.get(h, "A")
+	assert.True(t, ok)
+	assert.Equal(t, 10, r)
+
+	m = m.delete(h|1<<40, "C").delete(h, "A")
+	assert.Equal(t, 0, m.Len())
+
+	// 之前的版本不受影响
+	assert.Equal(t, map[string]int{"A": 10, "B": 2, "C": 3}, maps.Collect(old.All()))
+}
+
+// 测试迭代过程中提前结束
+func TestMap_AllBreak(t *testing.T) {
+	var m Map[int, int]
+	for i := range 100 {
+		m = m.Set(i, i)
+	}
+
+	n := 0
+	for range m.Keys() {
+		if n++; n == 10 {
+			break
+		}
+	}
+	assert.Equal(t, 10, n)
+}
@@ -0,0 +1,287 @@
+package lists
+
+import "iter"
+
+// 链表元素类型
+type Element[T any] struct {
+	next, prev *Element[T] // 前后元素指针
+	list       *List[T]    // 元素所属的链表, 元素被删除后为 `nil`
+
+	Value T // 元素存储的值
+}
+
+// 获取链表的下一个元素, 如果当前元素为链表末尾元素, 则返回 `nil`
+func (e *Element[T]) Next() *Element[T] {
+	if n := e.next; e.list != nil && n != &e.list.root {
+		return n
+	}
+	return nil
+}
+
+// 获取链表的前一个元素, 如果当前元素为链表首元素, 则返回 `nil`
+func (e *Element[T]) Prev() *Element[T] {
+	if p := e.prev; e.list != nil && p != &e.list.root {
+		return p
+	}
+	return nil
+}
+
+// 泛型双向链表类型
+//
+// 与标准库 `container/list` 包的 `List` 类型具备相同的结构和方法, 但元素值为具体类型, 无需进行类型断言,
+// 也避免了将值存储为 `any` 类型带来的额外内存分配
+//
+// 链表通过一个哨兵元素 (`root`) 连接为环形, `root.next` 为链表首元素, `root.prev` 为链表末尾元素;
+// 链表的零值为一个可以直接使用的空链表
+type List[T any] struct {
+	root Element[T] // 哨兵元素, 不存储值
+	len  int        // 链表长度 (不包括哨兵元素)
+}
+
+// 创建链表实例
+func New[T any]() *List[T] { return new(List[T]).Init() }
+
+// 创建链表实例, 并按顺序添加参数中的元素
+func Of[T any](values ...T) *List[T] {
+	l := New[T]()
+	for _, v := range values {
+		l.PushBack(v)
+	}
+	return l
+}
+
+// 创建链表实例, 并按顺序添加迭代器中的元素
+func Collect[T any](seq iter.Seq[T]) *List[T] {
+	l := New[T]()
+	for v := range seq {
+		l.PushBack(v)
+	}
+	return l
+}
+
+// 初始化 (清空) 链表
+func (l *List[T]) Init() *List[T] {
+	l.root.next = &l.root
+	l.root.prev = &l.root
+	l.len = 0
+	return l
+}
+
+// 延迟初始化链表, 令链表的零值可以直接使用
+func (l *List[T]) lazyInit() {
+	if l.root.next == nil {
+		l.Init()
+	}
+}
+
+// 获取链表长度
+func (l *List[T]) Len() int { return l.len }
+
+// 获取链表首元素, 链表为空时返回 `nil`
+func (l *List[T]) Front() *Element[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.next
+}
+
+// 获取链表末尾元素, 链表为空时返回 `nil`
+func (l *List[T]) Back() *Element[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.prev
+}
+
+// 将元素 `e` 插入到元素 `at` 之后
+func (l *List[T]) insert(e, at *Element[T]) *Element[T] {
+	e.prev = at
+	e.next = at.next
+	e.prev.next = e
+	e.next.prev = e
+	e.list = l
+	l.len++
+	return e
+}
+
+// 将值插入到元素 `at` 之后, 返回新元素
+func (l *List[T]) insertValue(v T, at *Element[T]) *Element[T] {
+	return l.insert(&Element[T]{Value: v}, at)
+}
+
+// 从链表中删除元素
+func (l *List[T]) remove(e *Element[T]) {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.next = nil // 避免内存泄漏
+	e.prev = nil
+	e.list = nil
+	l.len--
+}
+
+// 将元素 `e` 移动到元素 `at` 之后
+func (l *List[T]) move(e, at *Element[T]) {
+	if e == at {
+		return
+	}
+	e.prev.next = e.next
+	e.next.prev = e.prev
+
+	e.prev = at
+	e.next = at.next
+	e.prev.next = e
+	e.next.prev = e
+}
+
+// 删除链表元素, 返回元素的值
+//
+// 如果元素不属于当前链表, 则链表不发生变化
+func (l *List[T]) Remove(e *Element[T]) T {
+	if e.list == l {
+		l.remove(e)
+	}
+	return e.Value
+}
+
+// 在链表开头添加一个元素, 返回新元素
+func (l *List[T]) PushFront(v T) *Element[T] {
+	l.lazyInit()
+	return l.insertValue(v, &l.root)
+}
+
+// 在链表末尾添加一个元素, 返回新元素
+func (l *List[T]) PushBack(v T) *Element[T] {
+	l.lazyInit()
+	return l.insertValue(v, l.root.prev)
+}
+
+// 在元素 `mark` 之前插入一个元素, 返回新元素
+//
+// 如果 `mark` 不属于当前链表, 则链表不发生变化, 返回 `nil`
+func (l *List[T]) InsertBefore(v T, mark *Element[T]) *Element[T] {
+	if mark.list != l {
+		return nil
+	}
+	return l.insertValue(v, mark.prev)
+}
+
+// 在元素 `mark` 之后插入一个元素, 返回新元素
+//
+// 如果 `mark` 不属于当前链表, 则链表不发生变化, 返回 `nil`
+func (l *List[T]) InsertAfter(v T, mark *Element[T]) *Element[T] {
+	if mark.list != l {
+		return nil
+	}
+	return l.insertValue(v, mark)
+}
+
+// 将元素移动到链表开头
+//
+// 如果元素不属于当前链表, 则链表不发生变化
+func (l *List[T]) MoveToFront(e *Element[T]) {
+	if e.list != l || l.root.next == e {
+		return
+	}
+	l.move(e, &l.root)
+}
+
+// 将元素移动到链表末尾
+//
+// 如果元素不属于当前链表, 则链表不发生变化
+func (l *List[T]) MoveToBack(e *Element[T]) {
+	if e.list != l || l.root.prev == e {
+		return
+	}
+	l.move(e, l.root.prev)
+}
+
+// 将元素 `e` 移动到元素 `mark` 之前
+//
+// 如果 `e` 或 `mark` 不属于当前链表, 或 `e` 和 `mark` 为同一个元素, 则链表不发生变化
+func (l *List[T]) MoveBefore(e, mark *Element[T]) {
+	if e.list != l || e == mark || mark.list != l {
+		return
+	}
+	l.move(e, mark.prev)
+}
+
+// 将元素 `e` 移动到元素 `mark` 之后
+//
+// 如果 `e` 或 `mark` 不属于当前链表, 或 `e` 和 `mark` 为同一个元素, 则链表不发生变化
+func (l *List[T]) MoveAfter(e, mark *Element[T]) {
+	if e.list != l || e == mark || mark.list != l {
+		return
+	}
+	l.move(e, mark)
+}
+
+// 在链表末尾添加另一个链表的所有元素, 两个链表可以为同一个链表
+func (l *List[T]) PushBackList(other *List[T]) {
+	l.lazyInit()
+	for i, e := other.Len(), other.Front(); i > 0; i, e = i-1, e.Next() {
+		l.insertValue(e.Value, l.root.prev)
+	}
+}
+
+// 在链表开头添加另一个链表的所有元素, 两个链表可以为同一个链表
+func (l *List[T]) PushFrontList(other *List[T]) {
+	l.lazyInit()
+	for i, e := other.Len(), other.Back(); i > 0; i, e = i-1, e.Prev() {
+		l.insertValue(e.Value, &l.root)
+	}
+}
+
+// 获取从链表首元素开始, 按顺序迭代所有元素值的迭代器
+func (l *List[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for e := l.Front(); e != nil; {
+			next := e.Next()
+			if !yield(e.Value) {
+				return
+			}
+			e = next
+		}
+	}
+}
+
+// 获取从链表末尾元素开始, 按逆序迭代所有元素值的迭代器
+func (l *List[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for e := l.Back(); e != nil; {
+			prev := e.Prev()
+			if !yield(e.Value) {
+				return
+			}
+			e = prev
+		}
+	}
+}
+
+// 获取从链表首元素开始, 按顺序迭代所有元素的迭代器
+//
+// 迭代过程中可以删除或移动当前元素 (已提前获取下一个元素)
+func (l *List[T]) Elements() iter.Seq[*Element[T]] {
+	return func(yield func(*Element[T]) bool) {
+		for e := l.Front(); e != nil; {
+			next := e.Next()
+			if !yield(e) {
+				return
+			}
+			e = next
+		}
+	}
+}
+
+// 将链表转为切片
+func (l *List[T]) Slice() []T {
+	s := make([]T, 0, l.len)
+	for v := range l.All() {
+		s = append(s, v)
+	}
+	return s
+}
+
+// 将链表元素反转, 返回新链表
+func (l *List[T]) Reverse() *List[T] {
+	return Collect(l.Backward())
+}
@@ -0,0 +1,144 @@
+package lists
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试创建泛型链表实例
+func TestGenericList_New(t *testing.T) {
+	l := New[int]()
+	assert.Equal(t, 0, l.Len())
+	assert.Nil(t, l.Front())
+	assert.Nil(t, l.Back())
+
+	// 链表的零值可以直接使用
+	var zl List[int]
+	zl.PushBack(1)
+	assert.Equal(t, []int{1}, zl.Slice())
+
+	l = Of(1, 2, 3)
+	assert.Equal(t, []int{1, 2, 3}, l.Slice())
+
+	l = Collect(slices.Values([]int{3, 2, 1}))
+	assert.Equal(t, []int{3, 2, 1}, l.Slice())
+}
+
+// 测试在链表开头和末尾添加元素
+func TestGenericList_Push(t *testing.T) {
+	l := New[int]()
+
+	e := l.PushBack(1)
+	assert.Equal(t, 1, e.Value)
+
+	e = l.PushFront(2)
+	assert.Equal(t, 2, e.Value)
+
+	l.PushBack(3)
+	assert.Equal(t, 3, l.Len())
+	assert.Equal(t, []int{2, 1, 3}, l.Slice())
+
+	assert.Equal(t, 2, l.Front().Value)
+	assert.Equal(t, 3, l.Back().Value)
+	assert.Equal(t, 1, l.Front().Next().Value)
+	assert.Equal(t, 1, l.Back().Prev().Value)
+	assert.Nil(t, l.Front().Prev())
+	assert.Nil(t, l.Back().Next())
+}
+
+// 测试在指定元素前后插入元素
+func TestGenericList_Insert(t *testing.T) {
+	l := Of("Hello", "World")
+
+	e := l.InsertBefore("OK", l.Back())
+	assert.Equal(t, "OK", e.Value)
+	assert.Equal(t, []string{"Hello", "OK", "World"}, l.Slice())
+
+	e = l.InsertAfter("Bye", l.Back())
+	assert.Equal(t, "Bye", e.Value)
+	assert.Equal(t, []string{"Hello", "OK", "World", "Bye"}, l.Slice())
+
+	// 在不属于当前链表的元素前后插入, 链表不发生变化
+	other := Of("A")
+	assert.Nil(t, l.InsertBefore("X", other.Front()))
+	assert.Nil(t, l.InsertAfter("X", other.Front()))
+	assert.Equal(t, 4, l.Len())
+}
+
+// 测试移动链表元素
+func TestGenericList_Move(t *testing.T) {
+	l := Of(1, 2, 3, 4)
+	e := l.Front().Next()
+
+	l.MoveToFront(e)
+	assert.Equal(t, []int{2, 1, 3, 4}, l.Slice())
+
+	l.MoveToBack(e)
+	assert.Equal(t, []int{1, 3, 4, 2}, l.Slice())
+
+	l.MoveBefore(e, l.Front())
+	assert.Equal(t, []int{2, 1, 3, 4}, l.Slice())
+
+	l.MoveAfter(e, l.Back())
+	assert.Equal(t, []int{1, 3, 4, 2}, l.Slice())
+
+	// 移动到自身前后, 链表不发生变化
+	l.MoveBefore(e, e)
+	l.MoveAfter(e, e)
+	assert.Equal(t, []int{1, 3, 4, 2}, l.Slice())
+}
+
+// 测试删除链表元素
+func TestGenericList_Remove(t *testing.T) {
+	l := Of(1, 2, 3)
+	e := l.Front().Next()
+
+	assert.Equal(t, 2, l.Remove(e))
+	assert.Equal(t, []int{1, 3}, l.Slice())
+
+	// 元素删除后不再属于链表, 重复删除不影响链表
+	assert.Nil(t, e.Next())
+	assert.Nil(t, e.Prev())
+	l.Remove(e)
+	assert.Equal(t, 2, l.Len())
+
+	// 清空链表
+	l.Init()
+	assert.Equal(t, 0, l.Len())
+	assert.Nil(t, l.Front())
+}
+
+// 测试在链表开头或末尾添加另一个链表的所有元素
+func TestGenericList_PushList(t *testing.T) {
+	l := Of(1, 2)
+
+	l.PushBackList(Of(3, 4))
+	assert.Equal(t, []int{1, 2, 3, 4}, l.Slice())
+
+	l.PushFrontList(Of(-1, 0))
+	assert.Equal(t, []int{-1, 0, 1, 2, 3, 4}, l.Slice())
+
+	// 添加链表自身的元素
+	l = Of(1, 2)
+	l.PushBackList(l)
+	assert.Equal(t, []int{1, 2, 1, 2}, l.Slice())
+}
+
+// 测试通过迭代器遍历链表
+func TestGenericList_Iterator(t *testing.T) {
+	l := Of(1, 2, 3, 4)
+
+	assert.Equal(t, []int{1, 2, 3, 4}, slices.Collect(l.All()))
+	assert.Equal(t, []int{4, 3, 2, 1}, slices.Collect(l.Backward()))
+	assert.Equal(t, []int{4, 3, 2, 1}, l.Reverse().Slice())
+
+	// 迭代过程中删除元素
+	for e := range l.Elements() {
+		if e.Value%2 == 0 {
+			l.Remove(e)
+		}
+	}
+	assert.Equal(t, []int{1, 3}, l.Slice())
+}
@@ -3,6 +3,8 @@ package lists
 import "container/list"
 
 // 将切片转化为列表, 返回列表指针
+//
+// Deprecated: 使用泛型链表 `List` 类型代替, 通过 `Of(slice...)` 函数创建链表
 func FromSlice[T any](slice []T) *list.List {
 	l := list.New()
 	for _, v := range slice {
@@ -12,6 +14,8 @@ func FromSlice[T any](slice []T) *list.List {
 }
 
 // 将列表转化为切片
+//
+// Deprecated: 使用泛型链表 `List` 类型代替, 通过 `List.Slice` 方法转为切片
 func ToSlice[T any](l *list.List) []T {
 	// 生成一个 cap 为列表长度的空切片
 	slice := make([]T, 0, l.Len())
@@ -25,6 +29,8 @@ func ToSlice[T any](l *list.List) []T {
 }
 
 // 将列表元素反转, 返回新列表
+//
+// Deprecated: 使用泛型链表 `List` 类型代替, 通过 `List.Reverse` 方法反转链表
 func Reverse(l *list.List) *list.List {
 	rl := list.New()
 	for iter := l.Back(); iter != nil; iter = iter.Prev() {
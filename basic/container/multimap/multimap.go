@@ -0,0 +1,262 @@
+package multimap
+
+import (
+	"encoding/json"
+	"iter"
+	"slices"
+	"study/basic/container/sets"
+)
+
+// 确认 `MultiMap` 类型实现了 JSON 序列化接口
+var (
+	_ json.Marshaler   = (*MultiMap[string, int])(nil)
+	_ json.Unmarshaler = (*MultiMap[string, int])(nil)
+)
+
+// 存储同一个 key 对应的所有 value 的容器
+type bucket[V comparable] interface {
+	add(v V) bool      // 添加 value, 返回是否添加成功
+	remove(v V) bool   // 删除 value, 返回是否删除成功
+	contains(v V) bool // 判断 value 是否存在
+	len() int          // 获取 value 的个数
+	all() iter.Seq[V]  // 迭代所有的 value
+	marshal() any      // 获取用于 JSON 序列化的值
+}
+
+// 以切片存储 value, 按添加顺序排列, 允许重复的 value
+type listBucket[V comparable] struct {
+	vals []V
+}
+
+func (b *listBucket[V]) add(v V) bool {
+	b.vals = append(b.vals, v)
+	return true
+}
+
+// 删除第一个等于 `v` 参数的 value
+func (b *listBucket[V]) remove(v V) bool {
+	if i := slices.Index(b.vals, v); i >= 0 {
+		b.vals = slices.Delete(b.vals, i, i+1)
+		return true
+	}
+	return false
+}
+
+func (b *listBucket[V]) contains(v V) bool { return slices.Contains(b.vals, v) }
+
+func (b *listBucket[V]) len() int { return len(b.vals) }
+
+func (b *listBucket[V]) all() iter.Seq[V] { return slices.Values(b.vals) }
+
+func (b *listBucket[V]) marshal() any { return b.vals }
+
+// 以 `sets.Set` 集合存储 value, 不允许重复的 value, 且 value 无序
+type setBucket[V comparable] struct {
+	vals *sets.Set[V]
+}
+
+func (b *setBucket[V]) add(v V) bool {
+	if b.vals.Contains(v) {
+		return false
+	}
+	b.vals.Add(v)
+	return true
+}
+
+func (b *setBucket[V]) remove(v V) bool {
+	if !b.vals.Contains(v) {
+		return false
+	}
+	b.vals.Remove(v)
+	return true
+}
+
+func (b *setBucket[V]) contains(v V) bool { return b.vals.Contains(v) }
+
+func (b *setBucket[V]) len() int { return b.vals.Len() }
+
+func (b *setBucket[V]) all() iter.Seq[V] { return b.vals.All() }
+
+func (b *setBucket[V]) marshal() any { return b.vals }
+
+// 一个 key 可以对应多个 value 的 map 类型
+//
+// 相当于 `map[K][]V` 类型, 但会自动创建和删除 key 对应的 value 容器 (例如 key 的最后一个 value 被删除后, key 也随之删除);
+// 通过 `NewSetValued` 函数创建的实例, 同一个 key 的 value 不会重复 (相当于 `map[K]sets.Set[V]` 类型)
+//
+// 和内置的 map 类型一样, key 的顺序不确定
+type MultiMap[K, V comparable] struct {
+	m      map[K]bucket[V] // 存储 key 对应的 value 容器
+	size   int             // 所有 key/value 对的个数
+	unique bool            // 同一个 key 的 value 是否不允许重复
+}
+
+// 创建 `MultiMap` 对象, 同一个 key 的 value 按添加顺序排列, 且可以重复
+func New[K, V comparable]() *MultiMap[K, V] {
+	mm := &MultiMap[K, V]{}
+
+	// 初始化对象
+	mm.Init()
+	return mm
+}
+
+// 创建 `MultiMap` 对象, 同一个 key 的 value 不会重复, 且 value 无序
+func NewSetValued[K, V comparable]() *MultiMap[K, V] {
+	mm := &MultiMap[K, V]{unique: true}
+
+	// 初始化对象
+	mm.Init()
+	return mm
+}
+
+// 初始化 `MultiMap` 对象, 清空所有的 key/value, 但保持 value 的存储方式
+func (mm *MultiMap[K, V]) Init() {
+	mm.m = make(map[K]bucket[V])
+	mm.size = 0
+}
+
+// 创建一个新的 value 容器
+func (mm *MultiMap[K, V]) newBucket() bucket[V] {
+	if mm.unique {
+		return &setBucket[V]{vals: sets.New[V]()}
+	}
+	return &listBucket[V]{}
+}
+
+// 为 key 添加一个或多个 value
+//
+// 对于 value 不允许重复的实例, 已存在的 value 会被忽略
+func (mm *MultiMap[K, V]) Put(key K, values ...V) {
+	if len(values) == 0 {
+		return
+	}
+
+	b, ok := mm.m[key]
+	if !ok {
+		b = mm.newBucket()
+		mm.m[key] = b
+	}
+
+	for _, v := range values {
+		if b.add(v) {
+			mm.size++
+		}
+	}
+}
+
+// 获取 key 对应的所有 value, key 不存在时返回 `nil`
+//
+// 返回的切片是 value 的副本, 修改切片不影响集合
+func (mm *MultiMap[K, V]) Get(key K) []V {
+	b, ok := mm.m[key]
+	if !ok {
+		return nil
+	}
+	return slices.AppendSeq(make([]V, 0, b.len()), b.all())
+}
+
+// 判断 key 是否存在
+func (mm *MultiMap[K, V]) Contains(key K) bool {
+	_, ok := mm.m[key]
+	return ok
+}
+
+// 判断 key/value 对是否存在
+func (mm *MultiMap[K, V]) ContainsEntry(key K, value V) bool {
+	b, ok := mm.m[key]
+	return ok && b.contains(value)
+}
+
+// 删除 key 的一个 value, 返回 value 是否存在
+//
+// 对于 value 可以重复的实例, 只删除第一个相等的 value; 如果 key 已没有 value, 则删除 key
+func (mm *MultiMap[K, V]) Remove(key K, value V) bool {
+	b, ok := mm.m[key]
+	if !ok || !b.remove(value) {
+		return false
+	}
+
+	mm.size--
+	if b.len() == 0 {
+		delete(mm.m, key)
+	}
+	return true
+}
+
+// 删除 key 及其所有的 value, 返回被删除的 value
+func (mm *MultiMap[K, V]) RemoveAll(key K) []V {
+	vals := mm.Get(key)
+	if vals != nil {
+		delete(mm.m, key)
+		mm.size -= len(vals)
+	}
+	return vals
+}
+
+// 获取所有 key/value 对的个数
+func (mm *MultiMap[K, V]) Len() int { return mm.size }
+
+// 获取 key 的个数
+func (mm *MultiMap[K, V]) KeyLen() int { return len(mm.m) }
+
+// 迭代所有的 key
+func (mm *MultiMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range mm.m {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// 迭代所有的 key/value 对, 同一个 key 会和它的每个 value 组合迭代一次
+func (mm *MultiMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, b := range mm.m {
+			for v := range b.all() {
+				if !yield(k, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// 迭代所有的 key 及其 value 切片 (value 的副本)
+func (mm *MultiMap[K, V]) Buckets() iter.Seq2[K, []V] {
+	return func(yield func(K, []V) bool) {
+		for k := range mm.m {
+			if !yield(k, mm.Get(k)) {
+				return
+			}
+		}
+	}
+}
+
+// 将集合序列化为 JSON 对象, 每个 key 对应一个 value 数组
+//
+// key 的编码方式以及字段的顺序和 `encoding/json` 对 map 的处理方式一致 (按 key 编码后的字符串排序)
+func (mm *MultiMap[K, V]) MarshalJSON() ([]byte, error) {
+	m := make(map[K]any, len(mm.m))
+	for k, b := range mm.m {
+		m[k] = b.marshal()
+	}
+	return json.Marshal(m)
+}
+
+// 从 JSON 对象反序列化集合, 集合中原有的 key/value 会被清空
+//
+// 对于零值的集合 (例如作为结构体字段反序列化时), value 可以重复
+func (mm *MultiMap[K, V]) UnmarshalJSON(data []byte) error {
+	var m map[K][]V
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+
+	mm.Init()
+	for k, vals := range m {
+		mm.Put(k, vals...)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package multimap
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试添加和获取 key/value
+func TestMultiMap_PutAndGet(t *testing.T) {
+	mm := New[string, int]()
+
+	mm.Put("A", 1, 2)
+	mm.Put("A", 2)
+	mm.Put("B", 3)
+	mm.Put("C")
+
+	assert.Equal(t, 4, mm.Len())
+	assert.Equal(t, 2, mm.KeyLen())
+	assert.Equal(t, []int{1, 2, 2}, mm.Get("A"))
+	assert.Equal(t, []int{3}, mm.Get("B"))
+	assert.Nil(t, mm.Get("C"))
+
+	assert.True(t, mm.Contains("A"))
+	assert.False(t, mm.Contains("C"))
+	assert.True(t, mm.ContainsEntry("A", 2))
+	assert.False(t, mm.ContainsEntry("B", 2))
+
+	// 修改返回的切片不影响集合
+	mm.Get("A")[0] = 100
+	assert.Equal(t, []int{1, 2, 2}, mm.Get("A"))
+}
+
+// 测试 value 不重复的集合
+func TestMultiMap_SetValued(t *testing.T) {
+	mm := NewSetValued[string, int]()
+
+	mm.Put("A", 1, 2, 2)
+	mm.Put("A", 1)
+	assert.Equal(t, 2, mm.Len())
+	assert.ElementsMatch(t, []int{1, 2}, mm.Get("A"))
+
+	assert.True(t, mm.Remove("A", 1))
+	assert.False(t, mm.Remove("A", 1))
+	assert.Equal(t, []int{2}, mm.Get("A"))
+}
+
+// 测试删除 key/value
+func TestMultiMap_Remove(t *testing.T) {
+	mm := New[string, int]()
+	mm.Put("A", 1, 2, 1)
+	mm.Put("B", 3)
+
+	// 只删除第一个相等的 value
+	assert.True(t, mm.Remove("A", 1))
+	assert.Equal(t, []int{2, 1}, mm.Get("A"))
+	assert.False(t, mm.Remove("A", 3))
+	assert.False(t, mm.Remove("C", 1))
+
+	// 删除 key 的最后一个 value 后, key 也被删除
+	assert.True(t, mm.Remove("B", 3))
+	assert.False(t, mm.Contains("B"))
+
+	assert.Equal(t, []int{2, 1}, mm.RemoveAll("A"))
+	assert.Nil(t, mm.RemoveAll("A"))
+	assert.Equal(t, 0, mm.Len())
+	assert.Equal(t, 0, mm.KeyLen())
+}
+
+// 测试通过迭代器遍历集合
+func TestMultiMap_Iterator(t *testing.T) {
+	mm := New[string, int]()
+	mm.Put("A", 1, 2)
+	mm.Put("B", 3)
+
+	assert.ElementsMatch(t, []string{"A", "B"}, slices.Collect(mm.Keys()))
+	assert.Equal(t, map[string][]int{"A": {1, 2}, "B": {3}}, maps.Collect(mm.Buckets()))
+
+	var entries []string
+	for k, v := range mm.All() {
+		entries = append(entries, k+string(rune('0'+v)))
+	}
+	assert.ElementsMatch(t, []string{"A1", "A2", "B3"}, entries)
+}
+
+// 测试集合的 JSON 序列化和反序列化
+func TestMultiMap_JSON(t *testing.T) {
+	mm := New[string, int]()
+	mm.Put("B", 3, 1)
+	mm.Put("A", 2)
+
+	data, err := json.Marshal(mm)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"A":[2],"B":[3,1]}`, string(data))
+
+	// value 不重复的集合, value 数组按确定的顺序排列
+	smm := NewSetValued[int, string]()
+	smm.Put(1, "b", "a")
+
+	data, err = json.Marshal(smm)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"1":["a","b"]}`, string(data))
+
+	// 反序列化时保持集合的 value 存储方式
+	err = json.Unmarshal([]byte(`{"1":["x","x","y"]}`), smm)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, smm.Len())
+	assert.ElementsMatch(t, []string{"x", "y"}, smm.Get(1))
+
+	// 反序列化零值集合
+	var v struct {
+		Tags MultiMap[string, string] `json:"tags"`
+	}
+	err = json.Unmarshal([]byte(`{"tags":{"go":["a","a"]}}`), &v)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "a"}, v.Tags.Get("go"))
+}
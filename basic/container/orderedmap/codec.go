@@ -0,0 +1,238 @@
+package orderedmap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 确认 `OrderedMap` 类型实现了各类序列化接口
+var (
+	_ json.Marshaler   = (*OrderedMap[string, int])(nil)
+	_ json.Unmarshaler = (*OrderedMap[string, int])(nil)
+	_ yaml.Marshaler   = (*OrderedMap[string, int])(nil)
+	_ yaml.Unmarshaler = (*OrderedMap[string, int])(nil)
+	_ gob.GobEncoder   = (*OrderedMap[string, int])(nil)
+	_ gob.GobDecoder   = (*OrderedMap[string, int])(nil)
+)
+
+// 将 key 编码为 JSON 对象的字段名
+//
+// 和 `encoding/json` 对 map key 的处理方式一致: 字符串类型直接使用, 其次使用 `encoding.TextMarshaler` 接口,
+// 最后将整数类型转为十进制字符串, 其它类型的 key 无法编码
+func encodeKey[K any](key K) (string, error) {
+	rv := reflect.ValueOf(&key).Elem()
+	if rv.Kind() == reflect.String {
+		return rv.String(), nil
+	}
+
+	if tm, ok := any(key).(encoding.TextMarshaler); ok {
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("orderedmap: unsupported key type %v", rv.Type())
+}
+
+// 将 JSON 对象的字段名解码为 key, 是 `encodeKey` 函数的逆过程
+func decodeKey[K any](s string) (key K, err error) {
+	rv := reflect.ValueOf(&key).Elem()
+	if rv.Kind() == reflect.String {
+		rv.SetString(s)
+		return
+	}
+
+	if tu, ok := any(&key).(encoding.TextUnmarshaler); ok {
+		err = tu.UnmarshalText([]byte(s))
+		return
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		if n, err = strconv.ParseInt(s, 10, rv.Type().Bits()); err == nil {
+			rv.SetInt(n)
+		}
+		return
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		if n, err = strconv.ParseUint(s, 10, rv.Type().Bits()); err == nil {
+			rv.SetUint(n)
+		}
+		return
+	}
+
+	err = fmt.Errorf("orderedmap: unsupported key type %v", rv.Type())
+	return
+}
+
+// 将集合序列化为 JSON 对象, 对象字段的顺序即集合中 key 的顺序
+func (sm *OrderedMap[K, V]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for k, v := range sm.All() {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+
+		// 序列化 key, 字段名需要按 JSON 字符串进行转义
+		ks, err := encodeKey(k)
+		if err != nil {
+			return nil, err
+		}
+
+		kb, err := json.Marshal(ks)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+
+		// 序列化 value
+		vb, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// 从 JSON 对象反序列化集合
+//
+// JSON 对象中的 key/value 依次存入集合, 集合中已有的 key/value 会被保留或覆盖 (和 `encoding/json` 对 map 的处理方式一致);
+// 零值的集合按 key 的自然顺序排列, 需要保持 JSON 对象中字段的顺序时, 应预先通过 `NewLinked` 函数创建集合
+func (sm *OrderedMap[K, V]) UnmarshalJSON(data []byte) error {
+	// `null` 不改变集合
+	if string(data) == "null" {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	// 读取对象的起始符号
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return fmt.Errorf("orderedmap: cannot unmarshal %v into object", tok)
+	}
+
+	for dec.More() {
+		// 读取字段名并转为 key
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		key, err := decodeKey[K](tok.(string))
+		if err != nil {
+			return err
+		}
+
+		// 读取字段值并转为 value
+		var val V
+		if err := dec.Decode(&val); err != nil {
+			return err
+		}
+		sm.Put(key, val)
+	}
+
+	// 读取对象的结束符号
+	_, err = dec.Token()
+	return err
+}
+
+// 将集合序列化为 YAML 映射节点, 映射中 key 的顺序即集合中 key 的顺序
+func (sm *OrderedMap[K, V]) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for k, v := range sm.All() {
+		var kn, vn yaml.Node
+		if err := kn.Encode(k); err != nil {
+			return nil, err
+		}
+		if err := vn.Encode(v); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &kn, &vn)
+	}
+	return node, nil
+}
+
+// 从 YAML 映射节点反序列化集合, 处理方式和 `UnmarshalJSON` 方法一致
+func (sm *OrderedMap[K, V]) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("orderedmap: cannot unmarshal %v into mapping", node.Tag)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var (
+			key K
+			val V
+		)
+		if err := node.Content[i].Decode(&key); err != nil {
+			return err
+		}
+		if err := node.Content[i+1].Decode(&val); err != nil {
+			return err
+		}
+		sm.Put(key, val)
+	}
+	return nil
+}
+
+// 用于 gob 序列化的集合内容
+type gobEntries[K, V any] struct {
+	Keys   []K
+	Values []V
+}
+
+// 将集合序列化为 gob 字节串, 按集合中 key 的顺序存储 key/value
+func (sm *OrderedMap[K, V]) GobEncode() ([]byte, error) {
+	entries := gobEntries[K, V]{
+		Keys:   make([]K, 0, sm.Len()),
+		Values: make([]V, 0, sm.Len()),
+	}
+	for k, v := range sm.All() {
+		entries.Keys = append(entries.Keys, k)
+		entries.Values = append(entries.Values, v)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entries); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// 从 gob 字节串反序列化集合, 处理方式和 `UnmarshalJSON` 方法一致
+func (sm *OrderedMap[K, V]) GobDecode(data []byte) error {
+	var entries gobEntries[K, V]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entries); err != nil {
+		return err
+	}
+	if len(entries.Keys) != len(entries.Values) {
+		return fmt.Errorf("orderedmap: %d keys but %d values", len(entries.Keys), len(entries.Values))
+	}
+
+	for i, k := range entries.Keys {
+		sm.Put(k, entries.Values[i])
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package orderedmap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"net/netip"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// 测试将集合序列化为 JSON 对象, 字段的顺序即 key 的顺序
+func TestOrderedMap_MarshalJSON(t *testing.T) {
+	sm := New[int, string]()
+	sm.Put(100, "B")
+	sm.Put(1000, "C")
+	sm.Put(1, "D")
+
+	data, err := json.Marshal(sm)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"1":"D","100":"B","1000":"C"}`, string(data))
+
+	// 按插入顺序排列的集合
+	lm := NewLinked[string, []int]()
+	lm.Put("z", []int{1})
+	lm.Put("a\"b", nil)
+
+	data, err = json.Marshal(lm)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"z":[1],"a\"b":null}`, string(data))
+
+	// 空集合
+	data, err = json.Marshal(New[int, int]())
+	assert.NoError(t, err)
+	assert.Equal(t, `{}`, string(data))
+
+	// 不支持的 key 类型
+	fm := NewLinked[float64, int]()
+	fm.Put(1.5, 1)
+
+	_, err = json.Marshal(fm)
+	assert.Error(t, err)
+}
+
+// 测试从 JSON 对象反序列化集合
+func TestOrderedMap_UnmarshalJSON(t *testing.T) {
+	// 反序列化到按 key 排序的集合
+	sm := New[int, string]()
+
+	err := json.Unmarshal([]byte(`{"100":"B","1000":"C","1":"D"}`), sm)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 100, 1000}, slices.Collect(sm.Keys()))
+	assert.Equal(t, []string{"D", "B", "C"}, sm.Values())
+
+	// 作为结构体字段时, 零值的集合按 key 的自然顺序排列
+	var cfg struct {
+		Env OrderedMap[string, int] `json:"env"`
+	}
+
+	err = json.Unmarshal([]byte(`{"env":{"b":1,"c":2,"a":3}}`), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, slices.Collect(cfg.Env.Keys()))
+
+	// 预先创建按插入顺序排列的集合, 则按 JSON 中字段的顺序排列 key
+	var lcfg struct {
+		Env *OrderedMap[string, int] `json:"env"`
+	}
+	lcfg.Env = NewLinked[string, int]()
+
+	err = json.Unmarshal([]byte(`{"env":{"b":1,"c":2,"a":3}}`), &lcfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c", "a"}, slices.Collect(lcfg.Env.Keys()))
+
+	// 序列化的结果和原始 JSON 一致
+	data, err := json.Marshal(&lcfg)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"env":{"b":1,"c":2,"a":3}}`, string(data))
+
+	// 错误的 JSON
+	assert.Error(t, json.Unmarshal([]byte(`[1,2]`), sm))
+	assert.Error(t, json.Unmarshal([]byte(`{"x":"A"}`), sm))
+	assert.Error(t, json.Unmarshal([]byte(`{"1":1}`), sm))
+}
+
+// 测试以实现了 `encoding.TextMarshaler` 接口的类型作为 key
+func TestOrderedMap_TextMarshalerKey(t *testing.T) {
+	sm := NewFunc[netip.Addr, string](func(a, b netip.Addr) int { return a.Compare(b) })
+	sm.Put(netip.MustParseAddr("10.0.0.2"), "B")
+	sm.Put(netip.MustParseAddr("10.0.0.1"), "A")
+
+	data, err := json.Marshal(sm)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"10.0.0.1":"A","10.0.0.2":"B"}`, string(data))
+
+	am := NewLinked[netip.Addr, string]()
+	err = json.Unmarshal(data, am)
+	assert.NoError(t, err)
+
+	v, ok := am.Get(netip.MustParseAddr("10.0.0.2"))
+	assert.True(t, ok)
+	assert.Equal(t, "B", v)
+}
+
+// 测试 YAML 序列化和反序列化
+func TestOrderedMap_YAML(t *testing.T) {
+	lm := NewLinked[string, int]()
+	lm.Put("z", 1)
+	lm.Put("a", 2)
+	lm.Put("m", 3)
+
+	data, err := yaml.Marshal(lm)
+	assert.NoError(t, err)
+	assert.Equal(t, "z: 1\na: 2\nm: 3\n", string(data))
+
+	var cfg struct {
+		Env OrderedMap[string, int] `yaml:"env"`
+	}
+
+	err = yaml.Unmarshal([]byte("env:\n  b: 1\n  a: 2\n"), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, slices.Collect(cfg.Env.Keys()))
+
+	// 类型不匹配
+	err = yaml.Unmarshal([]byte("env: [1, 2]\n"), &cfg)
+	assert.Error(t, err)
+}
+
+// 测试 gob 序列化和反序列化
+func TestOrderedMap_Gob(t *testing.T) {
+	lm := NewLinked[string, int]()
+	lm.Put("z", 1)
+	lm.Put("a", 2)
+
+	buf := bytes.NewBuffer(nil)
+	err := gob.NewEncoder(buf).Encode(lm)
+	assert.NoError(t, err)
+
+	am := NewLinked[string, int]()
+	err = gob.NewDecoder(buf).Decode(am)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"z", "a"}, slices.Collect(am.Keys()))
+	assert.Equal(t, []int{1, 2}, am.Values())
+
+	// 零值的集合按 key 的自然顺序排列
+	buf.Reset()
+	err = gob.NewEncoder(buf).Encode(lm)
+	assert.NoError(t, err)
+
+	var sm OrderedMap[string, int]
+	err = gob.NewDecoder(buf).Decode(&sm)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "z"}, slices.Collect(sm.Keys()))
+}
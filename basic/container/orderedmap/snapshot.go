@@ -0,0 +1,76 @@
+package orderedmap
+
+import (
+	"encoding/json"
+	"iter"
+)
+
+// 确认 `Snapshot` 类型实现了序列化接口
+var (
+	_ json.Marshaler = (*Snapshot[string, int])(nil)
+)
+
+// `OrderedMap` 的只读快照
+//
+// 快照即集合在创建时的版本: 集合的每次修改只复制修改路径上的节点并生成新的版本, 未修改的节点和快照共享,
+// 所以创建快照的时间复杂度为 `O(1)`, 之后修改集合的开销和未创建快照时相同, 且快照的内容永远不会改变
+//
+// 由于快照不可修改, 所以可以被多个 goroutine 同时读取而无需加锁; 典型的用法是由一个 goroutine 修改集合并定期生成快照,
+// 通过 `snapshot.Holder` 类型发布给其它 goroutine 读取
+type Snapshot[K comparable, V any] struct {
+	m OrderedMap[K, V]
+}
+
+// 获取集合的只读快照
+//
+// 该方法只读取集合的当前版本, 不会修改集合, 所以可以和修改集合的操作同时进行 (参见 `BenchmarkOrderedMap_PutAfterSnapshot`)
+func (sm *OrderedMap[K, V]) Snapshot() *Snapshot[K, V] {
+	ss := new(Snapshot[K, V])
+	ss.m.cur.Store(sm.load())
+	return ss
+}
+
+// 获取快照中 key 的个数
+func (ss *Snapshot[K, V]) Len() int { return ss.m.Len() }
+
+// 根据 key 获取 value
+func (ss *Snapshot[K, V]) Get(key K) (V, bool) { return ss.m.Get(key) }
+
+// 按顺序迭代所有的 key
+func (ss *Snapshot[K, V]) Keys() iter.Seq[K] { return ss.m.Keys() }
+
+// 按顺序迭代所有的 key/value
+func (ss *Snapshot[K, V]) All() iter.Seq2[K, V] { return ss.m.All() }
+
+// 按顺序获取所有的 value
+func (ss *Snapshot[K, V]) Values() []V { return ss.m.Values() }
+
+// 按逆序迭代所有的 key/value
+func (ss *Snapshot[K, V]) Backward() iter.Seq2[K, V] { return ss.m.Backward() }
+
+// 获取第一个 key/value
+func (ss *Snapshot[K, V]) First() (K, V, bool) { return ss.m.First() }
+
+// 获取最后一个 key/value
+func (ss *Snapshot[K, V]) Last() (K, V, bool) { return ss.m.Last() }
+
+// 获取小于等于 `key` 参数的最大 key 及其 value, 按插入顺序排列时总是返回 `false`
+func (ss *Snapshot[K, V]) Floor(key K) (K, V, bool) { return ss.m.Floor(key) }
+
+// 获取大于等于 `key` 参数的最小 key 及其 value, 按插入顺序排列时总是返回 `false`
+func (ss *Snapshot[K, V]) Ceiling(key K) (K, V, bool) { return ss.m.Ceiling(key) }
+
+// 迭代 key 在 `[from, to)` 范围内的 key/value
+func (ss *Snapshot[K, V]) Range(from, to K) iter.Seq2[K, V] { return ss.m.Range(from, to) }
+
+// 基于快照创建一个可修改的集合
+//
+// 新集合和快照共享节点, 创建的时间复杂度为 `O(1)`, 所以可以基于当前快照低成本的构建下一个版本
+func (ss *Snapshot[K, V]) OrderedMap() *OrderedMap[K, V] {
+	m := new(OrderedMap[K, V])
+	m.cur.Store(ss.m.load())
+	return m
+}
+
+// 将快照序列化为 JSON 对象
+func (ss *Snapshot[K, V]) MarshalJSON() ([]byte, error) { return ss.m.MarshalJSON() }
@@ -0,0 +1,172 @@
+package orderedmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试快照的内容不受集合之后修改的影响
+func TestOrderedMap_Snapshot(t *testing.T) {
+	sm := New[int, string]()
+	for i := range 100 {
+		sm.Put(i, "A")
+	}
+
+	ss := sm.Snapshot()
+
+	// 修改集合: 更新, 删除和添加 key
+	sm.Put(0, "B")
+	sm.Remove(1)
+	sm.Put(100, "C")
+
+	// 快照的内容保持不变
+	assert.Equal(t, 100, ss.Len())
+	v, ok := ss.Get(0)
+	assert.True(t, ok)
+	assert.Equal(t, "A", v)
+	_, ok = ss.Get(1)
+	assert.True(t, ok)
+	_, ok = ss.Get(100)
+	assert.False(t, ok)
+
+	k, _, _ := ss.Last()
+	assert.Equal(t, 99, k)
+	k, _, _ = ss.Floor(1000)
+	assert.Equal(t, 99, k)
+
+	// 修改后的集合依然可以正常查找, 正序和逆序遍历
+	assert.Equal(t, 100, sm.Len())
+	v, _ = sm.Get(0)
+	assert.Equal(t, "B", v)
+	assert.Equal(t, 100, len(slices.Collect(sm.Keys())))
+	assert.Equal(t, []int{100, 99, 98}, slices.Collect(limit(keysOf(sm.Backward()), 3)))
+	assert.Equal(t, []int{0, 2, 3}, slices.Collect(limit(sm.Keys(), 3)))
+}
+
+// 测试按插入顺序排列时的快照
+func TestOrderedMap_SnapshotLinked(t *testing.T) {
+	sm := NewLinked[string, int]()
+	sm.Put("C", 1)
+	sm.Put("A", 2)
+
+	ss := sm.Snapshot()
+
+	sm.Remove("C")
+	sm.Put("B", 3)
+
+	assert.Equal(t, []string{"C", "A"}, slices.Collect(ss.Keys()))
+	assert.Equal(t, []string{"A", "B"}, slices.Collect(sm.Keys()))
+
+	// 更新 value 只影响集合, 不影响快照
+	sm.Put("A", 20)
+	v, _ := ss.Get("A")
+	assert.Equal(t, 2, v)
+	v, _ = sm.Get("A")
+	assert.Equal(t, 20, v)
+
+	data, err := json.Marshal(ss)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"C":1,"A":2}`, string(data))
+}
+
+// 测试基于快照构建新的集合
+func TestOrderedMap_SnapshotOrderedMap(t *testing.T) {
+	sm := New[int, int]()
+	sm.Put(1, 1)
+	sm.Put(2, 2)
+
+	ss := sm.Snapshot()
+
+	// 基于快照构建的集合和快照互不影响
+	next := ss.OrderedMap()
+	next.Put(3, 3)
+	assert.Equal(t, []int{1, 2, 3}, slices.Collect(next.Keys()))
+	assert.Equal(t, []int{1, 2}, slices.Collect(ss.Keys()))
+
+	// 原集合同样不受影响
+	sm.Remove(1)
+	assert.Equal(t, []int{2}, slices.Collect(sm.Keys()))
+	assert.Equal(t, []int{1, 2}, slices.Collect(ss.Keys()))
+	assert.Equal(t, []int{1, 2, 3}, slices.Collect(next.Keys()))
+}
+
+// 测试在修改集合的同时创建快照, 每个快照的内容都是完整的某个版本
+func TestOrderedMap_SnapshotConcurrent(t *testing.T) {
+	sm := New[int, int]()
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for i := range 1000 {
+			sm.Put(i, i)
+		}
+	})
+	wg.Go(func() {
+		for range 1000 {
+			ss := sm.Snapshot()
+
+			// 快照中的 key 为 `[0, n)`
+			n := ss.Len()
+			assert.Len(t, ss.Values(), n)
+			if k, _, ok := ss.Last(); ok {
+				assert.Equal(t, n-1, k)
+			}
+		}
+	})
+	wg.Wait()
+
+	assert.Equal(t, 1000, sm.Len())
+}
+
+// 获取 key/value 迭代器中的 key
+func keysOf[K, V any](seq iter.Seq2[K, V]) iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range seq {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// 获取迭代器的前 `n` 个元素
+func limit[T any](seq iter.Seq[T], n int) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range seq {
+			if n <= 0 || !yield(v) {
+				return
+			}
+			n--
+		}
+	}
+}
+
+// 测试不同集合大小下, 创建快照后修改集合的开销
+//
+// 每次修改只复制修改路径上的节点, 开销随集合大小对数增长, 且和不创建快照时相同
+func BenchmarkOrderedMap_PutAfterSnapshot(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		sm := New[int, int]()
+		for i := range size {
+			sm.Put(i, i)
+		}
+
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			for i := range b.N {
+				sm.Snapshot()
+				sm.Put(i%size, i)
+			}
+		})
+
+		b.Run(fmt.Sprintf("size=%d/no-snapshot", size), func(b *testing.B) {
+			for i := range b.N {
+				sm.Put(i%size, i)
+			}
+		})
+	}
+}
@@ -2,126 +2,544 @@ package orderedmap
 
 import (
 	"cmp"
-	"slices"
+	"fmt"
+	"iter"
+	"math/rand/v2"
+	"reflect"
+	"study/basic/container/internal/hamt"
+	"sync/atomic"
+	"unsafe"
 )
 
-// 利用 map 和 slice 组合一个 key 有序的 map 类型
+// 树节点
+//
+// 节点一旦加入树中就不再修改, 修改树时复制从根节点到被修改节点路径上的节点
+type node[K, V any] struct {
+	key   K           // 节点的 key
+	value V           // 节点的 value
+	seq   uint64      // 按插入顺序排列时, key 的插入序号
+	prio  uint32      // 节点的随机优先级, 父节点的优先级不小于子节点
+	left  *node[K, V] // 左子节点, 其子树中的节点均排在当前节点之前
+	right *node[K, V] // 右子节点, 其子树中的节点均排在当前节点之后
+}
+
+// 集合的一个版本, 创建后不再修改, 所以可以被多个 goroutine 同时读取
+type version[K comparable, V any] struct {
+	root  *node[K, V]         // 树的根节点, 为 `nil` 表示集合为空
+	size  int                 // 存储 key 的个数
+	cmp   func(a, b K) int    // key 的比较函数, 按插入顺序排列时为 `nil`
+	index hamt.Map[K, uint64] // 按插入顺序排列时, 从 key 到插入序号的索引
+	seq   uint64              // 按插入顺序排列时, 下一个 key 的插入序号
+
+	linked bool // 是否按 key 的插入顺序排列, 只有 `NewLinked` 函数创建的对象为 `true`
+}
+
+// 利用持久化的树堆 (treap) 实现一个 key 有序的 map 类型
+//
+// 树堆是按 key 排序的二叉查找树, 同时每个节点具有一个随机的优先级, 且父节点的优先级不小于子节点 (即按优先级构成堆),
+// 随机的优先级令树的期望深度为 `O(log n)`, 所以查找, 插入和删除的时间复杂度均为 `O(log n)`, 且可以方便的进行正序和逆序遍历以及范围查找
+//
+// 树是持久化的: 每次修改只复制从根节点到被修改节点路径上的节点, 生成集合的新版本, 其余节点在新旧版本之间共享,
+// 所以之前的版本永远不会改变, 创建快照只需获取当前版本 (参见 `Snapshot` 方法)
+//
+// 除按 key 排序外, 还支持按 key 的插入顺序排列 (即 Java 中 `LinkedHashMap` 的方式), 此时树按 key 的插入序号排序,
+// 并通过持久化的 `hamt.Map` 索引 key 的插入序号
+//
+// 集合的当前版本通过原子指针保存, 所以读取集合以及创建快照可以和一个 goroutine 的修改操作同时进行,
+// 但多个 goroutine 同时修改集合时仍需自行加锁
+//
+// 注意: 零值对象可以直接使用, 此时按 key 的自然顺序排列, 所以 key 的底层类型需满足 `cmp.Ordered` 约束;
+// 按插入顺序排列的对象只能通过 `NewLinked` 函数创建
 //
 // 定义结构体
-type OrderedMap[K cmp.Ordered, V any] struct {
-	m      map[K]V // map 对象
-	s      []K     // 保存有序 key 集合的 slice 对象
-	sorted bool    // s 字段是否已排序
+type OrderedMap[K comparable, V any] struct {
+	cur atomic.Pointer[version[K, V]] // 集合的当前版本, 为 `nil` 表示零值对象
 }
 
-// 创建 `OrderedMap` 对象
+// 创建 `OrderedMap` 对象, 按 key 的自然顺序排列
 func New[K cmp.Ordered, V any]() *OrderedMap[K, V] {
+	return NewFunc[K, V](cmp.Compare[K])
+}
+
+// 创建 `OrderedMap` 对象, 按 `cmp` 参数指定的比较函数排列 key
+//
+// `cmp` 参数的返回值含义和 `slices.SortFunc` 函数的比较函数一致, 比较结果为 `0` 的 key 被视为同一个 key,
+// 可用于以结构体等不满足 `cmp.Ordered` 约束的类型作为 key
+func NewFunc[K comparable, V any](cmp func(a, b K) int) *OrderedMap[K, V] {
 	sm := &OrderedMap[K, V]{}
+	sm.cur.Store(&version[K, V]{cmp: cmp})
+	return sm
+}
 
-	// 初始化对象
-	sm.Init()
+// 创建 `OrderedMap` 对象, 按 key 的插入顺序排列
+//
+// 更新已存在 key 的 value 不会改变 key 的顺序, 删除后重新存储的 key 则排列在最后
+func NewLinked[K comparable, V any]() *OrderedMap[K, V] {
+	sm := &OrderedMap[K, V]{}
+	sm.cur.Store(&version[K, V]{linked: true})
 	return sm
 }
 
-// 初始化 `OrderedMap` 对象
+// 初始化 `OrderedMap` 对象, 清空所有的 key/value, 但保持 key 的排列方式
+//
+// 对零值对象调用该方法, 得到按 key 的自然顺序排列的对象 (和 `New` 函数相同), 如果 key 的底层类型不满足 `cmp.Ordered` 约束, 则 panic
 func (sm *OrderedMap[K, V]) Init() {
-	// 创建 map 对象
-	sm.m = make(map[K]V)
-	// 创建 slice 对象
-	sm.s = make([]K, 0)
-	// 一开始 key 数量为 0, 所以默认已排序
-	sm.sorted = true
+	v := sm.load()
+	v.mustOrdered()
+
+	sm.cur.Store(&version[K, V]{cmp: v.cmp, linked: v.linked})
+}
+
+// 获取集合的当前版本, 零值对象返回按 key 的自然顺序排列的空版本
+func (sm *OrderedMap[K, V]) load() *version[K, V] {
+	if v := sm.cur.Load(); v != nil {
+		return v
+	}
+	return &version[K, V]{cmp: naturalCompare[K]()}
+}
+
+// 确认集合可以排列 key, 即按插入顺序排列, 或具备 key 的比较函数
+func (v *version[K, V]) mustOrdered() {
+	if !v.linked && v.cmp == nil {
+		panic(fmt.Sprintf("orderedmap: key type %v is not ordered", reflect.TypeFor[K]()))
+	}
+}
+
+// 获取 key 类型自然顺序的比较函数, key 的底层类型不满足 `cmp.Ordered` 约束时返回 `nil`
+//
+// 零值对象无法通过类型约束得到比较函数, 所以按 key 的底层类型选择, 以支持 `type ID int` 这类自定义类型
+func naturalCompare[K comparable]() func(a, b K) int {
+	switch reflect.TypeFor[K]().Kind() {
+	case reflect.Int:
+		return compareAs[K, int]
+	case reflect.Int8:
+		return compareAs[K, int8]
+	case reflect.Int16:
+		return compareAs[K, int16]
+	case reflect.Int32:
+		return compareAs[K, int32]
+	case reflect.Int64:
+		return compareAs[K, int64]
+	case reflect.Uint:
+		return compareAs[K, uint]
+	case reflect.Uint8:
+		return compareAs[K, uint8]
+	case reflect.Uint16:
+		return compareAs[K, uint16]
+	case reflect.Uint32:
+		return compareAs[K, uint32]
+	case reflect.Uint64:
+		return compareAs[K, uint64]
+	case reflect.Uintptr:
+		return compareAs[K, uintptr]
+	case reflect.Float32:
+		return compareAs[K, float32]
+	case reflect.Float64:
+		return compareAs[K, float64]
+	case reflect.String:
+		return compareAs[K, string]
+	}
+	return nil
+}
+
+// 按底层类型 `T` 比较两个 key, 调用方需保证 `K` 的底层类型即为 `T`
+func compareAs[K comparable, T cmp.Ordered](a, b K) int {
+	return cmp.Compare(*(*T)(unsafe.Pointer(&a)), *(*T)(unsafe.Pointer(&b)))
+}
+
+// 比较 key 和节点的先后顺序, 按插入顺序排列时比较插入序号 `seq`
+func (v *version[K, V]) compare(key K, seq uint64, n *node[K, V]) int {
+	if v.linked {
+		return cmp.Compare(seq, n.seq)
+	}
+	return v.cmp(key, n.key)
+}
+
+// 查找 key 对应的节点, 不存在时返回 `nil`
+func (v *version[K, V]) find(key K) *node[K, V] {
+	var seq uint64
+	if v.linked {
+		var ok bool
+		if seq, ok = v.index.Get(key); !ok {
+			return nil
+		}
+	}
+
+	for x := v.root; x != nil; {
+		switch c := v.compare(key, seq, x); {
+		case c < 0:
+			x = x.left
+		case c > 0:
+			x = x.right
+		default:
+			return x
+		}
+	}
+	return nil
+}
+
+// 存储一对 key/value, 返回集合的新版本
+func (v *version[K, V]) put(key K, value V) *version[K, V] {
+	v.mustOrdered()
+
+	nv := *v
+	x := &node[K, V]{key: key, value: value, prio: rand.Uint32()}
+
+	// 按插入顺序排列时, 新的 key 获取下一个插入序号, 即排列在最后
+	if v.linked {
+		seq, ok := v.index.Get(key)
+		if !ok {
+			seq = v.seq
+			nv.seq++
+			nv.index = v.index.Set(key, seq)
+		}
+		x.seq = seq
+	}
+
+	root, added := v.insert(v.root, x)
+	if added {
+		nv.size++
+	}
+	nv.root = root
+	return &nv
+}
+
+// 将节点 `x` 插入以 `n` 为根的子树, 返回复制后的新子树以及是否新增了 key
+//
+// 如果 key 已存在, 则复制原节点并更新其 value; 否则将 `x` 作为叶子节点插入, 再通过旋转将其上移, 直到满足堆的性质
+func (v *version[K, V]) insert(n, x *node[K, V]) (*node[K, V], bool) {
+	if n == nil {
+		return x, true
+	}
+
+	c := *n
+
+	cr := v.compare(x.key, x.seq, n)
+	if cr == 0 {
+		c.value = x.value
+		return &c, false
+	}
+
+	var added bool
+	if cr < 0 {
+		if c.left, added = v.insert(n.left, x); c.left.prio > c.prio {
+			// 右旋, 左子节点成为新的根节点 (左子节点是新复制的节点, 可以直接修改)
+			l := c.left
+			c.left, l.right = l.right, &c
+			return l, added
+		}
+	} else {
+		if c.right, added = v.insert(n.right, x); c.right.prio > c.prio {
+			// 左旋, 右子节点成为新的根节点
+			r := c.right
+			c.right, r.left = r.left, &c
+			return r, added
+		}
+	}
+	return &c, added
+}
+
+// 删除一个 key, 返回集合的新版本, key 不存在时返回当前版本
+func (v *version[K, V]) remove(key K) *version[K, V] {
+	var seq uint64
+	if v.linked {
+		var ok bool
+		if seq, ok = v.index.Get(key); !ok {
+			return v
+		}
+	}
+
+	root, removed := v.delete(v.root, key, seq)
+	if !removed {
+		return v
+	}
+
+	nv := *v
+	nv.root = root
+	nv.size--
+	if v.linked {
+		nv.index = v.index.Delete(key)
+	}
+	return &nv
+}
+
+// 从以 `n` 为根的子树中删除 key, 返回复制后的新子树以及 key 是否存在
+func (v *version[K, V]) delete(n *node[K, V], key K, seq uint64) (*node[K, V], bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	cr := v.compare(key, seq, n)
+	if cr == 0 {
+		return join(n.left, n.right), true
+	}
+
+	c := *n
+
+	var removed bool
+	if cr < 0 {
+		c.left, removed = v.delete(n.left, key, seq)
+	} else {
+		c.right, removed = v.delete(n.right, key, seq)
+	}
+	if !removed {
+		return n, false
+	}
+	return &c, true
+}
+
+// 合并两棵子树, `a` 中的节点均排在 `b` 中的节点之前, 优先级较高的根节点成为新的根节点
+func join[K, V any](a, b *node[K, V]) *node[K, V] {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	if a.prio > b.prio {
+		c := *a
+		c.right = join(a.right, b)
+		return &c
+	}
+
+	c := *b
+	c.left = join(a, b.left)
+	return &c
+}
+
+// 按顺序迭代以当前节点为根的子树, 返回是否需要继续迭代
+func (n *node[K, V]) ascend(yield func(*node[K, V]) bool) bool {
+	if n == nil {
+		return true
+	}
+	return n.left.ascend(yield) && yield(n) && n.right.ascend(yield)
+}
+
+// 按逆序迭代以当前节点为根的子树, 返回是否需要继续迭代
+func (n *node[K, V]) descend(yield func(*node[K, V]) bool) bool {
+	if n == nil {
+		return true
+	}
+	return n.right.descend(yield) && yield(n) && n.left.descend(yield)
+}
+
+// 从 key (按插入顺序排列时为插入序号 `seq`) 开始, 按顺序迭代以 `n` 为根的子树, 返回是否需要继续迭代
+func (v *version[K, V]) ascendFrom(n *node[K, V], key K, seq uint64, yield func(*node[K, V]) bool) bool {
+	if n == nil {
+		return true
+	}
+
+	// 当前节点排在起点之前, 只需迭代右子树
+	if v.compare(key, seq, n) > 0 {
+		return v.ascendFrom(n.right, key, seq, yield)
+	}
+	return v.ascendFrom(n.left, key, seq, yield) && yield(n) && n.right.ascend(yield)
 }
 
 // 获取存储 key 的个数
-func (sm *OrderedMap[K, V]) Len() int { return len(sm.s) }
+func (sm *OrderedMap[K, V]) Len() int { return sm.load().size }
 
 // 存储一对 key/value
 func (sm *OrderedMap[K, V]) Put(key K, value V) {
-	// 判断 key 是否已存在, 若 key 不存在, 则额外将 key 在 slice 中存储一份
-	if _, ok := sm.m[key]; !ok {
-		sm.s = append(sm.s, key)
-		// 由于加入了新的 key, 所以之前的排序失效
-		sm.sorted = false
-	}
-	sm.m[key] = value // 设置 value
+	sm.cur.Store(sm.load().put(key, value))
 }
 
 // 根据 key 获取 value
 func (sm *OrderedMap[K, V]) Get(key K) (value V, ok bool) {
-	// 从 map 集合中获取 value
-	value, ok = sm.m[key]
-	return
-}
-
-// 对 slice 中存储的 key 进行排序
-func (sm *OrderedMap[K, V]) sortKey() {
-	// 如果已经排序, 则跳过此步骤
-	if !sm.sorted {
-		// 对 key slice 进行排序
-		slices.Sort(sm.s)
-
-		// 设置已排序的标志
-		sm.sorted = true
+	if x := sm.load().find(key); x != nil {
+		return x.value, true
 	}
+	return
 }
 
 // 删除一个 key
 func (sm *OrderedMap[K, V]) Remove(key K) {
-	// 判断 key 是否存在, 若存在, 则执行删除操作
-	if _, ok := sm.m[key]; ok {
-		// 从 map 中删除 key
-		delete(sm.m, key)
-
-		// 二分查找法, 在 key slice 中找到所需删除 key 的下标
-		sm.sortKey()
-		if i, ok := slices.BinarySearch(sm.s, key); ok {
-			// 删除该元素
-			sm.s = slices.Delete(sm.s, i, i+1)
+	if v := sm.load(); v.root != nil {
+		if nv := v.remove(key); nv != v {
+			sm.cur.Store(nv)
 		}
 	}
 }
 
-// 获取所有的 key
-func (sm *OrderedMap[K, V]) Keys() []K {
-	sm.sortKey()
-	return sm.s
+// 按顺序迭代所有的 key
+func (sm *OrderedMap[K, V]) Keys() iter.Seq[K] {
+	v := sm.load()
+	return func(yield func(K) bool) {
+		v.root.ascend(func(x *node[K, V]) bool { return yield(x.key) })
+	}
+}
+
+// 按顺序迭代所有的 key/value
+func (sm *OrderedMap[K, V]) All() iter.Seq2[K, V] {
+	v := sm.load()
+	return func(yield func(K, V) bool) {
+		v.root.ascend(func(x *node[K, V]) bool { return yield(x.key, x.value) })
+	}
 }
 
 // 获取所有的 value
 func (sm *OrderedMap[K, V]) Values() []V {
-	sm.sortKey()
+	v := sm.load()
 
-	vs := make([]V, len(sm.s))
-	for i, v := range sm.Keys() {
-		vs[i] = sm.m[v]
-	}
+	vs := make([]V, 0, v.size)
+	v.root.ascend(func(x *node[K, V]) bool {
+		vs = append(vs, x.value)
+		return true
+	})
 	return vs
 }
 
 // 迭代所有的 key/value
 func (sm *OrderedMap[K, V]) Do(r func(key K, val V)) {
-	sm.sortKey()
-	for _, k := range sm.Keys() { // 变量有序的 key 集合
-		r(k, sm.m[k]) // 回调迭代函数
-	}
+	sm.load().root.ascend(func(x *node[K, V]) bool { // 按顺序遍历树的节点
+		r(x.key, x.value) // 回调迭代函数
+		return true
+	})
 }
 
 // 返回一个迭代函数对集合进行迭代
+//
+// 迭代结束后, 迭代函数返回零值及 `false`
+//
+// Deprecated: 使用 `All` 方法返回的迭代器, 配合 `for range` 语句进行迭代
 func (sm *OrderedMap[K, V]) Iterate() func() (K, V, bool) {
-	sm.sortKey()
-
-	i := 0
+	// 通过栈记录尚未迭代的祖先节点, 栈顶即下一个节点
+	var stack []*node[K, V]
+	push := func(x *node[K, V]) {
+		for ; x != nil; x = x.left {
+			stack = append(stack, x)
+		}
+	}
+	push(sm.load().root)
 
 	// 返回迭代函数
 	return func() (K, V, bool) {
-		if i >= len(sm.s) {
-			k := sm.s[len(sm.s)-1]
-			return k, sm.m[k], false
+		if len(stack) == 0 {
+			var k K
+			var v V
+			return k, v, false
 		}
 
-		k := sm.s[i]
-		i++
-		return k, sm.m[k], true
+		x := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		push(x.right)
+		return x.key, x.value, true
+	}
+}
+
+// 获取排在最前的 key 及其 value (按 key 排序时即最小的 key), 集合为空时返回 `false`
+func (sm *OrderedMap[K, V]) First() (key K, value V, ok bool) {
+	x := sm.load().root
+	if x == nil {
+		return
+	}
+
+	for x.left != nil {
+		x = x.left
+	}
+	return x.key, x.value, true
+}
+
+// 获取排在最后的 key 及其 value (按 key 排序时即最大的 key), 集合为空时返回 `false`
+func (sm *OrderedMap[K, V]) Last() (key K, value V, ok bool) {
+	x := sm.load().root
+	if x == nil {
+		return
+	}
+
+	for x.right != nil {
+		x = x.right
+	}
+	return x.key, x.value, true
+}
+
+// 获取小于等于 `key` 参数的最大 key 及其 value, 不存在时返回 `false`
+//
+// 按插入顺序排列时, key 之间没有大小关系, 总是返回 `false`
+func (sm *OrderedMap[K, V]) Floor(key K) (k K, v V, ok bool) {
+	ver := sm.load()
+	if ver.linked {
+		return
+	}
+
+	// 记录沿查找路径遇到的, 小于 `key` 参数的最后一个节点
+	var floor *node[K, V]
+	for x := ver.root; x != nil; {
+		switch c := ver.cmp(key, x.key); {
+		case c < 0:
+			x = x.left
+		case c > 0:
+			floor, x = x, x.right
+		default:
+			return x.key, x.value, true
+		}
+	}
+
+	if floor != nil {
+		return floor.key, floor.value, true
+	}
+	return
+}
+
+// 获取大于等于 `key` 参数的最小 key 及其 value, 不存在时返回 `false`
+//
+// 按插入顺序排列时, key 之间没有大小关系, 总是返回 `false`
+func (sm *OrderedMap[K, V]) Ceiling(key K) (k K, v V, ok bool) {
+	ver := sm.load()
+	if ver.linked {
+		return
+	}
+
+	// 记录沿查找路径遇到的, 大于 `key` 参数的最后一个节点
+	var ceiling *node[K, V]
+	for x := ver.root; x != nil; {
+		switch c := ver.cmp(key, x.key); {
+		case c < 0:
+			ceiling, x = x, x.left
+		case c > 0:
+			x = x.right
+		default:
+			return x.key, x.value, true
+		}
+	}
+
+	if ceiling != nil {
+		return ceiling.key, ceiling.value, true
+	}
+	return
+}
+
+// 按 key 的顺序迭代 key 位于 `[from, to)` 区间内的 key/value
+//
+// 查找区间起点的时间复杂度为 `O(log n)`, 之后按顺序依次迭代
+//
+// 按插入顺序排列时, 从 `from` 开始迭代, 直到遇到 `to` 为止 (不包含 `to`), `from` 不存在时不进行迭代
+func (sm *OrderedMap[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	v := sm.load()
+	return func(yield func(K, V) bool) {
+		if v.linked {
+			seq, ok := v.index.Get(from)
+			if !ok {
+				return
+			}
+
+			v.ascendFrom(v.root, from, seq, func(x *node[K, V]) bool {
+				return x.key != to && yield(x.key, x.value)
+			})
+			return
+		}
+
+		v.ascendFrom(v.root, from, 0, func(x *node[K, V]) bool {
+			return v.cmp(x.key, to) < 0 && yield(x.key, x.value)
+		})
+	}
+}
+
+// 按逆序迭代所有的 key/value
+func (sm *OrderedMap[K, V]) Backward() iter.Seq2[K, V] {
+	v := sm.load()
+	return func(yield func(K, V) bool) {
+		v.root.descend(func(x *node[K, V]) bool { return yield(x.key, x.value) })
 	}
 }
@@ -1,7 +1,12 @@
 package orderedmap
 
 import (
+	"cmp"
+	"maps"
+	"math/rand/v2"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -19,7 +24,7 @@ func TestOrderedMap_New(t *testing.T) {
 	sm.Put(100, "B")
 
 	// 目前只存储了 1 个 key
-	keys := sm.Keys()
+	keys := slices.Collect(sm.Keys())
 	assert.Len(t, keys, 1)
 
 	// 利用 key 获取 value
@@ -31,7 +36,7 @@ func TestOrderedMap_New(t *testing.T) {
 	sm.Put(1000, "C")
 
 	// 目前存储了 3 个 key
-	keys = sm.Keys()
+	keys = slices.Collect(sm.Keys())
 	assert.Len(t, keys, 3)
 
 	// 获取到的 key 是有序的
@@ -83,11 +88,11 @@ func TestOrderedMap_Remove(t *testing.T) {
 	// 删除一个 key
 	sm.Remove(100)
 	// 剩余的 key 依旧有序
-	assert.Equal(t, []int{1, 10, 1000}, sm.Keys())
+	assert.Equal(t, []int{1, 10, 1000}, slices.Collect(sm.Keys()))
 
 	// 继续删除 key
 	sm.Remove(10)
-	assert.Equal(t, []int{1, 1000}, sm.Keys())
+	assert.Equal(t, []int{1, 1000}, slices.Collect(sm.Keys()))
 
 
     assert.Equal(t, []string{"D", "C"}, sm.Values()) // 剩余的 values 依旧保持和 keys 的有序对应
@@ -95,12 +100,11 @@ func TestOrderedMap_Remove(t *testing.T) {
 	// 清空, 返回初始状态
 	sm.Init()
 
-	assert.Len(t, sm.m, 0)
-	assert.Len(t, sm.s, 0)
+	assert.Equal(t, 0, sm.Len())
+	assert.Nil(t, sm.load().root)
 
-	keys := sm.Keys()
+	keys := slices.Collect(sm.Keys())
 	assert.Len(t, keys, 0)
-	assert.True(t, sm.sorted)
 }
 
 // 测试迭代函数
@@ -128,3 +132,371 @@ func TestOrderedMap_Iterator(t *testing.T) {
 	assert.Equal(t, []int{1, 10, 100, 1000}, ks)
 	assert.Equal(t, []string{"D", "A", "B", "C"}, vs)
 }
+
+// 测试大量随机 key/value 的存储和删除
+func TestOrderedMap_Random(t *testing.T) {
+	sm := New[int, int]()
+	m := make(map[int]int)
+
+	// 随机存储和删除 key, 并与内置 map 的结果进行比较
+	for i := range 10000 {
+		k := rand.IntN(1000)
+		if i%3 == 0 {
+			sm.Remove(k)
+			delete(m, k)
+		} else {
+			sm.Put(k, i)
+			m[k] = i
+		}
+	}
+	assert.Equal(t, len(m), sm.Len())
+
+	// key 有序且与 map 的 key 一致
+	keys := slices.Sorted(maps.Keys(m))
+	assert.Equal(t, keys, slices.Collect(sm.Keys()))
+
+	for _, k := range keys {
+		v, ok := sm.Get(k)
+		assert.True(t, ok)
+		assert.Equal(t, m[k], v)
+	}
+
+	// 逆序迭代的结果和正序相反
+	rks := make([]int, 0, len(keys))
+	for k := range sm.Backward() {
+		rks = append(rks, k)
+	}
+	slices.Reverse(rks)
+	assert.Equal(t, keys, rks)
+
+	// 删除全部 key 后, 树恢复初始状态
+	for _, k := range keys {
+		sm.Remove(k)
+	}
+	assert.Equal(t, 0, sm.Len())
+	assert.Nil(t, sm.load().root)
+}
+
+// 测试按插入顺序排列时, 随机存储和删除 key
+func TestOrderedMap_LinkedRandom(t *testing.T) {
+	sm := NewLinked[int, int]()
+
+	// 按插入顺序记录 key
+	var keys []int
+	m := make(map[int]int)
+
+	for i := range 5000 {
+		k := rand.IntN(500)
+		if i%3 == 0 {
+			sm.Remove(k)
+			if _, ok := m[k]; ok {
+				delete(m, k)
+				keys = slices.DeleteFunc(keys, func(x int) bool { return x == k })
+			}
+		} else {
+			sm.Put(k, i)
+			if _, ok := m[k]; !ok {
+				keys = append(keys, k)
+			}
+			m[k] = i
+		}
+	}
+
+	assert.Equal(t, len(keys), sm.Len())
+	assert.Equal(t, keys, slices.Collect(sm.Keys()))
+
+	for _, k := range keys {
+		v, ok := sm.Get(k)
+		assert.True(t, ok)
+		assert.Equal(t, m[k], v)
+	}
+
+	// 按插入顺序迭代区间
+	if len(keys) > 10 {
+		var ks []int
+		for k := range sm.Range(keys[3], keys[8]) {
+			ks = append(ks, k)
+		}
+		assert.Equal(t, keys[3:8], ks)
+	}
+}
+
+// 测试获取最小和最大的 key
+func TestOrderedMap_FirstLast(t *testing.T) {
+	sm := New[int, string]()
+
+	// 集合为空
+	_, _, ok := sm.First()
+	assert.False(t, ok)
+	_, _, ok = sm.Last()
+	assert.False(t, ok)
+
+	sm.Put(100, "B")
+	sm.Put(1000, "C")
+	sm.Put(1, "D")
+	sm.Put(10, "A")
+
+	k, v, ok := sm.First()
+	assert.True(t, ok)
+	assert.Equal(t, 1, k)
+	assert.Equal(t, "D", v)
+
+	k, v, ok = sm.Last()
+	assert.True(t, ok)
+	assert.Equal(t, 1000, k)
+	assert.Equal(t, "C", v)
+
+	// 删除最大的 key 后, 最大 key 随之改变
+	sm.Remove(1000)
+
+	k, _, _ = sm.Last()
+	assert.Equal(t, 100, k)
+}
+
+// 测试查找小于等于以及大于等于指定 key 的 key
+func TestOrderedMap_FloorCeiling(t *testing.T) {
+	sm := New[int, string]()
+
+	sm.Put(10, "A")
+	sm.Put(20, "B")
+	sm.Put(30, "C")
+
+	// key 存在时, 返回该 key
+	k, v, ok := sm.Floor(20)
+	assert.True(t, ok)
+	assert.Equal(t, 20, k)
+	assert.Equal(t, "B", v)
+
+	k, _, ok = sm.Ceiling(20)
+	assert.True(t, ok)
+	assert.Equal(t, 20, k)
+
+	// key 不存在时, 返回相邻的 key
+	k, _, ok = sm.Floor(25)
+	assert.True(t, ok)
+	assert.Equal(t, 20, k)
+
+	k, _, ok = sm.Ceiling(25)
+	assert.True(t, ok)
+	assert.Equal(t, 30, k)
+
+	k, _, ok = sm.Floor(100)
+	assert.True(t, ok)
+	assert.Equal(t, 30, k)
+
+	k, _, ok = sm.Ceiling(1)
+	assert.True(t, ok)
+	assert.Equal(t, 10, k)
+
+	// 超出范围时, 返回 `false`
+	_, _, ok = sm.Floor(5)
+	assert.False(t, ok)
+
+	_, _, ok = sm.Ceiling(35)
+	assert.False(t, ok)
+}
+
+// 测试迭代指定区间内的 key/value
+func TestOrderedMap_Range(t *testing.T) {
+	sm := New[int64, int]()
+
+	// 以时间戳为 key, 每分钟一个数据点
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := func(d time.Duration) int64 { return start.Add(d).Unix() }
+
+	for i := range 60 {
+		sm.Put(ts(time.Duration(i)*time.Minute), i)
+	}
+
+	// 获取 [10:30, 20:00) 区间内的数据点
+	vs := make([]int, 0)
+	for _, v := range sm.Range(ts(10*time.Minute+30*time.Second), ts(20*time.Minute)) {
+		vs = append(vs, v)
+	}
+	assert.Equal(t, []int{11, 12, 13, 14, 15, 16, 17, 18, 19}, vs)
+
+	// 中途结束迭代
+	vs = vs[:0]
+	for _, v := range sm.Range(ts(0), ts(time.Hour)) {
+		if v >= 3 {
+			break
+		}
+		vs = append(vs, v)
+	}
+	assert.Equal(t, []int{0, 1, 2}, vs)
+
+	// 区间内没有 key
+	for range sm.Range(ts(2*time.Hour), ts(3*time.Hour)) {
+		assert.Fail(t, "cannot run here")
+	}
+}
+
+// 测试迭代空集合
+func TestOrderedMap_IteratorEmpty(t *testing.T) {
+	sm := New[int, string]()
+
+	// 空集合的迭代函数直接返回 `false`
+	k, v, ok := sm.Iterate()()
+	assert.False(t, ok)
+	assert.Equal(t, 0, k)
+	assert.Equal(t, "", v)
+
+	for range sm.All() {
+		assert.Fail(t, "cannot run here")
+	}
+	for range sm.Backward() {
+		assert.Fail(t, "cannot run here")
+	}
+}
+
+// 测试通过迭代器迭代集合
+func TestOrderedMap_All(t *testing.T) {
+	sm := New[int, string]()
+
+	sm.Put(100, "B")
+	sm.Put(1000, "C")
+	sm.Put(1, "D")
+	sm.Put(10, "A")
+
+	ks := make([]int, 0, 4)
+	vs := make([]string, 0, 4)
+	for k, v := range sm.All() {
+		ks = append(ks, k)
+		vs = append(vs, v)
+	}
+	assert.Equal(t, []int{1, 10, 100, 1000}, ks)
+	assert.Equal(t, []string{"D", "A", "B", "C"}, vs)
+
+	// 逆序迭代
+	ks = ks[:0]
+	for k := range sm.Backward() {
+		ks = append(ks, k)
+	}
+	assert.Equal(t, []int{1000, 100, 10, 1}, ks)
+
+	// 迭代器可以配合标准库使用
+	assert.Equal(t, map[int]string{1: "D", 10: "A", 100: "B", 1000: "C"}, maps.Collect(sm.All()))
+}
+
+// 测试按插入顺序排列 key
+func TestOrderedMap_Linked(t *testing.T) {
+	sm := NewLinked[string, int]()
+
+	sm.Put("C", 1)
+	sm.Put("A", 2)
+	sm.Put("B", 3)
+
+	// 更新已存在的 key, 不改变 key 的顺序
+	sm.Put("C", 4)
+
+	assert.Equal(t, 3, sm.Len())
+	assert.Equal(t, []string{"C", "A", "B"}, slices.Collect(sm.Keys()))
+	assert.Equal(t, []int{4, 2, 3}, sm.Values())
+
+	v, ok := sm.Get("A")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+
+	k, _, _ := sm.First()
+	assert.Equal(t, "C", k)
+
+	k, _, _ = sm.Last()
+	assert.Equal(t, "B", k)
+
+	// 删除后重新存储的 key 排列在最后
+	sm.Remove("C")
+	sm.Put("C", 5)
+	assert.Equal(t, []string{"A", "B", "C"}, slices.Collect(sm.Keys()))
+
+	// 按插入顺序迭代区间
+	ks := make([]string, 0)
+	for k := range sm.Range("B", "X") {
+		ks = append(ks, k)
+	}
+	assert.Equal(t, []string{"B", "C"}, ks)
+
+	// 按插入顺序排列时, 不支持按大小查找 key
+	_, _, ok = sm.Floor("B")
+	assert.False(t, ok)
+
+	// 删除全部的 key
+	sm.Remove("B")
+	sm.Remove("A")
+	sm.Remove("C")
+	sm.Remove("C")
+	assert.Equal(t, 0, sm.Len())
+	assert.Nil(t, sm.load().root)
+	assert.Empty(t, slices.Collect(sm.Keys()))
+
+	// 清空后仍按插入顺序排列
+	sm.Put("Z", 1)
+	sm.Put("Y", 2)
+	sm.Init()
+	sm.Put("B", 1)
+	sm.Put("A", 2)
+	assert.Equal(t, []string{"B", "A"}, slices.Collect(sm.Keys()))
+}
+
+// 测试零值对象调用 `Init` 方法后, 按 key 的自然顺序排列
+func TestOrderedMap_ZeroValueInit(t *testing.T) {
+	var sm OrderedMap[string, int]
+	sm.Init()
+
+	sm.Put("C", 1)
+	sm.Put("A", 2)
+	sm.Put("B", 3)
+	assert.Equal(t, []string{"A", "B", "C"}, slices.Collect(sm.Keys()))
+
+	// 底层类型满足 `cmp.Ordered` 约束的自定义类型同样按自然顺序排列
+	type id int
+
+	var im OrderedMap[id, string]
+	im.Init()
+
+	im.Put(3, "C")
+	im.Put(-1, "A")
+	im.Put(2, "B")
+	assert.Equal(t, []string{"A", "B", "C"}, im.Values())
+
+	// 对按插入顺序排列的集合再次调用 `Init` 方法, 保持 key 的排列方式
+	lm := NewLinked[string, int]()
+	lm.Put("C", 1)
+
+	lm.Init()
+	lm.Put("B", 1)
+	lm.Put("A", 2)
+	assert.Equal(t, []string{"B", "A"}, slices.Collect(lm.Keys()))
+
+	// 不可排序的 key 类型无法使用零值对象
+	type point struct{ x, y int }
+
+	var pm OrderedMap[point, int]
+	assert.PanicsWithValue(t, "orderedmap: key type orderedmap.point is not ordered", func() { pm.Init() })
+}
+
+// 测试通过比较函数排列结构体 key
+func TestOrderedMap_Func(t *testing.T) {
+	// 表示版本号的结构体
+	type version struct {
+		major, minor int
+	}
+
+	sm := NewFunc[version, string](func(a, b version) int {
+		if c := cmp.Compare(a.major, b.major); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.minor, b.minor)
+	})
+
+	sm.Put(version{1, 10}, "B")
+	sm.Put(version{2, 0}, "C")
+	sm.Put(version{1, 2}, "A")
+
+	assert.Equal(t, []version{{1, 2}, {1, 10}, {2, 0}}, slices.Collect(sm.Keys()))
+
+	// 查找不大于 1.20 的最大版本
+	k, v, ok := sm.Floor(version{1, 20})
+	assert.True(t, ok)
+	assert.Equal(t, version{1, 10}, k)
+	assert.Equal(t, "B", v)
+}
@@ -0,0 +1,94 @@
+package sets
+
+import "study/basic/container/internal/hamt"
+
+// 复制当前集合
+//
+// 新集合和当前集合共享当前版本的节点, 所以复制的时间复杂度为 `O(1)`
+func (s *Set[T]) Clone() *Set[T] {
+	c := new(Set[T])
+	c.m.Store(s.m.Load())
+	return c
+}
+
+// 获取由满足条件的元素组成的新集合
+func (s *Set[T]) Filter(fn func(v T) bool) *Set[T] {
+	var m hamt.Map[T, Nothing]
+	for v := range s.All() {
+		if fn(v) {
+			m = m.Set(v, Empty)
+		}
+	}
+	return fromMap(m)
+}
+
+// 获取当前集合和其它集合的 并集, 即包含所有集合中全部元素的新集合
+func (s *Set[T]) Union(others ...ReadOnly[T]) *Set[T] {
+	m := s.load()
+	for _, o := range others {
+		for v := range o.All() {
+			m = m.Set(v, Empty)
+		}
+	}
+	return fromMap(m)
+}
+
+// 获取当前集合和其它集合的 交集, 即由在所有集合中均存在的元素组成的新集合
+func (s *Set[T]) Intersection(others ...ReadOnly[T]) *Set[T] {
+	var m hamt.Map[T, Nothing]
+	for v := range s.All() {
+		if containsAll(v, others) {
+			m = m.Set(v, Empty)
+		}
+	}
+	return fromMap(m)
+}
+
+// 获取当前集合和其它集合的 差集, 即由当前集合中不存在于任何其它集合的元素组成的新集合
+func (s *Set[T]) Difference(others ...ReadOnly[T]) *Set[T] {
+	var m hamt.Map[T, Nothing]
+	for v := range s.All() {
+		if !containsAny(v, others) {
+			m = m.Set(v, Empty)
+		}
+	}
+	return fromMap(m)
+}
+
+// 获取当前集合和其它集合的 对称差集
+//
+// 对于两个集合, 即由只在其中一个集合中存在的元素组成的新集合; 对于多个集合, 即由在奇数个集合中存在的元素组成的新集合
+// (相当于依次计算两两之间的对称差集)
+func (s *Set[T]) SymmetricDifference(others ...ReadOnly[T]) *Set[T] {
+	m := s.load()
+	for _, o := range others {
+		for v := range o.All() {
+			if _, ok := m.Get(v); ok {
+				m = m.Delete(v)
+			} else {
+				m = m.Set(v, Empty)
+			}
+		}
+	}
+	return fromMap(m)
+}
+
+// 判断元素是否在所有集合中存在
+func containsAll[T comparable](v T, sets []ReadOnly[T]) bool {
+	for _, s := range sets {
+		if !s.Contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// 判断元素是否在任意一个集合中存在
+func containsAny[T comparable](v T, sets []ReadOnly[T]) bool {
+	for _, s := range sets {
+		if s.Contains(v) {
+			return true
+		}
+	}
+	return false
+}
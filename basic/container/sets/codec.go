@@ -0,0 +1,146 @@
+package sets
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 确认 `Set` 类型实现了各类序列化接口
+var (
+	_ json.Marshaler   = (*Set[int])(nil)
+	_ json.Unmarshaler = (*Set[int])(nil)
+	_ yaml.Marshaler   = (*Set[int])(nil)
+	_ yaml.Unmarshaler = (*Set[int])(nil)
+	_ gob.GobEncoder   = (*Set[int])(nil)
+	_ gob.GobDecoder   = (*Set[int])(nil)
+)
+
+// 比较两个元素的大小, 用于在序列化时确定元素的顺序
+//
+// 数值, 字符串以及布尔类型按其自然顺序比较; 其它类型 (或类型不同) 的元素返回 `0`, 由调用方进一步比较
+func compareElem(a, b reflect.Value) int {
+	if a.Kind() != b.Kind() {
+		return 0
+	}
+
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	case reflect.Bool:
+		if a.Bool() == b.Bool() {
+			return 0
+		}
+		if a.Bool() {
+			return 1
+		}
+		return -1
+	}
+	return 0
+}
+
+// 获取按确定顺序排列的元素切片
+//
+// Set 集合本身是无序的, 为了令序列化的结果稳定, 元素先按其自然顺序排列, 无法比较的元素再按其 JSON 编码排列
+func (s *Set[T]) sorted() ([]T, error) {
+	type elem struct {
+		val T
+		rv  reflect.Value
+		enc []byte
+	}
+
+	es := make([]elem, 0, s.Len())
+	for v := range s.All() {
+		enc, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		// 对于接口类型的元素, 获取其实际值
+		rv := reflect.ValueOf(&v).Elem()
+		if rv.Kind() == reflect.Interface {
+			rv = rv.Elem()
+		}
+		es = append(es, elem{val: v, rv: rv, enc: enc})
+	}
+
+	slices.SortFunc(es, func(a, b elem) int {
+		if c := compareElem(a.rv, b.rv); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.enc, b.enc)
+	})
+
+	rs := make([]T, 0, len(es))
+	for _, e := range es {
+		rs = append(rs, e.val)
+	}
+	return rs, nil
+}
+
+// 将集合序列化为 JSON 数组, 数组元素的顺序是确定的
+func (s *Set[T]) MarshalJSON() ([]byte, error) {
+	vals, err := s.sorted()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(vals)
+}
+
+// 从 JSON 数组反序列化集合, 数组元素加入集合中, 重复的元素只保留一个
+func (s *Set[T]) UnmarshalJSON(data []byte) error {
+	var vals []T
+	if err := json.Unmarshal(data, &vals); err != nil {
+		return err
+	}
+
+	s.Add(vals...)
+	return nil
+}
+
+// 将集合序列化为 YAML 序列, 序列元素的顺序和 `MarshalJSON` 方法一致
+func (s *Set[T]) MarshalYAML() (any, error) {
+	return s.sorted()
+}
+
+// 从 YAML 序列反序列化集合
+func (s *Set[T]) UnmarshalYAML(node *yaml.Node) error {
+	var vals []T
+	if err := node.Decode(&vals); err != nil {
+		return err
+	}
+
+	s.Add(vals...)
+	return nil
+}
+
+// 将集合序列化为 gob 字节串
+func (s *Set[T]) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(s.Slice()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// 从 gob 字节串反序列化集合
+func (s *Set[T]) GobDecode(data []byte) error {
+	var vals []T
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&vals); err != nil {
+		return err
+	}
+
+	s.Add(vals...)
+	return nil
+}
@@ -0,0 +1,71 @@
+package sets
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// 测试将集合序列化为 JSON 数组, 元素的顺序是确定的
+func TestSet_MarshalJSON(t *testing.T) {
+	s := New[int]()
+	s.Add(10, 2, 33, 1)
+
+	data, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.Equal(t, `[1,2,10,33]`, string(data))
+
+	// 结构体元素按其 JSON 编码排序
+	type point struct{ X, Y int }
+
+	ps := New[point]()
+	ps.Add(point{2, 1}, point{1, 2})
+
+	data, err = json.Marshal(ps)
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"X":1,"Y":2},{"X":2,"Y":1}]`, string(data))
+
+	// 接口类型的元素, 类型相同的元素按其自然顺序排列, 类型不同的元素按其 JSON 编码排列
+	as := New[any]()
+	as.Add("b", 2, "a", 1)
+
+	data, err = json.Marshal(as)
+	assert.NoError(t, err)
+	assert.Equal(t, `["a","b",1,2]`, string(data))
+}
+
+// 测试从 JSON 数组反序列化集合
+func TestSet_UnmarshalJSON(t *testing.T) {
+	var cfg struct {
+		Tags Set[string] `json:"tags"`
+	}
+
+	err := json.Unmarshal([]byte(`{"tags":["b","a","b"]}`), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cfg.Tags.Len())
+	assert.True(t, cfg.Tags.Contains("a", "b"))
+
+	data, err := json.Marshal(&cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"tags":["a","b"]}`, string(data))
+
+	// 类型不匹配
+	assert.Error(t, json.Unmarshal([]byte(`{"tags":{"a":1}}`), &cfg))
+}
+
+// 测试 YAML 序列化和反序列化
+func TestSet_YAML(t *testing.T) {
+	s := New[string]()
+	s.Add("b", "c", "a")
+
+	data, err := yaml.Marshal(s)
+	assert.NoError(t, err)
+	assert.Equal(t, "- a\n- b\n- c\n", string(data))
+
+	as := New[string]()
+	err = yaml.Unmarshal(data, as)
+	assert.NoError(t, err)
+	assert.True(t, s.Equal(as))
+}
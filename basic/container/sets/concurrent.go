@@ -0,0 +1,276 @@
+package sets
+
+import (
+	"hash/maphash"
+	"iter"
+	"maps"
+	"runtime"
+	"sync"
+)
+
+// 集合分片
+type shard[T comparable] struct {
+	mux sync.RWMutex
+	m   map[T]Nothing
+}
+
+// 并发安全的集合类型
+//
+// 集合元素按哈希值分布在多个分片中, 每个分片有独立的读写锁, 以减少多个 goroutine 同时访问集合时的锁竞争;
+// 除了添加, 删除等单元素操作外, 涉及多个分片的操作 (例如 `Len`, `All`, 集合运算等) 不保证原子性,
+// 其结果相当于依次读取各个分片时的状态
+type ConcurrentSet[T comparable] struct {
+	seed   maphash.Seed
+	shards []shard[T]
+}
+
+// 创建并发安全的集合对象, 分片数量默认为 CPU 核数的 4 倍
+func NewConcurrent[T comparable]() *ConcurrentSet[T] {
+	return NewConcurrentSharded[T](runtime.GOMAXPROCS(0) * 4)
+}
+
+// 创建并发安全的集合对象, 并指定分片数量
+func NewConcurrentSharded[T comparable](shards int) *ConcurrentSet[T] {
+	s := &ConcurrentSet[T]{
+		seed:   maphash.MakeSeed(),
+		shards: make([]shard[T], max(shards, 1)),
+	}
+	for i := range s.shards {
+		s.shards[i].m = make(map[T]Nothing)
+	}
+	return s
+}
+
+// 获取元素所在的分片
+func (s *ConcurrentSet[T]) shard(v T) *shard[T] {
+	return &s.shards[maphash.Comparable(s.seed, v)%uint64(len(s.shards))]
+}
+
+// 向集合中添加元素
+func (s *ConcurrentSet[T]) Add(values ...T) {
+	for _, v := range values {
+		sh := s.shard(v)
+
+		sh.mux.Lock()
+		sh.m[v] = Empty
+		sh.mux.Unlock()
+	}
+}
+
+// 向集合中添加元素, 返回元素是否为新添加的 (即添加前集合中不存在该元素)
+func (s *ConcurrentSet[T]) AddIfAbsent(v T) bool {
+	sh := s.shard(v)
+
+	sh.mux.Lock()
+	defer sh.mux.Unlock()
+
+	if _, ok := sh.m[v]; ok {
+		return false
+	}
+	sh.m[v] = Empty
+	return true
+}
+
+// 从集合中删除指定的元素
+func (s *ConcurrentSet[T]) Remove(values ...T) {
+	for _, v := range values {
+		sh := s.shard(v)
+
+		sh.mux.Lock()
+		delete(sh.m, v)
+		sh.mux.Unlock()
+	}
+}
+
+// 判断元素是否全部在集合中存在
+func (s *ConcurrentSet[T]) Contains(values ...T) bool {
+	for _, v := range values {
+		sh := s.shard(v)
+
+		sh.mux.RLock()
+		_, ok := sh.m[v]
+		sh.mux.RUnlock()
+
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// 获取集合元素个数
+func (s *ConcurrentSet[T]) Len() int {
+	n := 0
+	for i := range s.shards {
+		sh := &s.shards[i]
+
+		sh.mux.RLock()
+		n += len(sh.m)
+		sh.mux.RUnlock()
+	}
+	return n
+}
+
+// 清空集合
+func (s *ConcurrentSet[T]) Clear() {
+	for i := range s.shards {
+		sh := &s.shards[i]
+
+		sh.mux.Lock()
+		clear(sh.m)
+		sh.mux.Unlock()
+	}
+}
+
+// 获取迭代集合所有元素的迭代器, 元素的顺序不确定
+//
+// 迭代时依次复制各个分片的元素, 并在锁外调用迭代函数, 所以在迭代过程中可以修改集合
+func (s *ConcurrentSet[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := range s.shards {
+			sh := &s.shards[i]
+
+			sh.mux.RLock()
+			vals := make([]T, 0, len(sh.m))
+			for v := range sh.m {
+				vals = append(vals, v)
+			}
+			sh.mux.RUnlock()
+
+			for _, v := range vals {
+				if !yield(v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// 通过回调函数遍历所有元素, 回调函数返回 `false` 时停止遍历
+func (s *ConcurrentSet[T]) Do(fn func(v T) bool) {
+	for v := range s.All() {
+		if !fn(v) {
+			break
+		}
+	}
+}
+
+// 将集合转为切片
+func (s *ConcurrentSet[T]) Slice() []T {
+	r := make([]T, 0, s.Len())
+	for v := range s.All() {
+		r = append(r, v)
+	}
+	return r
+}
+
+// 判断两个集合是否相同 (包含相同的元素)
+func (s *ConcurrentSet[T]) Equal(other ReadOnly[T]) bool {
+	return s.Len() == other.Len() && s.IsSuperset(other)
+}
+
+// 判断当前集合是否另一个集合的子集
+func (s *ConcurrentSet[T]) IsSubset(other ReadOnly[T]) bool {
+	if s.Len() > other.Len() {
+		return false
+	}
+
+	for v := range s.All() {
+		if !other.Contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// 判断当前集合是否另一个集合的超集
+func (s *ConcurrentSet[T]) IsSuperset(other ReadOnly[T]) bool {
+	if s.Len() < other.Len() {
+		return false
+	}
+
+	for v := range other.All() {
+		if !s.Contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// 复制当前集合, 新集合与当前集合具有相同的分片数量
+func (s *ConcurrentSet[T]) Clone() *ConcurrentSet[T] {
+	r := &ConcurrentSet[T]{
+		seed:   s.seed,
+		shards: make([]shard[T], len(s.shards)),
+	}
+	for i := range s.shards {
+		sh := &s.shards[i]
+
+		sh.mux.RLock()
+		r.shards[i].m = maps.Clone(sh.m)
+		sh.mux.RUnlock()
+	}
+	return r
+}
+
+// 创建一个与当前集合具有相同分片数量的空集合
+func (s *ConcurrentSet[T]) empty() *ConcurrentSet[T] {
+	return NewConcurrentSharded[T](len(s.shards))
+}
+
+// 获取由满足条件的元素组成的新集合
+func (s *ConcurrentSet[T]) Filter(fn func(v T) bool) *ConcurrentSet[T] {
+	r := s.empty()
+	for v := range s.All() {
+		if fn(v) {
+			r.Add(v)
+		}
+	}
+	return r
+}
+
+// 获取当前集合和其它集合的 并集
+func (s *ConcurrentSet[T]) Union(others ...ReadOnly[T]) *ConcurrentSet[T] {
+	r := s.Clone()
+	for _, o := range others {
+		for v := range o.All() {
+			r.Add(v)
+		}
+	}
+	return r
+}
+
+// 获取当前集合和其它集合的 交集
+func (s *ConcurrentSet[T]) Intersection(others ...ReadOnly[T]) *ConcurrentSet[T] {
+	r := s.empty()
+	for v := range s.All() {
+		if containsAll(v, others) {
+			r.Add(v)
+		}
+	}
+	return r
+}
+
+// 获取当前集合和其它集合的 差集
+func (s *ConcurrentSet[T]) Difference(others ...ReadOnly[T]) *ConcurrentSet[T] {
+	r := s.empty()
+	for v := range s.All() {
+		if !containsAny(v, others) {
+			r.Add(v)
+		}
+	}
+	return r
+}
+
+// 获取当前集合和其它集合的 对称差集, 即由在奇数个集合中存在的元素组成的新集合
+func (s *ConcurrentSet[T]) SymmetricDifference(others ...ReadOnly[T]) *ConcurrentSet[T] {
+	r := s.Clone()
+	for _, o := range others {
+		for v := range o.All() {
+			if !r.AddIfAbsent(v) {
+				r.Remove(v)
+			}
+		}
+	}
+	return r
+}
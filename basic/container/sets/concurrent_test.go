@@ -0,0 +1,88 @@
+package sets
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试并发安全集合的基本操作
+func TestConcurrentSet_Basic(t *testing.T) {
+	s := NewConcurrent[int]()
+	assert.Equal(t, 0, s.Len())
+
+	s.Add(1, 2, 3, 4, 2)
+	assert.Equal(t, 4, s.Len())
+	assert.ElementsMatch(t, []int{1, 2, 3, 4}, s.Slice())
+	assert.ElementsMatch(t, []int{1, 2, 3, 4}, slices.Collect(s.All()))
+
+	assert.True(t, s.Contains(2, 3))
+	assert.False(t, s.Contains(4, 5))
+
+	assert.False(t, s.AddIfAbsent(1))
+	assert.True(t, s.AddIfAbsent(5))
+
+	s.Remove(1, 5)
+	assert.ElementsMatch(t, []int{2, 3, 4}, s.Slice())
+
+	s.Clear()
+	assert.Equal(t, 0, s.Len())
+}
+
+// 测试并发安全集合和 Set 集合之间的比较
+func TestConcurrentSet_Compare(t *testing.T) {
+	s := NewConcurrentSharded[int](3)
+	s.Add(1, 2, 3, 4)
+
+	assert.True(t, s.Equal(Of(1, 2, 3, 4)))
+	assert.True(t, Of(1, 2, 3, 4).Equal(s))
+	assert.True(t, s.IsSuperset(Of(2, 3)))
+	assert.True(t, Of(2, 3).IsSubset(s))
+	assert.False(t, s.IsSubset(Of(2, 3)))
+}
+
+// 测试并发安全集合的集合运算
+func TestConcurrentSet_Algebra(t *testing.T) {
+	s := NewConcurrent[int]()
+	s.Add(1, 2, 3, 4)
+
+	assert.ElementsMatch(t, []int{1, 2, 3, 4, 5}, s.Union(Of(4, 5)).Slice())
+	assert.ElementsMatch(t, []int{3, 4}, s.Intersection(Of(3, 4, 5)).Slice())
+	assert.ElementsMatch(t, []int{1, 2}, s.Difference(Of(3, 4, 5)).Slice())
+	assert.ElementsMatch(t, []int{1, 2, 5}, s.SymmetricDifference(Of(3, 4, 5)).Slice())
+	assert.ElementsMatch(t, []int{2, 4}, s.Filter(func(v int) bool { return v%2 == 0 }).Slice())
+
+	// 复制后的集合与原集合互不影响
+	c := s.Clone()
+	c.Add(5)
+	assert.False(t, s.Contains(5))
+	assert.True(t, c.Contains(5))
+}
+
+// 测试多个 goroutine 同时读写集合
+func TestConcurrentSet_Parallel(t *testing.T) {
+	s := NewConcurrent[int]()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			for n := range 1000 {
+				s.Add(i*1000 + n)
+				s.Contains(n)
+			}
+		})
+	}
+
+	// 在写入的同时遍历集合
+	wg.Go(func() {
+		for range 10 {
+			for range s.All() {
+			}
+		}
+	})
+	wg.Wait()
+
+	assert.Equal(t, 8000, s.Len())
+}
@@ -1,20 +1,78 @@
 package sets
 
+import (
+	"iter"
+	"study/basic/container/internal/hamt"
+	"sync/atomic"
+)
+
 type Nothing struct{}
 
 var (
 	Empty Nothing = struct{}{}
 )
 
+// 确认 `Set` 和 `ConcurrentSet` 类型实现了集合接口
+var (
+	_ Interface[int] = (*Set[int])(nil)
+	_ Interface[int] = (*ConcurrentSet[int])(nil)
+)
+
+// 只读集合接口
+//
+// 集合运算的参数均为该接口类型, 所以不同类型的集合之间可以进行集合运算
+type ReadOnly[T comparable] interface {
+	// 判断元素是否全部在集合中存在
+	Contains(values ...T) bool
+
+	// 获取集合元素个数
+	Len() int
+
+	// 获取迭代集合所有元素的迭代器
+	All() iter.Seq[T]
+}
+
+// 集合接口
+type Interface[T comparable] interface {
+	ReadOnly[T]
+
+	// 向集合中添加元素
+	Add(values ...T)
+
+	// 从集合中删除指定的元素
+	Remove(values ...T)
+
+	// 判断两个集合是否相同 (包含相同的元素)
+	Equal(other ReadOnly[T]) bool
+
+	// 判断当前集合是否另一个集合的子集
+	IsSubset(other ReadOnly[T]) bool
+
+	// 判断当前集合是否另一个集合的超集
+	IsSuperset(other ReadOnly[T]) bool
+
+	// 将集合转为切片
+	Slice() []T
+
+	// 通过回调函数遍历所有元素
+	Do(fn func(v T) bool)
+}
+
 // 定义 Set 集合结构体
 //
 // Go 语言本身不提供 Set 集合, 需要用 Map 模拟, 基本思路为:
 //   - 定义一个 `Map` 集合, 并将 Map 的 Value 类型定义为 `Nothing`
 //   - 将 `Map` 集合的 Key 作为 Set 集合的元素
 //
+// 为了支持低成本的快照, 这里使用持久化的 `hamt.Map` 而不是内置的 map: 每次修改只复制修改路径上的节点 (`O(log n)`),
+// 其余节点和之前的版本共享, 所以之前的版本永远不会改变, 创建快照只需获取当前版本
+//
+// 集合的当前版本通过原子指针保存, 所以读取集合以及创建快照可以和一个 goroutine 的修改操作同时进行,
+// 但多个 goroutine 同时修改集合时仍需自行加锁, 或使用 `ConcurrentSet` 类型
+//
 // `Nothing` (即 `struct{}`) 类型相当于一个空类型, 不占用实际的存储空间
 type Set[T comparable] struct {
-	m map[T]Nothing
+	m atomic.Pointer[hamt.Map[T, Nothing]] // 集合的当前版本, 为 `nil` 表示集合为空
 }
 
 // 创建并初始化 Set 集合对象
@@ -24,33 +82,79 @@ func New[T comparable]() *Set[T] {
 	return s
 }
 
-// 初始化 Set 集合
+// 创建 Set 集合对象, 并添加参数中的元素
+func Of[T comparable](values ...T) *Set[T] {
+	s := New[T]()
+	s.Add(values...)
+	return s
+}
+
+// 创建 Set 集合对象, 并添加迭代器中的元素
+func Collect[T comparable](seq iter.Seq[T]) *Set[T] {
+	var m hamt.Map[T, Nothing]
+	for v := range seq {
+		m = m.Set(v, Empty)
+	}
+	return fromMap(m)
+}
+
+// 以 `m` 参数作为当前版本创建 Set 集合对象
+func fromMap[T comparable](m hamt.Map[T, Nothing]) *Set[T] {
+	s := new(Set[T])
+	s.store(m)
+	return s
+}
+
+// 初始化 Set 集合, 清空集合中的所有元素
+//
+// 零值的集合即为空集合, 可以直接使用
 func (s *Set[T]) Init() {
-	s.m = make(map[T]Nothing)
+	s.m.Store(nil)
+}
+
+// 获取集合的当前版本
+func (s *Set[T]) load() hamt.Map[T, Nothing] {
+	if m := s.m.Load(); m != nil {
+		return *m
+	}
+	return hamt.Map[T, Nothing]{}
+}
+
+// 将修改后的 Map 保存为集合的当前版本
+func (s *Set[T]) store(m hamt.Map[T, Nothing]) {
+	s.m.Store(&m)
 }
 
 // 向 Set 集合中添加元素
 func (s *Set[T]) Add(values ...T) {
+	m := s.load()
+
 	// 遍历参数, 获取参数值 (range 返回的第 2 个值, 第 1 个值为下标)
 	for _, v := range values {
 		// 以 某个参数值 为 key, 空结构为 value, 设置 map (相当于只给 map 设置了 key)
-		s.m[v] = Empty
+		m = m.Set(v, Empty)
 	}
+	s.store(m)
 }
 
 // 从集合中删除指定的元素
 func (s *Set[T]) Remove(values ...T) {
+	m := s.load()
+
 	// 遍历参数, 从 map 中删除参数所表示的 key
 	for _, v := range values {
-		delete(s.m, v)
+		m = m.Delete(v)
 	}
+	s.store(m)
 }
 
 // 判断元素是否在集合中存在
 func (s *Set[T]) Contains(values ...T) bool {
+	m := s.load()
+
 	// 遍历参数, 从 map 中查找 参数所表示的 key 是否存在
 	for _, v := range values {
-		if _, ok := s.m[v]; !ok {
+		if _, ok := m.Get(v); !ok {
 			return false
 		}
 	}
@@ -58,17 +162,18 @@ func (s *Set[T]) Contains(values ...T) bool {
 }
 
 // 获取 Set 集合元素个数
-func (s *Set[T]) Len() int { return len(s.m) }
+func (s *Set[T]) Len() int { return s.load().Len() }
 
 // 判断两个 Set 集合是否相同 (包含相同的元素)
-func (s *Set[T]) Equal(other *Set[T]) bool {
-	if s.Len() != other.Len() {
+func (s *Set[T]) Equal(other ReadOnly[T]) bool {
+	m := s.load()
+	if m.Len() != other.Len() {
 		// 元素个数不同不能相等
 		return false
 	}
 
 	// 遍历 map, 判断 参数 所表示的 key 是否存在
-	for v := range s.m {
+	for v := range m.Keys() {
 		if !other.Contains(v) {
 			// 某个参数的 key 不存在, 则返回 false
 			return false
@@ -78,13 +183,14 @@ func (s *Set[T]) Equal(other *Set[T]) bool {
 }
 
 // 判断当前 Set 集合是否另一个集合的 子集
-func (s *Set[T]) IsSubset(other *Set[T]) bool {
-	if s.Len() > other.Len() {
+func (s *Set[T]) IsSubset(other ReadOnly[T]) bool {
+	m := s.load()
+	if m.Len() > other.Len() {
 		// 当前集合元素数必须不能大于另一个集合, 否则不能成为 子集
 		return false
 	}
 
-	for v := range s.m {
+	for v := range m.Keys() {
 		// 另一个集合是否包含 当前集合的 所有元素
 		if !other.Contains(v) {
 			return false
@@ -93,11 +199,29 @@ func (s *Set[T]) IsSubset(other *Set[T]) bool {
 	return true
 }
 
+// 判断当前 Set 集合是否另一个集合的 超集
+func (s *Set[T]) IsSuperset(other ReadOnly[T]) bool {
+	m := s.load()
+	if m.Len() < other.Len() {
+		// 当前集合元素数必须不能小于另一个集合, 否则不能成为 超集
+		return false
+	}
+
+	for v := range other.All() {
+		// 当前集合是否包含 另一个集合的 所有元素
+		if _, ok := m.Get(v); !ok {
+			return false
+		}
+	}
+	return true
+}
+
 // 将 Set 转为切片
 func (s *Set[T]) Slice() []T {
-	rs := make([]T, 0, s.Len())
+	m := s.load()
+	rs := make([]T, 0, m.Len())
 
-	for v := range s.m {
+	for v := range m.Keys() {
 		rs = append(rs, v)
 	}
 	return rs
@@ -105,9 +229,16 @@ func (s *Set[T]) Slice() []T {
 
 // 通过回调函数遍历所有元素
 func (s *Set[T]) Do(fn func(v T) bool) {
-	for v := range s.m {
+	for v := range s.All() {
 		if !fn(v) {
 			break
 		}
 	}
 }
+
+// 获取迭代集合所有元素的迭代器, 元素的顺序不确定
+//
+// 迭代器迭代的是调用该方法时集合的版本, 不受之后修改的影响
+func (s *Set[T]) All() iter.Seq[T] {
+	return s.load().Keys()
+}
@@ -1,6 +1,7 @@
 package sets
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -109,3 +110,100 @@ func TestSet_Do(t *testing.T) {
 
 	assert.ElementsMatch(t, []int{1, 2, 3, 4}, vs)
 }
+
+// 测试通过参数或迭代器创建集合
+func TestSet_OfAndCollect(t *testing.T) {
+	s := Of(1, 2, 3, 2)
+	assert.ElementsMatch(t, []int{1, 2, 3}, s.Slice())
+
+	s = Collect(slices.Values([]int{3, 4, 5, 4}))
+	assert.ElementsMatch(t, []int{3, 4, 5}, s.Slice())
+}
+
+// 测试通过迭代器遍历集合
+func TestSet_All(t *testing.T) {
+	s := Of(1, 2, 3, 4)
+	assert.ElementsMatch(t, []int{1, 2, 3, 4}, slices.Collect(s.All()))
+
+	// 迭代过程中可以提前结束
+	n := 0
+	for range s.All() {
+		n++
+		break
+	}
+	assert.Equal(t, 1, n)
+}
+
+// 测试判断集合是否为指定集合的超集
+func TestSet_IsSuperset(t *testing.T) {
+	s1 := Of(1, 2, 3, 4)
+	s2 := Of(2, 3, 4)
+
+	assert.True(t, s1.IsSuperset(s2))
+	assert.False(t, s2.IsSuperset(s1))
+
+	// 集合是其自身的超集
+	assert.True(t, s1.IsSuperset(s1))
+}
+
+// 测试复制集合
+func TestSet_Clone(t *testing.T) {
+	s1 := Of(1, 2, 3)
+	s2 := s1.Clone()
+	assert.True(t, s1.Equal(s2))
+
+	// 修改复制后的集合, 不影响原集合
+	s2.Add(4)
+	assert.False(t, s1.Contains(4))
+
+	// 复制未初始化的集合
+	var s3 Set[int]
+	s3.Clone().Add(1)
+}
+
+// 测试过滤集合元素
+func TestSet_Filter(t *testing.T) {
+	s := Of(1, 2, 3, 4, 5, 6)
+
+	r := s.Filter(func(v int) bool { return v%2 == 0 })
+	assert.ElementsMatch(t, []int{2, 4, 6}, r.Slice())
+}
+
+// 测试集合的并集
+func TestSet_Union(t *testing.T) {
+	s1 := Of(1, 2, 3)
+
+	assert.ElementsMatch(t, []int{1, 2, 3, 4, 5}, s1.Union(Of(3, 4, 5)).Slice())
+	assert.ElementsMatch(t, []int{1, 2, 3, 4, 5, 6}, s1.Union(Of(4), Of(5, 6)).Slice())
+
+	// 无参数时返回当前集合的副本
+	assert.True(t, s1.Union().Equal(s1))
+	assert.Equal(t, 3, s1.Len())
+}
+
+// 测试集合的交集
+func TestSet_Intersection(t *testing.T) {
+	s1 := Of(1, 2, 3, 4)
+
+	assert.ElementsMatch(t, []int{3, 4}, s1.Intersection(Of(3, 4, 5)).Slice())
+	assert.ElementsMatch(t, []int{4}, s1.Intersection(Of(3, 4, 5), Of(4, 5, 6)).Slice())
+	assert.Equal(t, 0, s1.Intersection(Of(5, 6)).Len())
+}
+
+// 测试集合的差集
+func TestSet_Difference(t *testing.T) {
+	s1 := Of(1, 2, 3, 4)
+
+	assert.ElementsMatch(t, []int{1, 2}, s1.Difference(Of(3, 4, 5)).Slice())
+	assert.ElementsMatch(t, []int{2}, s1.Difference(Of(3, 4), Of(1)).Slice())
+}
+
+// 测试集合的对称差集
+func TestSet_SymmetricDifference(t *testing.T) {
+	s1 := Of(1, 2, 3)
+
+	assert.ElementsMatch(t, []int{1, 2, 4, 5}, s1.SymmetricDifference(Of(3, 4, 5)).Slice())
+
+	// 多个集合时, 结果为在奇数个集合中存在的元素
+	assert.ElementsMatch(t, []int{1, 3, 5}, s1.SymmetricDifference(Of(2, 3, 4), Of(3, 4, 5)).Slice())
+}
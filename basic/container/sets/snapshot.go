@@ -0,0 +1,64 @@
+package sets
+
+import (
+	"encoding/json"
+	"iter"
+)
+
+// 确认 `Snapshot` 类型实现了只读集合接口
+var (
+	_ ReadOnly[int]  = (*Snapshot[int])(nil)
+	_ json.Marshaler = (*Snapshot[int])(nil)
+)
+
+// 集合的只读快照
+//
+// 快照即集合在创建时的版本: 集合的每次修改只复制修改路径上的节点并生成新的版本, 未修改的节点和快照共享,
+// 所以创建快照的时间复杂度为 `O(1)`, 之后修改集合的开销和未创建快照时相同, 且快照的内容永远不会改变
+//
+// 由于快照不可修改, 所以可以被多个 goroutine 同时读取而无需加锁; 典型的用法是由一个 goroutine 修改集合并定期生成快照,
+// 通过 `snapshot.Holder` 类型发布给其它 goroutine 读取
+type Snapshot[T comparable] struct {
+	s Set[T]
+}
+
+// 获取集合的只读快照
+//
+// 该方法只读取集合的当前版本, 不会修改集合, 所以可以和修改集合的操作同时进行 (参见 `BenchmarkSet_AddAfterSnapshot`)
+func (s *Set[T]) Snapshot() *Snapshot[T] {
+	ss := new(Snapshot[T])
+	ss.s.m.Store(s.m.Load())
+	return ss
+}
+
+// 判断元素是否全部在快照中存在
+func (ss *Snapshot[T]) Contains(values ...T) bool { return ss.s.Contains(values...) }
+
+// 获取快照元素个数
+func (ss *Snapshot[T]) Len() int { return ss.s.Len() }
+
+// 获取迭代快照所有元素的迭代器, 元素的顺序不确定
+func (ss *Snapshot[T]) All() iter.Seq[T] { return ss.s.All() }
+
+// 将快照转为切片
+func (ss *Snapshot[T]) Slice() []T { return ss.s.Slice() }
+
+// 通过回调函数遍历所有元素
+func (ss *Snapshot[T]) Do(fn func(v T) bool) { ss.s.Do(fn) }
+
+// 判断快照和另一个集合是否相同 (包含相同的元素)
+func (ss *Snapshot[T]) Equal(other ReadOnly[T]) bool { return ss.s.Equal(other) }
+
+// 判断快照是否另一个集合的子集
+func (ss *Snapshot[T]) IsSubset(other ReadOnly[T]) bool { return ss.s.IsSubset(other) }
+
+// 判断快照是否另一个集合的超集
+func (ss *Snapshot[T]) IsSuperset(other ReadOnly[T]) bool { return ss.s.IsSuperset(other) }
+
+// 基于快照创建一个可修改的集合
+//
+// 新集合和快照共享节点, 创建的时间复杂度为 `O(1)`, 所以可以基于当前快照低成本的构建下一个版本
+func (ss *Snapshot[T]) Set() *Set[T] { return ss.s.Clone() }
+
+// 将快照序列化为 JSON 数组
+func (ss *Snapshot[T]) MarshalJSON() ([]byte, error) { return ss.s.MarshalJSON() }
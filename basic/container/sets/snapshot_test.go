@@ -0,0 +1,101 @@
+package sets
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试快照的内容不受集合之后修改的影响
+func TestSet_Snapshot(t *testing.T) {
+	s := Of(1, 2, 3)
+	ss := s.Snapshot()
+
+	s.Add(4)
+	s.Remove(1)
+
+	assert.ElementsMatch(t, []int{1, 2, 3}, ss.Slice())
+	assert.True(t, ss.Contains(1))
+	assert.False(t, ss.Contains(4))
+	assert.Equal(t, 3, ss.Len())
+	assert.ElementsMatch(t, []int{2, 3, 4}, s.Slice())
+
+	// 快照可以和其它集合进行比较和运算
+	assert.True(t, ss.Equal(Of(1, 2, 3)))
+	assert.True(t, ss.IsSuperset(Of(2, 3)))
+	assert.ElementsMatch(t, []int{2, 3}, s.Intersection(ss).Slice())
+
+	data, err := json.Marshal(ss)
+	assert.Nil(t, err)
+	assert.Equal(t, `[1,2,3]`, string(data))
+
+	// 零值集合的快照
+	var zs Set[int]
+	assert.Equal(t, 0, zs.Snapshot().Len())
+}
+
+// 测试基于快照构建新的集合
+func TestSet_SnapshotSet(t *testing.T) {
+	s := Of(1, 2)
+	ss := s.Snapshot()
+
+	next := ss.Set()
+	next.Add(3)
+
+	assert.ElementsMatch(t, []int{1, 2, 3}, next.Slice())
+	assert.ElementsMatch(t, []int{1, 2}, ss.Slice())
+	assert.ElementsMatch(t, []int{1, 2}, s.Slice())
+}
+
+// 测试在修改集合的同时创建快照, 每个快照的内容都是完整的某个版本
+func TestSet_SnapshotConcurrent(t *testing.T) {
+	s := New[int]()
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for i := range 1000 {
+			s.Add(i)
+		}
+	})
+	wg.Go(func() {
+		for range 1000 {
+			ss := s.Snapshot()
+
+			// 快照中的元素为 `[0, n)`
+			n := ss.Len()
+			assert.Len(t, ss.Slice(), n)
+			assert.True(t, n == 0 || ss.Contains(0, n-1))
+		}
+	})
+	wg.Wait()
+
+	assert.Equal(t, 1000, s.Len())
+}
+
+// 测试不同集合大小下, 创建快照后修改集合的开销
+//
+// 每次修改只复制修改路径上的节点, 开销随集合大小对数增长, 且和不创建快照时相同
+func BenchmarkSet_AddAfterSnapshot(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		s := New[int]()
+		for i := range size {
+			s.Add(i)
+		}
+
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			for i := range b.N {
+				s.Snapshot()
+				s.Add(i % size)
+			}
+		})
+
+		b.Run(fmt.Sprintf("size=%d/no-snapshot", size), func(b *testing.B) {
+			for i := range b.N {
+				s.Add(i % size)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package snapshot
+
+import "sync/atomic"
+
+// 原子交换的值容器
+//
+// 用于在 goroutine 之间发布不可变的数据 (例如 `orderedmap.Snapshot` 或 `sets.Snapshot`): 写入方构建好新版本的数据后,
+// 通过 `Store` 方法整体替换; 读取方通过 `Load` 方法获取当前版本, 整个过程无需加锁, 且读取方总能看到一个完整的版本
+//
+// 容器的零值可以直接使用, 此时 `Load` 方法返回 `nil`
+type Holder[T any] struct {
+	p atomic.Pointer[T]
+}
+
+// 创建容器实例, 并存储初始值
+func New[T any](v *T) *Holder[T] {
+	h := &Holder[T]{}
+	h.p.Store(v)
+	return h
+}
+
+// 获取当前存储的值
+func (h *Holder[T]) Load() *T { return h.p.Load() }
+
+// 存储新值
+func (h *Holder[T]) Store(v *T) { h.p.Store(v) }
+
+// 存储新值, 并返回之前存储的值
+func (h *Holder[T]) Swap(v *T) *T { return h.p.Swap(v) }
+
+// 如果当前存储的值为 `old`, 则替换为 `new`, 返回是否替换成功
+func (h *Holder[T]) CompareAndSwap(old, new *T) bool { return h.p.CompareAndSwap(old, new) }
+
+// 基于当前存储的值计算新值并存储, 返回存储的新值
+//
+// 如果计算期间其它 goroutine 存储了新值, 则基于最新的值重新计算, 所以 `fn` 函数可能被调用多次, 且不应有副作用
+func (h *Holder[T]) Update(fn func(old *T) *T) *T {
+	for {
+		old := h.p.Load()
+		if v := fn(old); h.p.CompareAndSwap(old, v) {
+			return v
+		}
+	}
+}
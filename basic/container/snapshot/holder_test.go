@@ -0,0 +1,90 @@
+package snapshot_test
+
+import (
+	"fmt"
+	"study/basic/container/orderedmap"
+	"study/basic/container/snapshot"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试存储和交换容器中的值
+func TestHolder_StoreAndSwap(t *testing.T) {
+	var h snapshot.Holder[int]
+	assert.Nil(t, h.Load())
+
+	a, b := 1, 2
+
+	h.Store(&a)
+	assert.Equal(t, 1, *h.Load())
+
+	assert.Same(t, &a, h.Swap(&b))
+	assert.Equal(t, 2, *h.Load())
+
+	assert.False(t, h.CompareAndSwap(&a, &a))
+	assert.True(t, h.CompareAndSwap(&b, &a))
+	assert.Equal(t, 1, *h.Load())
+}
+
+// 测试多个 goroutine 同时更新容器中的值
+func TestHolder_Update(t *testing.T) {
+	zero := 0
+	h := snapshot.New(&zero)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				h.Update(func(old *int) *int {
+					v := *old + 1
+					return &v
+				})
+			}
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, 800, *h.Load())
+}
+
+// 测试通过容器发布集合快照, 读取方无需加锁即可读取完整的快照
+func TestHolder_PublishSnapshot(t *testing.T) {
+	sm := orderedmap.New[string, int]()
+	h := snapshot.New(sm.Snapshot())
+
+	var (
+		wg   sync.WaitGroup
+		stop atomic.Bool
+	)
+
+	// 读取方: 快照中的所有 value 均等于快照的 key 的个数
+	for range 4 {
+		wg.Go(func() {
+			for !stop.Load() {
+				ss := h.Load()
+				for _, v := range ss.All() {
+					if v != ss.Len() {
+						assert.Fail(t, "inconsistent snapshot")
+						return
+					}
+				}
+			}
+		})
+	}
+
+	// 写入方: 每次添加一个 key, 并将所有 value 更新为 key 的个数后发布快照
+	for i := range 100 {
+		sm.Put(fmt.Sprintf("key-%03d", i), 0)
+		for k := range sm.Keys() {
+			sm.Put(k, sm.Len())
+		}
+		h.Store(sm.Snapshot())
+	}
+	stop.Store(true)
+	wg.Wait()
+
+	assert.Equal(t, 100, h.Load().Len())
+}
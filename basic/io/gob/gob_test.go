@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/gob"
 	"reflect"
+	"study/basic/container/sets"
 	"testing"
 
 	"github.com/google/uuid"
@@ -204,3 +205,35 @@ func TestGob_ReflectValue(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, ep, ap)
 }
+
+// 包含自定义序列化类型字段的结构体
+//
+// `Tags` 字段为 `sets.Set` 类型, 其内部字段未导出, 通过实现 `gob.GobEncoder` 和 `gob.GobDecoder` 接口进行序列化
+type Article struct {
+	Title string
+	Tags  *sets.Set[string]
+}
+
+// 测试实现了 `gob.GobEncoder` 和 `gob.GobDecoder` 接口的类型的序列化和反序列化
+//
+// 对于包含未导出字段的类型, 编码器无法直接对其序列化, 此时可以让类型实现 `GobEncode` 和 `GobDecode` 方法,
+// 自行完成序列化和反序列化
+func TestGob_GobEncoder(t *testing.T) {
+	buf := bytes.NewBuffer(make([]byte, 0))
+
+	ea := Article{
+		Title: "Hello",
+		Tags:  sets.New[string](),
+	}
+	ea.Tags.Add("go", "gob", "set")
+
+	err := gob.NewEncoder(buf).Encode(ea)
+	assert.Nil(t, err)
+
+	var aa Article
+
+	err = gob.NewDecoder(buf).Decode(&aa)
+	assert.Nil(t, err)
+	assert.Equal(t, ea.Title, aa.Title)
+	assert.True(t, ea.Tags.Equal(aa.Tags))
+}
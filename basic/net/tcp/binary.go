@@ -0,0 +1,313 @@
+package tcp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+var (
+	// 二进制数据格式错误
+	ErrInvalidData = errors.New("invalid binary data")
+)
+
+// protobuf 的字段类型 (wire type)
+const (
+	WIRE_VARINT  = 0 // 变长整数, 用于整数和布尔类型
+	WIRE_FIXED64 = 1 // 8 字节小端序, 用于 `float64` 类型
+	WIRE_BYTES   = 2 // 长度前缀的字节串, 用于字符串, 字节切片和嵌套结构体
+	WIRE_FIXED32 = 5 // 4 字节小端序, 用于 `float32` 类型
+)
+
+// 类似 protobuf 的二进制编解码器
+//
+// 结构体被编码为 protobuf 的消息格式, 所以其它语言的对端可以通过对应的 `.proto` 定义使用标准的 protobuf 库进行解码:
+//   - 字段编号通过 `tcp:"<编号>"` 标签指定, 未指定时为字段的序号 (从 `1` 开始), `tcp:"-"` 表示忽略该字段;
+//   - 有符号整数使用 zigzag 编码 (对应 protobuf 的 `sint32`/`sint64` 类型), 无符号整数对应 `uint32`/`uint64` 类型;
+//   - `float32`/`float64` 对应 `float`/`double` 类型, `string` 和 `[]byte` 对应 `string` 和 `bytes` 类型;
+//   - 切片对应 `repeated` 字段 (非 packed 格式), 结构体及其指针对应嵌套消息;
+//   - 和 proto3 一致, 零值的标量字段不会被编码;
+//
+// 不支持 map, 接口和通道等类型的字段
+type binaryCodec struct{}
+
+func (binaryCodec) Name() string { return CODEC_BINARY }
+
+// 结构体字段的编码信息
+type fieldInfo struct {
+	index int // 字段在结构体中的下标
+	num   int // 字段编号
+}
+
+// 缓存每个结构体类型的字段编码信息
+var fieldCache sync.Map
+
+// 获取结构体类型的字段编码信息
+func structFields(t reflect.Type) ([]fieldInfo, error) {
+	if fs, ok := fieldCache.Load(t); ok {
+		return fs.([]fieldInfo), nil
+	}
+
+	fs := make([]fieldInfo, 0, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		num := i + 1
+		if tag, ok := f.Tag.Lookup("tcp"); ok {
+			if tag == "-" {
+				continue
+			}
+
+			n, err := strconv.Atoi(tag)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid field number %q of %v.%v", tag, t, f.Name)
+			}
+			num = n
+		}
+		fs = append(fs, fieldInfo{index: i, num: num})
+	}
+
+	fieldCache.Store(t, fs)
+	return fs, nil
+}
+
+// 将结构体编码为 protobuf 消息
+func (binaryCodec) Marshal(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+	return appendStruct(nil, rv)
+}
+
+// 将结构体的所有字段编码后追加到 `b` 参数中
+func appendStruct(b []byte, rv reflect.Value) ([]byte, error) {
+	fs, err := structFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range fs {
+		if b, err = appendField(b, f.num, rv.Field(f.index), true); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// 追加字段编号和字段类型
+func appendTag(b []byte, num int, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(num)<<3|uint64(wire))
+}
+
+// 将一个字段编码后追加到 `b` 参数中
+//
+// `omitZero` 参数表示是否忽略零值, 对于切片中的元素, 零值也需要编码, 否则会丢失元素
+func appendField(b []byte, num int, v reflect.Value, omitZero bool) ([]byte, error) {
+	if omitZero && v.Kind() != reflect.Struct && v.IsZero() {
+		return b, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b = appendTag(b, num, WIRE_VARINT)
+		if v.Bool() {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := v.Int()
+		return binary.AppendUvarint(appendTag(b, num, WIRE_VARINT), uint64(n<<1)^uint64(n>>63)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return binary.AppendUvarint(appendTag(b, num, WIRE_VARINT), v.Uint()), nil
+	case reflect.Float32:
+		return binary.LittleEndian.AppendUint32(appendTag(b, num, WIRE_FIXED32), math.Float32bits(float32(v.Float()))), nil
+	case reflect.Float64:
+		return binary.LittleEndian.AppendUint64(appendTag(b, num, WIRE_FIXED64), math.Float64bits(v.Float())), nil
+	case reflect.String:
+		b = binary.AppendUvarint(appendTag(b, num, WIRE_BYTES), uint64(v.Len()))
+		return append(b, v.String()...), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b = binary.AppendUvarint(appendTag(b, num, WIRE_BYTES), uint64(v.Len()))
+			return append(b, v.Bytes()...), nil
+		}
+
+		// 切片的每个元素均编码为一个相同编号的字段
+		var err error
+		for i := range v.Len() {
+			if b, err = appendField(b, num, v.Index(i), false); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case reflect.Struct:
+		msg, err := appendStruct(nil, v)
+		if err != nil {
+			return nil, err
+		}
+		b = binary.AppendUvarint(appendTag(b, num, WIRE_BYTES), uint64(len(msg)))
+		return append(b, msg...), nil
+	case reflect.Pointer:
+		if v.IsNil() {
+			return b, nil
+		}
+		return appendField(b, num, v.Elem(), false)
+	}
+	return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, v.Type())
+}
+
+// 将 protobuf 消息解码到 `v` 参数指向的结构体中, 未知的字段会被忽略
+func (binaryCodec) Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+	return decodeStruct(data, rv.Elem())
+}
+
+// 解码 protobuf 消息的所有字段
+func decodeStruct(data []byte, rv reflect.Value) error {
+	fs, err := structFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrInvalidData
+		}
+		data = data[n:]
+
+		num, wire := int(tag>>3), int(tag&7)
+
+		// 读取字段的值, 对于 `WIRE_BYTES` 类型, `payload` 为字段的内容, 否则 `x` 为字段的值
+		var (
+			x       uint64
+			payload []byte
+		)
+		switch wire {
+		case WIRE_VARINT:
+			if x, n = binary.Uvarint(data); n <= 0 {
+				return ErrInvalidData
+			}
+		case WIRE_FIXED64:
+			if n = 8; len(data) < n {
+				return ErrInvalidData
+			}
+			x = binary.LittleEndian.Uint64(data)
+		case WIRE_FIXED32:
+			if n = 4; len(data) < n {
+				return ErrInvalidData
+			}
+			x = uint64(binary.LittleEndian.Uint32(data))
+		case WIRE_BYTES:
+			size, m := binary.Uvarint(data)
+			if m <= 0 || uint64(len(data)-m) < size {
+				return ErrInvalidData
+			}
+			payload, n = data[m:m+int(size)], m+int(size)
+		default:
+			return ErrInvalidData
+		}
+		data = data[n:]
+
+		// 查找字段编号对应的结构体字段, 未知的字段被忽略
+		for _, f := range fs {
+			if f.num == num {
+				if err := decodeField(rv.Field(f.index), wire, x, payload); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// 将字段的值解码到结构体字段中
+func decodeField(v reflect.Value, wire int, x uint64, payload []byte) error {
+	// 检查字段类型是否和结构体字段的类型匹配
+	expect := func(w int) error {
+		if wire != w {
+			return fmt.Errorf("%w: wire type %v mismatch %v", ErrInvalidData, wire, v.Type())
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if err := expect(WIRE_VARINT); err != nil {
+			return err
+		}
+		v.SetBool(x != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if err := expect(WIRE_VARINT); err != nil {
+			return err
+		}
+		v.SetInt(int64(x>>1) ^ -int64(x&1))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if err := expect(WIRE_VARINT); err != nil {
+			return err
+		}
+		v.SetUint(x)
+	case reflect.Float32:
+		if err := expect(WIRE_FIXED32); err != nil {
+			return err
+		}
+		v.SetFloat(float64(math.Float32frombits(uint32(x))))
+	case reflect.Float64:
+		if err := expect(WIRE_FIXED64); err != nil {
+			return err
+		}
+		v.SetFloat(math.Float64frombits(x))
+	case reflect.String:
+		if err := expect(WIRE_BYTES); err != nil {
+			return err
+		}
+		v.SetString(string(payload))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if err := expect(WIRE_BYTES); err != nil {
+				return err
+			}
+			v.SetBytes(append([]byte(nil), payload...))
+			return nil
+		}
+
+		// 每个相同编号的字段为切片的一个元素
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := decodeField(elem, wire, x, payload); err != nil {
+			return err
+		}
+		v.Set(reflect.Append(v, elem))
+	case reflect.Struct:
+		if err := expect(WIRE_BYTES); err != nil {
+			return err
+		}
+		return decodeStruct(payload, v)
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeField(v.Elem(), wire, x, payload)
+	default:
+		return fmt.Errorf("%w: %v", ErrUnsupportedType, v.Type())
+	}
+	return nil
+}
@@ -1,17 +1,80 @@
 package tcp
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
+	"sync"
+	"time"
 )
 
+var (
+	// 客户端已关闭错误
+	ErrClientClosed = errors.New("client closed")
+
+	// 心跳超时错误
+	ErrHeartbeatTimeout = errors.New("heartbeat timeout")
+
+	// 连接意外断开错误, 可以在重新连接后重试请求 (参见 `IsRetryable` 函数)
+	ErrConnectionLost = errors.New("connection lost")
+)
+
+// 判断请求的错误是否可以重试
+//
+// 连接意外断开 (包括心跳超时) 以及自动重连客户端正在重新连接时, 请求的错误可以重试,
+// 但请求可能已经被服务端处理, 所以只应重试幂等的请求
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrConnectionLost)
+}
+
 // 客户端结构体
+//
+// 每个请求携带唯一的 id, 服务端的响应携带相同的 id, 所以多个请求可以同时通过同一个连接发送,
+// 且响应可以乱序返回; 客户端通过一个 goroutine 接收所有的响应, 并根据 id 将响应交给对应的请求,
+// 所以客户端可以被多个 goroutine 同时使用
 type Client struct {
-	conn *TCPConn
+	conn      *TCPConn
+	mux       sync.RWMutex              // 保护响应类型注册表的读写锁
+	responses map[ActionCode]func() any // 业务码对应的响应内容实例的创建函数
+
+	pmux    sync.Mutex       // 保护等待响应的请求的互斥锁
+	pending map[uint64]*call // 等待响应的请求
+	nextId  uint64           // 下一个请求的 id
+	err     error            // 客户端不可用的原因, 不为 `nil` 表示客户端已关闭或连接已断开
+	done    chan struct{}    // 接收响应的 goroutine 结束时关闭的通道
+}
+
+// 等待响应的请求
+type call struct {
+	action ActionCode // 请求的业务码
+	resp   any        // 用于解码响应内容的实例
+	future *Future    // 请求的响应
 }
 
 // 连接服务端
-func Connect(address string) (*Client, error) {
+//
+// 连接建立后, 通过握手和服务端协商编解码器, 可通过 `WithCodecs` 参数设置客户端支持的编解码器及其优先级;
+// 可通过 `WithReadTimeout`, `WithWriteTimeout`, `WithIdleTimeout` 以及 `WithHandshakeTimeout` 参数设置连接的超时时间,
+// 通过 `WithHeartbeat` 参数定时发送心跳
+func Connect(address string, opts ...ClientOption) (*Client, error) {
+	return connect(address, nil, newClientOptions(opts))
+}
+
+// 通过 TLS 连接服务端
+//
+// `config` 参数需包含用于校验服务端证书的 `RootCAs` 字段 (为空时使用系统根证书), `ServerName` 字段为空时,
+// 使用 `address` 参数中的主机名; 如果服务端要求校验客户端证书 (双向 TLS), 需通过 `Certificates` 字段提供客户端证书
+func ConnectTLS(address string, config *tls.Config, opts ...ClientOption) (*Client, error) {
+	if config == nil {
+		return nil, ErrNoTLSConfig
+	}
+	return connect(address, config, newClientOptions(opts))
+}
+
+// 连接服务端, `config` 参数为 `nil` 表示不使用 TLS
+func connect(address string, config *tls.Config, o *clientOptions) (*Client, error) {
 	// 解析字符串地址
 	addr, err := net.ResolveTCPAddr("tcp", address)
 	if err != nil {
@@ -25,76 +88,292 @@ func Connect(address string) (*Client, error) {
 	}
 	cLog.Printf("Connect to server %v", addr)
 
-	// 设置连接的发送和接收超时
-	// conn.SetReadDeadline(time.Now().Add(time.Second * 10))
-	// conn.SetWriteDeadline(time.Now().Add(time.Second * 30))
+	codecs := o.codecs
+	if len(codecs) == 0 {
+		codecs = defaultCodecs
+	}
+
+	// 使用 TLS 时, 先完成 TLS 握手
+	var nc net.Conn = conn
+	if config != nil {
+		if nc, err = tlsHandshake(conn, address, config, &o.options); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	// 设置连接的超时时间, 并握手协商编解码器
+	tc := NewTCPConn(nc)
+	tc.setTimeouts(&o.options)
+	if err := tc.clientHandshake(codecs); err != nil {
+		tc.Close()
+		return nil, err
+	}
+	cLog.Printf("Handshake with server %v, codec=%v", addr, tc.Codec().Name())
+
+	// 返回 Client 结构体, 并注册内置业务的响应类型
+	c := &Client{
+		conn:      tc,
+		responses: make(map[ActionCode]func() any),
+		pending:   make(map[uint64]*call),
+		done:      make(chan struct{}),
+	}
+	RegisterResponse[LoginAck](c, ACTION_LOGIN)
+	RegisterResponse[ShutdownAck](c, ACTION_SHUTDOWN)
+	RegisterResponse[PingAck](c, ACTION_PING)
+
+	// 启动接收响应的 goroutine
+	go c.receiveLoop()
+
+	// 启动发送心跳的 goroutine
+	if o.heartbeat > 0 {
+		go c.heartbeatLoop(o.heartbeat)
+	}
+
+	return c, nil
+}
+
+// 作为客户端进行 TLS 握手, 握手时间受握手超时时间限制
+func tlsHandshake(conn *net.TCPConn, address string, config *tls.Config, o *options) (*tls.Conn, error) {
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+
+		config = config.Clone()
+		config.ServerName = host
+	}
+	tlsConn := tls.Client(conn, config)
+
+	conn.SetDeadline(deadline(o.handshake()))
+	defer conn.SetDeadline(time.Time{})
+
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// 注册业务码对应的响应类型, `factory` 参数用于创建解码响应内容的实例
+//
+// 注册后, 可以通过 `Request` 方法发送该业务码的请求, 同一个业务码的注册会被替换
+func (c *Client) Register(code ActionCode, factory func() any) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
 
-	// 返回 Client 结构体
-	return &Client{
-		conn: NewTCPConn(conn),
-	}, nil
+	c.responses[code] = factory
 }
 
-// 发送请求数据, 返回
+// 注册业务码对应的响应类型, 响应内容被解码为 `*Resp` 类型
+func RegisterResponse[Resp any](c *Client, code ActionCode) {
+	c.Register(code, func() any { return new(Resp) })
+}
+
+// 发送请求数据, 等待并返回响应内容
+//
+// 响应内容的类型由 `Register` 方法注册, 业务码未注册时返回 `ErrUnknownAction` 错误;
+// 服务端返回错误响应时, 返回 `*Error` 类型的错误
 func (c *Client) Request(action ActionCode, body interface{}) (interface{}, error) {
-	if err := c.sendRequest(action, body); err != nil {
+	return c.RequestAsync(action, body).Await(context.Background())
+}
+
+// 发送请求数据, 不等待响应, 返回表示响应的 `Future` 实例
+//
+// 可以连续发送多个请求后再依次等待响应, 以减少等待网络往返的时间
+func (c *Client) RequestAsync(action ActionCode, body interface{}) *Future {
+	c.mux.RLock()
+	factory, ok := c.responses[action]
+	c.mux.RUnlock()
+
+	if !ok {
+		f := newFuture()
+		f.resolve(nil, NewError(ERROR_UNKNOWN_ACTION, "response type of %v not registered", action))
+		return f
+	}
+	return c.send(action, body, factory())
+}
+
+// 发送请求/响应类型确定的请求, 无需注册响应类型
+func Call[Req, Resp any](c *Client, action ActionCode, ask *Req) (*Resp, error) {
+	resp, err := c.send(action, ask, new(Resp)).Await(context.Background())
+	if err != nil {
 		return nil, err
 	}
-	return c.receiveResponse(action)
+	return resp.(*Resp), nil
 }
 
-// 发送请求
-func (c *Client) sendRequest(action ActionCode, body interface{}) error {
-	// 发送请求头
-	if err := c.conn.Encode(&AskHeader{Action: action}); err != nil {
-		return err
+// 发送请求, 响应内容会被解码到 `resp` 参数指向的实例中
+func (c *Client) send(action ActionCode, body any, resp any) *Future {
+	f := newFuture()
+
+	// 分配请求 id, 并记录等待响应的请求
+	c.pmux.Lock()
+	if c.err != nil {
+		c.pmux.Unlock()
+		f.resolve(nil, c.err)
+		return f
 	}
-	cLog.Printf("Send ask header to %v, action=%v", c.conn.RemoteAddr(), action)
 
-	// 发送请求内容
-	if err := c.conn.Encode(body); err != nil {
-		return err
+	c.nextId++
+	id := c.nextId
+	c.pending[id] = &call{action: action, resp: resp, future: f}
+	c.pmux.Unlock()
+
+	// 放弃等待响应时删除等待响应的请求, 请求已结束时 (已取出) 不做任何操作
+	f.abandon = func(err error) {
+		if cl := c.take(id); cl != nil {
+			cl.future.resolve(nil, err)
+		}
 	}
-	cLog.Printf("Send ask body to %v, action=%v", c.conn.RemoteAddr(), action)
 
-	return nil
+	// 将请求头和请求内容作为一帧发送
+	if err := c.conn.WriteFrame(&AskHeader{Action: action, Id: id}, body); err != nil {
+		if cl := c.take(id); cl != nil {
+			cl.future.resolve(nil, err)
+		}
+		return f
+	}
+	cLog.Printf("Send ask to %v, action=%v, id=%v", c.conn.RemoteAddr(), action, id)
+
+	return f
+}
+
+// 取出等待响应的请求, 请求不存在时返回 `nil`
+func (c *Client) take(id uint64) *call {
+	c.pmux.Lock()
+	defer c.pmux.Unlock()
+
+	cl := c.pending[id]
+	delete(c.pending, id)
+	return cl
 }
 
-// 接收响应
-func (c *Client) receiveResponse(action ActionCode) (interface{}, error) {
-	// 接收响应头
+// 接收所有的响应, 并交给对应的请求
+//
+// 连接断开后, 所有等待响应的请求均以 `ErrConnectionLost` 错误结束
+func (c *Client) receiveLoop() {
+	defer close(c.done)
+
+	var err error
+	for err == nil {
+		err = c.receiveResponse()
+	}
+
+	c.pmux.Lock()
+	if c.err == nil {
+		c.err = fmt.Errorf("%w: %w", ErrConnectionLost, err)
+	}
+	pending := c.pending
+	c.pending = make(map[uint64]*call)
+	c.pmux.Unlock()
+
+	for _, cl := range pending {
+		cl.future.resolve(nil, c.err)
+	}
+}
+
+// 接收一个响应
+//
+// 返回错误表示连接已无法继续使用
+func (c *Client) receiveResponse() error {
+	// 接收响应帧
+	frame, err := c.conn.ReadFrame()
+	if err != nil {
+		return err
+	}
+
+	// 解码响应头
 	header := AckHeader{}
-	if err := c.conn.Decode(&header); err != nil {
-		return nil, err
+	if err := frame.Header(&header); err != nil {
+		return err
+	}
+	cLog.Printf("Receive ack header from %v, action=%v, id=%v", c.conn.RemoteAddr(), header.Action, header.Id)
+
+	// 查找响应对应的请求, 请求可能已经放弃等待 (例如超时)
+	cl := c.take(header.Id)
+	if cl == nil {
+		return nil
 	}
 
 	// 确认响应正确
-	if header.Action != action {
-		return nil, fmt.Errorf("invalid response action %v", header.Action)
+	if header.Action != cl.action {
+		cl.future.resolve(nil, fmt.Errorf("invalid response action %v", header.Action))
+		return nil
 	}
-	cLog.Printf("Receive ack header from %v, action=%v", c.conn.RemoteAddr(), action)
 
-	// 根据响应头接收响应内容
-	var resp interface{} = nil
-	switch action {
-	case ACTION_LOGIN:
-		resp = &LoginAck{}
-	case ACTION_SHUTDOWN:
-		resp = &ShutdownAck{}
-	default:
-		return nil, fmt.Errorf("invalid action code %v", action)
+	// 错误响应没有内容
+	if !header.IsOk {
+		cl.future.resolve(nil, &Error{Code: header.Code, Message: header.Error})
+		return nil
 	}
 
-	if err := c.conn.Decode(resp); err != nil {
-		return nil, err
+	if err := frame.Body(cl.resp); err != nil {
+		cl.future.resolve(nil, err)
+		return nil
 	}
-	cLog.Printf("Receive ack body from %v, action=%v", c.conn.RemoteAddr(), action)
+	cLog.Printf("Receive ack body from %v, action=%v, id=%v", c.conn.RemoteAddr(), header.Action, header.Id)
 
-	return resp, nil
+	cl.future.resolve(cl.resp, nil)
+	return nil
+}
+
+// 向服务端发送心跳请求, 返回请求的往返时间
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	if _, err := c.send(ACTION_PING, &PingAsk{Time: start.UnixNano()}, &PingAck{}).Await(ctx); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// 定时发送心跳, 心跳在下一次发送前仍未收到响应时, 认为连接已经断开, 关闭连接
+//
+// 关闭连接后, 所有等待响应的请求均以包装了 `ErrHeartbeatTimeout` 的 `ErrConnectionLost` 错误结束
+func (c *Client) heartbeatLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		_, err := c.Ping(ctx)
+		cancel()
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			cLog.Printf("Heartbeat to %v timeout, close connection", c.conn.RemoteAddr())
+
+			c.pmux.Lock()
+			if c.err == nil {
+				c.err = fmt.Errorf("%w: %w", ErrConnectionLost, ErrHeartbeatTimeout)
+			}
+			c.pmux.Unlock()
+
+			c.conn.Close()
+			return
+		}
+	}
 }
 
 // 关闭连接
+//
+// 关闭后, 所有等待响应的请求均以 `ErrClientClosed` 错误结束
 func (c *Client) Close() error {
+	c.pmux.Lock()
+	if c.err == nil {
+		c.err = ErrClientClosed
+	}
+	c.pmux.Unlock()
+
 	cLog.Printf("Connection %v closed", c.conn.RemoteAddr())
-	return c.conn.Close()
+	err := c.conn.Close()
+
+	// 等待接收响应的 goroutine 结束
+	<-c.done
+	return err
 }
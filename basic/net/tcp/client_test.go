@@ -0,0 +1,125 @@
+package tcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试使用的业务码
+const (
+	ACTION_SLEEP ActionCode = 200 // 等待指定时长后响应的业务码
+)
+
+// 等待请求和响应结构体
+type SleepAsk struct {
+	Millis int `json:"millis"`
+}
+
+type SleepAck struct {
+	Millis int `json:"millis"`
+}
+
+// 启动测试服务端, 注册等待业务处理器, 并连接服务端
+func startSleepServer(t *testing.T) (*Server, *Client) {
+	server, client := startEchoServer(t)
+
+	server.Handle(ACTION_SLEEP, Typed(func(ctx context.Context, ask *SleepAsk) (*SleepAck, error) {
+		time.Sleep(time.Duration(ask.Millis) * time.Millisecond)
+		return &SleepAck{Millis: ask.Millis}, nil
+	}))
+	RegisterResponse[SleepAck](client, ACTION_SLEEP)
+
+	return server, client
+}
+
+// 测试异步发送多个请求, 响应乱序返回
+func TestClient_RequestAsync(t *testing.T) {
+	_, client := startSleepServer(t)
+
+	// 第一个请求的处理时间最长
+	slow := client.RequestAsync(ACTION_SLEEP, &SleepAsk{Millis: 200})
+	fast := client.RequestAsync(ACTION_SLEEP, &SleepAsk{Millis: 10})
+
+	// 后发送的请求先返回响应
+	select {
+	case <-fast.Done():
+	case <-slow.Done():
+		assert.Fail(t, "slow request finished first")
+	}
+
+	resp, err := fast.Await(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 10, resp.(*SleepAck).Millis)
+
+	resp, err = slow.Await(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 200, resp.(*SleepAck).Millis)
+}
+
+// 测试多个 goroutine 同时通过同一个客户端发送请求
+func TestClient_Concurrent(t *testing.T) {
+	_, client := startEchoServer(t)
+
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Go(func() {
+			for n := range 20 {
+				text := fmt.Sprintf("g%d-%d", i, n)
+
+				ack, err := Call[EchoAsk, EchoAck](client, ACTION_ECHO, &EchoAsk{Text: text})
+				if assert.Nil(t, err) {
+					assert.Equal(t, fmt.Sprintf("G%d-%d", i, n), ack.Text)
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// 测试等待响应超时
+func TestClient_AwaitTimeout(t *testing.T) {
+	_, client := startSleepServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	f := client.RequestAsync(ACTION_SLEEP, &SleepAsk{Millis: 100})
+	_, err := f.Await(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 超时后请求不再等待响应, 已从等待响应的请求中删除
+	client.pmux.Lock()
+	assert.Empty(t, client.pending)
+	client.pmux.Unlock()
+
+	_, err = f.Await(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 超时后到达的响应被丢弃, 不影响之后的请求
+	time.Sleep(100 * time.Millisecond)
+	resp, err := client.Request(ACTION_SLEEP, &SleepAsk{Millis: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, resp.(*SleepAck).Millis)
+}
+
+// 测试关闭客户端时, 等待响应的请求以错误结束
+func TestClient_Close(t *testing.T) {
+	_, client := startSleepServer(t)
+
+	f := client.RequestAsync(ACTION_SLEEP, &SleepAsk{Millis: 200})
+	time.Sleep(20 * time.Millisecond)
+
+	client.Close()
+
+	_, err := f.Await(context.Background())
+	assert.ErrorIs(t, err, ErrClientClosed)
+
+	// 关闭后发送的请求直接返回错误
+	_, err = client.Request(ACTION_SLEEP, &SleepAsk{Millis: 1})
+	assert.ErrorIs(t, err, ErrClientClosed)
+}
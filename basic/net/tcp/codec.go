@@ -0,0 +1,90 @@
+package tcp
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+var (
+	// 编解码器不支持的数据类型错误
+	ErrUnsupportedType = errors.New("unsupported type")
+)
+
+// 编解码器接口
+//
+// 编解码器负责将请求/响应的头和内容编码为字节串 (以及反向解码), 每个头或内容被独立编码,
+// 再由分帧层 (参见 `frame.go`) 组合为一帧发送, 所以编解码器无需关心数据的边界
+type Codec interface {
+	// 编解码器名称, 在握手时用于协商双方使用的编解码器
+	Name() string
+
+	// 将数据编码为字节串
+	Marshal(v any) ([]byte, error)
+
+	// 将字节串解码到 `v` 参数指向的实例中
+	Unmarshal(data []byte, v any) error
+}
+
+// 内置编解码器的名称
+const (
+	CODEC_GOB    = "gob"    // 使用 `encoding/gob` 编码, 仅适用于 Go 语言的对端
+	CODEC_JSON   = "json"   // 使用 `encoding/json` 编码
+	CODEC_BINARY = "binary" // 使用类似 protobuf 的二进制编码, 参见 `binaryCodec` 类型
+)
+
+var (
+	codecMux sync.RWMutex
+	codecs   = map[string]Codec{
+		CODEC_GOB:    gobCodec{},
+		CODEC_JSON:   jsonCodec{},
+		CODEC_BINARY: binaryCodec{},
+	}
+)
+
+// 注册编解码器, 同名的编解码器会被替换
+func RegisterCodec(c Codec) {
+	codecMux.Lock()
+	defer codecMux.Unlock()
+
+	codecs[c.Name()] = c
+}
+
+// 根据名称获取已注册的编解码器
+func LookupCodec(name string) (Codec, bool) {
+	codecMux.RLock()
+	defer codecMux.RUnlock()
+
+	c, ok := codecs[name]
+	return c, ok
+}
+
+// 使用 `encoding/gob` 的编解码器
+//
+// 由于每个头或内容被独立编码, 所以每次编码都会包含类型信息, 编码结果比连续的 gob 流略大
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return CODEC_GOB }
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// 使用 `encoding/json` 的编解码器
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return CODEC_JSON }
+
+func (jsonCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
@@ -0,0 +1,93 @@
+package tcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 用于测试二进制编解码器的结构体
+type binaryMessage struct {
+	Name    string         `tcp:"1"`
+	Id      int64          `tcp:"2"`
+	Count   uint32         `tcp:"3"`
+	Ok      bool           `tcp:"4"`
+	Score   float64        `tcp:"5"`
+	Ratio   float32        `tcp:"6"`
+	Data    []byte         `tcp:"7"`
+	Tags    []string       `tcp:"8"`
+	Nums    []int          `tcp:"9"`
+	Child   *binaryMessage `tcp:"10"`
+	Ignored string         `tcp:"-"`
+	private int
+}
+
+// 测试二进制编解码器的编码和解码
+func TestCodec_Binary(t *testing.T) {
+	codec := binaryCodec{}
+
+	msg := binaryMessage{
+		Name:    "Alvin",
+		Id:      -100,
+		Count:   3,
+		Ok:      true,
+		Score:   99.5,
+		Ratio:   0.25,
+		Data:    []byte{1, 2, 3},
+		Tags:    []string{"a", "", "c"},
+		Nums:    []int{0, -1, 1},
+		Child:   &binaryMessage{Name: "Emma"},
+		Ignored: "ignored",
+	}
+
+	data, err := codec.Marshal(&msg)
+	assert.Nil(t, err)
+
+	var out binaryMessage
+	err = codec.Unmarshal(data, &out)
+	assert.Nil(t, err)
+
+	msg.Ignored = ""
+	assert.Equal(t, msg, out)
+}
+
+// 测试二进制编解码器的编码结果和 protobuf 一致
+func TestCodec_BinaryWireFormat(t *testing.T) {
+	codec := binaryCodec{}
+
+	// 字段 1 为字符串, 字段 2 为字符串
+	data, err := codec.Marshal(&LoginAsk{Account: "a", Password: "b"})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x0a, 0x01, 'a', 0x12, 0x01, 'b'}, data)
+
+	// 有符号整数使用 zigzag 编码, 零值的字段不被编码
+	data, err = codec.Marshal(&AckHeader{Action: -1})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x08, 0x01}, data)
+
+	// 解码时忽略未知的字段 (字段 15, 变长整数)
+	var ask LoginAsk
+	err = codec.Unmarshal([]byte{0x78, 0x01, 0x0a, 0x01, 'a'}, &ask)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", ask.Account)
+
+	// 格式错误的数据
+	err = codec.Unmarshal([]byte{0x0a, 0x05, 'a'}, &ask)
+	assert.ErrorIs(t, err, ErrInvalidData)
+
+	// 不支持的类型
+	_, err = codec.Marshal(1)
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+// 测试通过名称获取编解码器
+func TestCodec_Lookup(t *testing.T) {
+	for _, name := range []string{CODEC_GOB, CODEC_JSON, CODEC_BINARY} {
+		codec, ok := LookupCodec(name)
+		assert.True(t, ok)
+		assert.Equal(t, name, codec.Name())
+	}
+
+	_, ok := LookupCodec("xml")
+	assert.False(t, ok)
+}
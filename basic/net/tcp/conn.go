@@ -1,37 +1,147 @@
 package tcp
 
 import (
-	"encoding/gob"
+	"bufio"
+	"errors"
+	"io"
 	"net"
+	"sync"
 	"sync/atomic"
+	"time"
+)
+
+var (
+	// 连接已停止接收数据错误
+	ErrInterrupted = errors.New("connection interrupted")
 )
 
 // 包装 TCP 连接实例
+//
+// 连接建立后需先通过握手协商编解码器, 之后通过帧收发数据, 参见 `frame.go`
 type TCPConn struct {
-	conn   *net.TCPConn // TCP 连接实例
-	dec    *gob.Decoder // 接收数据的解码实例
-	enc    *gob.Encoder // 发送数据的编码实例
-	closed int32        // 连接是否已经关闭
+	conn   net.Conn      // 连接实例, TCP 连接或基于 TCP 连接的 TLS 连接
+	r      *bufio.Reader // 接收数据的缓冲
+	codec  Codec         // 握手协商得到的编解码器
+	wmux   sync.Mutex    // 保证每一帧数据被完整写入的互斥锁
+	closed atomic.Bool   // 连接是否已经关闭
+
+	interrupted atomic.Bool // 连接是否已停止接收数据
+
+	readTimeout  time.Duration // 接收一帧数据的超时时间
+	writeTimeout time.Duration // 发送一帧数据的超时时间
+	idleTimeout  time.Duration // 等待下一帧数据的超时时间
+
+	handshakeTimeout time.Duration // 完成握手的超时时间
 }
 
-// 创建实例
-func NewTCPConn(conn *net.TCPConn) *TCPConn {
+// 创建实例, `conn` 参数为 TCP 连接, 或基于 TCP 连接的 TLS 连接 (`*tls.Conn`)
+func NewTCPConn(conn net.Conn) *TCPConn {
 	return &TCPConn{
-		conn:   conn,
-		dec:    gob.NewDecoder(conn),
-		enc:    gob.NewEncoder(conn),
-		closed: 0,
+		conn: conn,
+		r:    bufio.NewReader(conn),
+	}
+}
+
+// 根据可选参数设置连接的超时时间
+func (c *TCPConn) setTimeouts(o *options) {
+	c.readTimeout, c.writeTimeout, c.idleTimeout = o.readTimeout, o.writeTimeout, o.idleTimeout
+	c.handshakeTimeout = o.handshake()
+}
+
+// 根据超时时间计算截止时间, 超时时间为 `0` 时返回零值 (表示不限制)
+func deadline(d time.Duration) time.Time {
+	if d <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(d)
+}
+
+// 获取握手使用的读写实例, 读取时使用缓冲, 写入时直接使用连接
+func (c *TCPConn) rw() io.ReadWriter {
+	return struct {
+		io.Reader
+		io.Writer
+	}{c.r, c.conn}
+}
+
+// 作为客户端进行握手, `names` 参数为按优先级排列的编解码器名称
+func (c *TCPConn) clientHandshake(names []string) (err error) {
+	c.conn.SetDeadline(deadline(c.handshakeTimeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	c.codec, err = clientHandshake(c.rw(), names)
+	return
+}
+
+// 作为服务端进行握手, `names` 参数为服务端支持的编解码器名称, 为空表示支持所有已注册的编解码器
+func (c *TCPConn) serverHandshake(names []string) (err error) {
+	c.conn.SetDeadline(deadline(c.handshakeTimeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	c.codec, err = serverHandshake(c.rw(), names)
+	return
+}
+
+// 获取握手协商得到的编解码器
+func (c *TCPConn) Codec() Codec { return c.codec }
+
+// 将头和内容编码为一帧后发送
+//
+// 可以被多个 goroutine 同时调用, 每一帧数据会被完整的写入
+//
+// 写入失败 (包括写入超时) 时, 连接中可能已写入了不完整的帧, 之后的数据无法被正确解析, 所以会关闭连接;
+// 编码失败 (返回 `ErrEncodeFrame` 错误) 时没有数据被写入, 连接仍可继续使用
+func (c *TCPConn) WriteFrame(header, body any) error {
+	b, err := encodeFrame(c.codec, header, body)
+	if err != nil {
+		return err
+	}
+
+	c.wmux.Lock()
+	defer c.wmux.Unlock()
+
+	c.conn.SetWriteDeadline(deadline(c.writeTimeout))
+	if _, err = c.conn.Write(b); err != nil {
+		c.Close()
+	}
+	return err
+}
+
+// 接收一帧数据
+//
+// 等待一帧数据开始的时间受空闲超时时间限制, 接收到第一个字节后, 接收整帧数据的时间受接收超时时间限制
+//
+// 连接停止接收数据后 (参见 `interrupt` 方法), 返回 `ErrInterrupted` 错误
+func (c *TCPConn) ReadFrame() (*Frame, error) {
+	if c.interrupted.Load() {
+		return nil, ErrInterrupted
 	}
+
+	if c.r.Buffered() == 0 {
+		c.conn.SetReadDeadline(deadline(c.idleTimeout))
+
+		// 设置截止时间后再次检查, 保证 `interrupt` 方法设置的截止时间不会被覆盖
+		if c.interrupted.Load() {
+			return nil, ErrInterrupted
+		}
+		if _, err := c.r.Peek(1); err != nil {
+			return nil, err
+		}
+	}
+
+	c.conn.SetReadDeadline(deadline(c.readTimeout))
+	return readFrame(c.r, c.codec)
 }
 
-// 将数据编码后发送
-func (c *TCPConn) Encode(input interface{}) error {
-	return c.enc.Encode(input)
+// 停止接收数据, 正在等待数据的 `ReadFrame` 方法立即返回, 但仍可以发送数据
+func (c *TCPConn) interrupt() {
+	c.interrupted.Store(true)
+	c.conn.SetReadDeadline(time.Now())
 }
 
-// 接收数据并解码
-func (c *TCPConn) Decode(output interface{}) error {
-	return c.dec.Decode(output)
+// 返回连接是否已停止接收数据
+func (c *TCPConn) IsInterrupted() bool {
+	return c.interrupted.Load()
 }
 
 // 获取远端连接地址
@@ -41,7 +151,7 @@ func (c *TCPConn) RemoteAddr() net.Addr {
 
 // 关闭当前连接
 func (c *TCPConn) Close() error {
-	if atomic.SwapInt32(&c.closed, 1) == 0 {
+	if c.closed.CompareAndSwap(false, true) {
 		return c.conn.Close()
 	}
 	return nil
@@ -49,5 +159,5 @@ func (c *TCPConn) Close() error {
 
 // 返回当前连接是否已被关闭
 func (c *TCPConn) IsClosed() bool {
-	return atomic.LoadInt32(&c.closed) == 0
+	return c.closed.Load()
 }
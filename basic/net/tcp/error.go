@@ -0,0 +1,73 @@
+package tcp
+
+import "fmt"
+
+// 定义错误码, 随错误响应发送给客户端
+type ErrorCode int
+
+const (
+	ERROR_NONE           ErrorCode = iota // 没有错误
+	ERROR_UNKNOWN_ACTION                  // 服务端没有处理该业务码的处理器
+	ERROR_BAD_REQUEST                     // 请求内容无法解码
+	ERROR_UNAUTHORIZED                    // 未登录或没有权限
+	ERROR_INTERNAL                        // 服务端内部错误
+)
+
+// 错误码转字符串
+func (c ErrorCode) String() string {
+	switch c {
+	case ERROR_NONE:
+		return "NONE"
+	case ERROR_UNKNOWN_ACTION:
+		return "UNKNOWN_ACTION"
+	case ERROR_BAD_REQUEST:
+		return "BAD_REQUEST"
+	case ERROR_UNAUTHORIZED:
+		return "UNAUTHORIZED"
+	case ERROR_INTERNAL:
+		return "INTERNAL"
+	default:
+		return fmt.Sprintf("ERROR(%d)", int(c))
+	}
+}
+
+// 请求处理错误
+//
+// 处理器返回该类型的错误时, 其错误码和错误信息会通过错误响应发送给客户端, 返回其它错误时, 错误码为 `ERROR_INTERNAL`;
+// 客户端接收到错误响应时, 同样返回该类型的错误
+type Error struct {
+	Code    ErrorCode // 错误码
+	Message string    // 错误信息
+}
+
+// 创建错误实例
+func NewError(code ErrorCode, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return e.Code.String()
+	}
+	return fmt.Sprintf("%v: %v", e.Code, e.Message)
+}
+
+// 判断错误码是否相同, 以便通过 `errors.Is` 函数和下面定义的错误进行比较
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+var (
+	// 未知业务码错误
+	ErrUnknownAction = &Error{Code: ERROR_UNKNOWN_ACTION}
+
+	// 请求内容错误
+	ErrBadRequest = &Error{Code: ERROR_BAD_REQUEST}
+
+	// 未登录或没有权限错误
+	ErrUnauthorized = &Error{Code: ERROR_UNAUTHORIZED}
+
+	// 服务端内部错误
+	ErrInternal = &Error{Code: ERROR_INTERNAL}
+)
@@ -0,0 +1,215 @@
+package tcp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+/**
+ * 帧格式和握手协议
+ *
+ * 连接建立后, 客户端和服务端首先进行握手, 协商双方使用的编解码器:
+ *
+ *   客户端 -> 服务端: | "GTCP" (4 字节) | 版本号 (1 字节) | 编解码器个数 N (1 字节) | N 个 (名称长度 (1 字节) | 名称) |
+ *   服务端 -> 客户端: | 状态 (1 字节, 0 表示成功) | 名称长度 (1 字节) | 选定的编解码器名称 |
+ *
+ * 客户端按优先级从高到低列出支持的编解码器, 服务端选择第一个自身也支持的编解码器; 如果没有双方均支持的编解码器,
+ * 则服务端返回非 0 的状态并关闭连接
+ *
+ * 握手之后, 双方通过帧交换数据, 每一帧包含一个请求 (或响应) 的头和内容, 头和内容分别通过选定的编解码器编码:
+ *
+ *   | 帧长度 L (4 字节) | 头长度 H (4 字节) | 头 (H 字节) | 内容 (L - 4 - H 字节) |
+ *
+ * 其中所有的整数均为大端序, 帧长度不包括其自身的 4 个字节
+ *
+ * 由于帧的边界由长度前缀确定, 且编解码器可以协商, 所以其它语言的客户端只需实现上述格式即可和服务端通信
+ */
+
+const (
+	HANDSHAKE_MAGIC   = "GTCP" // 握手的魔数
+	HANDSHAKE_VERSION = 1      // 协议版本号
+
+	MAX_FRAME_SIZE = 16 << 20 // 帧的最大长度
+)
+
+// 握手的状态码
+const (
+	HANDSHAKE_OK          byte = iota // 握手成功
+	HANDSHAKE_NO_CODEC                // 没有双方均支持的编解码器
+	HANDSHAKE_BAD_VERSION             // 不支持的协议版本
+)
+
+var (
+	// 帧长度超过最大长度的错误
+	ErrFrameTooLarge = errors.New("frame too large")
+
+	// 帧编码失败的错误, 此时没有任何数据被发送, 连接仍可继续使用
+	ErrEncodeFrame = errors.New("encode frame failed")
+
+	// 握手失败的错误
+	ErrHandshake = errors.New("handshake failed")
+)
+
+// 默认的编解码器优先级
+var defaultCodecs = []string{CODEC_GOB, CODEC_JSON, CODEC_BINARY}
+
+// 写入一个长度为 1 字节的字符串
+func appendShortString(b []byte, s string) []byte {
+	return append(append(b, byte(len(s))), s...)
+}
+
+// 读取一个长度为 1 字节的字符串
+func readShortString(r io.Reader) (string, error) {
+	var n [1]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, n[0])
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// 客户端握手, 发送支持的编解码器列表, 返回服务端选定的编解码器
+func clientHandshake(rw io.ReadWriter, names []string) (Codec, error) {
+	if len(names) == 0 || len(names) > 255 {
+		return nil, fmt.Errorf("%w: invalid codec list %v", ErrHandshake, names)
+	}
+
+	b := append([]byte(HANDSHAKE_MAGIC), HANDSHAKE_VERSION, byte(len(names)))
+	for _, name := range names {
+		b = appendShortString(b, name)
+	}
+	if _, err := rw.Write(b); err != nil {
+		return nil, err
+	}
+
+	// 读取服务端的响应
+	var status [1]byte
+	if _, err := io.ReadFull(rw, status[:]); err != nil {
+		return nil, err
+	}
+
+	name, err := readShortString(rw)
+	if err != nil {
+		return nil, err
+	}
+	if status[0] != HANDSHAKE_OK {
+		return nil, fmt.Errorf("%w: status %v", ErrHandshake, status[0])
+	}
+
+	codec, ok := LookupCodec(name)
+	if !ok || !slices.Contains(names, name) {
+		return nil, fmt.Errorf("%w: unexpected codec %q", ErrHandshake, name)
+	}
+	return codec, nil
+}
+
+// 服务端握手, 从客户端支持的编解码器中选择第一个服务端也支持的编解码器
+//
+// `names` 参数为服务端支持的编解码器, 为空表示支持所有已注册的编解码器
+func serverHandshake(rw io.ReadWriter, names []string) (Codec, error) {
+	var head [6]byte
+	if _, err := io.ReadFull(rw, head[:]); err != nil {
+		return nil, err
+	}
+	if string(head[:4]) != HANDSHAKE_MAGIC {
+		return nil, fmt.Errorf("%w: bad magic %q", ErrHandshake, head[:4])
+	}
+
+	reply := func(status byte, name string) error {
+		_, err := rw.Write(appendShortString([]byte{status}, name))
+		return err
+	}
+
+	if head[4] != HANDSHAKE_VERSION {
+		reply(HANDSHAKE_BAD_VERSION, "")
+		return nil, fmt.Errorf("%w: unsupported version %v", ErrHandshake, head[4])
+	}
+
+	// 读取客户端支持的编解码器, 并选择第一个服务端也支持的编解码器
+	var codec Codec
+	for range head[5] {
+		name, err := readShortString(rw)
+		if err != nil {
+			return nil, err
+		}
+
+		if codec == nil && (len(names) == 0 || slices.Contains(names, name)) {
+			codec, _ = LookupCodec(name)
+		}
+	}
+
+	if codec == nil {
+		reply(HANDSHAKE_NO_CODEC, "")
+		return nil, fmt.Errorf("%w: no common codec", ErrHandshake)
+	}
+	return codec, reply(HANDSHAKE_OK, codec.Name())
+}
+
+// 接收到的一帧数据
+//
+// 帧的头和内容在读取时不进行解码, 因为接收方需要根据头的内容 (例如业务码) 才能确定内容的类型
+type Frame struct {
+	codec  Codec  // 解码使用的编解码器
+	header []byte // 头的编码数据
+	body   []byte // 内容的编码数据
+}
+
+// 解码帧的头
+func (f *Frame) Header(v any) error { return f.codec.Unmarshal(f.header, v) }
+
+// 解码帧的内容
+func (f *Frame) Body(v any) error { return f.codec.Unmarshal(f.body, v) }
+
+// 将头和内容编码为一帧
+func encodeFrame(codec Codec, header, body any) ([]byte, error) {
+	h, err := codec.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncodeFrame, err)
+	}
+
+	bd, err := codec.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncodeFrame, err)
+	}
+
+	size := 4 + len(h) + len(bd)
+	if size > MAX_FRAME_SIZE {
+		return nil, fmt.Errorf("%w: %w", ErrEncodeFrame, ErrFrameTooLarge)
+	}
+
+	b := make([]byte, 0, 4+size)
+	b = binary.BigEndian.AppendUint32(b, uint32(size))
+	b = binary.BigEndian.AppendUint32(b, uint32(len(h)))
+	b = append(b, h...)
+	return append(b, bd...), nil
+}
+
+// 读取一帧数据
+func readFrame(r io.Reader, codec Codec) (*Frame, error) {
+	var prefix [8]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(prefix[:4])
+	hsize := binary.BigEndian.Uint32(prefix[4:])
+	if size > MAX_FRAME_SIZE {
+		return nil, ErrFrameTooLarge
+	}
+	if size < 4 || hsize > size-4 {
+		return nil, fmt.Errorf("%w: header size %v, frame size %v", ErrInvalidData, hsize, size)
+	}
+
+	buf := make([]byte, size-4)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return &Frame{codec: codec, header: buf[:hsize], body: buf[hsize:]}, nil
+}
@@ -0,0 +1,118 @@
+package tcp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试握手协商编解码器
+func TestFrame_Handshake(t *testing.T) {
+	c, s := net.Pipe()
+	defer c.Close()
+	defer s.Close()
+
+	done := make(chan Codec)
+	go func() {
+		codec, err := serverHandshake(s, []string{CODEC_JSON, CODEC_BINARY})
+		assert.Nil(t, err)
+		done <- codec
+	}()
+
+	// 服务端不支持 gob, 选择客户端优先级最高的 binary
+	codec, err := clientHandshake(c, []string{CODEC_GOB, CODEC_BINARY, CODEC_JSON})
+	assert.Nil(t, err)
+	assert.Equal(t, CODEC_BINARY, codec.Name())
+	assert.Equal(t, CODEC_BINARY, (<-done).Name())
+}
+
+// 测试没有双方均支持的编解码器时握手失败
+func TestFrame_HandshakeNoCodec(t *testing.T) {
+	c, s := net.Pipe()
+	defer c.Close()
+	defer s.Close()
+
+	go serverHandshake(s, []string{CODEC_JSON})
+
+	_, err := clientHandshake(c, []string{CODEC_GOB})
+	assert.ErrorIs(t, err, ErrHandshake)
+}
+
+// 测试帧的编码和解码
+func TestFrame_ReadWrite(t *testing.T) {
+	codec := jsonCodec{}
+
+	data, err := encodeFrame(codec, &AskHeader{Action: ACTION_LOGIN}, &LoginAsk{Account: "Alvin"})
+	assert.Nil(t, err)
+
+	frame, err := readFrame(bytes.NewReader(data), codec)
+	assert.Nil(t, err)
+
+	var header AskHeader
+	assert.Nil(t, frame.Header(&header))
+	assert.Equal(t, ACTION_LOGIN, header.Action)
+
+	var ask LoginAsk
+	assert.Nil(t, frame.Body(&ask))
+	assert.Equal(t, "Alvin", ask.Account)
+
+	// 帧长度超过最大长度
+	_, err = readFrame(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}), codec)
+	assert.ErrorIs(t, err, ErrFrameTooLarge)
+
+	// 头长度超过帧长度
+	_, err = readFrame(bytes.NewReader([]byte{0, 0, 0, 4, 0, 0, 0, 1}), codec)
+	assert.ErrorIs(t, err, ErrInvalidData)
+}
+
+// 测试不使用 `Client` 类型, 按协议格式直接和服务端通信, 模拟其它语言的客户端
+func TestFrame_RawClient(t *testing.T) {
+	server, err := ServerStart("127.0.0.1:0")
+	assert.Nil(t, err)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	// 握手, 只支持 json 编解码器
+	_, err = conn.Write([]byte("GTCP\x01\x01\x04json"))
+	assert.Nil(t, err)
+
+	reply := make([]byte, 6)
+	_, err = io.ReadFull(conn, reply)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("\x00\x04json"), reply)
+
+	// 发送登录请求帧
+	header := []byte(`{"action":0}`)
+	body := []byte(`{"account":"Alvin","password":"password"}`)
+
+	frame := binary.BigEndian.AppendUint32(nil, uint32(4+len(header)+len(body)))
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(header)))
+	frame = append(append(frame, header...), body...)
+
+	_, err = conn.Write(frame)
+	assert.Nil(t, err)
+
+	// 接收响应帧
+	prefix := make([]byte, 8)
+	_, err = io.ReadFull(conn, prefix)
+	assert.Nil(t, err)
+
+	buf := make([]byte, binary.BigEndian.Uint32(prefix)-4)
+	_, err = io.ReadFull(conn, buf)
+	assert.Nil(t, err)
+
+	hsize := binary.BigEndian.Uint32(prefix[4:])
+
+	var ack map[string]any
+	assert.Nil(t, json.Unmarshal(buf[:hsize], &ack))
+	assert.Equal(t, true, ack["ok"])
+	assert.JSONEq(t, `{"welcome":"Hello Alvin"}`, string(buf[hsize:]))
+}
@@ -0,0 +1,45 @@
+package tcp
+
+import "context"
+
+// 表示一个异步请求的响应
+//
+// 通过 `Client.RequestAsync` 方法发送请求后立即返回 `Future` 实例, 接收到响应 (或连接断开) 后, 可通过 `Await` 方法获取结果
+type Future struct {
+	done    chan struct{}   // 接收到响应后关闭的通道
+	resp    any             // 响应内容
+	err     error           // 错误信息
+	abandon func(err error) // 放弃等待响应的函数, 由客户端设置, 用于删除等待响应的请求
+}
+
+// 创建实例
+func newFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+// 设置响应结果, 并通知等待结果的 goroutine
+//
+// 该方法只能调用一次, 由客户端保证
+func (f *Future) resolve(resp any, err error) {
+	f.resp, f.err = resp, err
+	close(f.done)
+}
+
+// 等待并返回响应结果
+//
+// 如果 `ctx` 参数在接收到响应前结束, 则返回 `ctx` 的错误, 此时请求不再等待响应 (之后到达的响应会被丢弃),
+// 其它等待该实例的 goroutine 同样得到 `ctx` 的错误
+func (f *Future) Await(ctx context.Context) (any, error) {
+	select {
+	case <-f.done:
+		return f.resp, f.err
+	case <-ctx.Done():
+		if f.abandon != nil {
+			f.abandon(ctx.Err())
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// 获取接收到响应时关闭的通道, 用于在 `select` 中等待响应
+func (f *Future) Done() <-chan struct{} { return f.done }
@@ -0,0 +1,112 @@
+package tcp
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"slices"
+	"time"
+)
+
+// 请求处理器, 返回的响应内容会被编码后发送给客户端
+//
+// 处理器返回错误时, 客户端会接收到错误响应, 参见 `Error` 类型
+type Handler func(ctx context.Context, req *Request) (any, error)
+
+// 中间件, 用于包装处理器, 在处理器执行前后进行额外的处理 (例如认证, 日志和异常恢复)
+type Middleware func(next Handler) Handler
+
+// 上下文中存储请求实例的 key 类型
+type requestKey struct{}
+
+// 从上下文中获取当前的请求实例, 用于在 `Typed` 包装的处理器中访问会话上下文
+func RequestFrom(ctx context.Context) *Request {
+	req, _ := ctx.Value(requestKey{}).(*Request)
+	return req
+}
+
+// 将请求/响应类型确定的处理函数包装为处理器
+//
+// 请求内容会被解码为 `Req` 类型的实例后传递给处理函数, 解码失败时客户端接收到 `ERROR_BAD_REQUEST` 错误响应,
+// 例如:
+//
+//	server.Handle(ACTION_LOGIN, tcp.Typed(func(ctx context.Context, ask *LoginAsk) (*LoginAck, error) {
+//		...
+//	}))
+func Typed[Req, Resp any](fn func(ctx context.Context, ask *Req) (*Resp, error)) Handler {
+	return func(ctx context.Context, req *Request) (any, error) {
+		ask := new(Req)
+		if err := req.Bind(ask); err != nil {
+			return nil, NewError(ERROR_BAD_REQUEST, "%v", err)
+		}
+
+		resp, err := fn(ctx, ask)
+		if resp == nil {
+			// 避免返回包含 `nil` 指针的接口值
+			return nil, err
+		}
+		return resp, err
+	}
+}
+
+// 将中间件依次包装到处理器上, 第一个中间件位于最外层
+func chain(h Handler, mws []Middleware) Handler {
+	for _, mw := range slices.Backward(mws) {
+		h = mw(h)
+	}
+	return h
+}
+
+// 异常恢复中间件, 将处理器中的 panic 转为 `ERROR_INTERNAL` 错误响应, 避免整个服务端进程崩溃
+func Recovery() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (resp any, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					sLog.Printf("Handle action %v panic: %v\n%s", req.Action(), r, debug.Stack())
+					resp, err = nil, NewError(ERROR_INTERNAL, "%v", r)
+				}
+			}()
+			return next(ctx, req)
+		}
+	}
+}
+
+// 日志中间件, 通过 `sLog` 记录每个请求的处理结果和耗时
+func Logging() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (any, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+
+			if err != nil && !errors.Is(err, ErrServerShouldClose) {
+				sLog.Printf("Handle action %v from %v failed in %v: %v", req.Action(), req.RemoteAddr(), time.Since(start), err)
+			} else {
+				sLog.Printf("Handle action %v from %v in %v", req.Action(), req.RemoteAddr(), time.Since(start))
+			}
+			return resp, err
+		}
+	}
+}
+
+// 会话上下文中存储登录账号的 key
+const CTX_ACCOUNT = "account"
+
+// 会话上下文中存储客户端证书 (`*x509.Certificate`) 的 key, 仅在客户端通过 TLS 连接并提供证书时具备
+const CTX_PEER = "peer"
+
+// 认证中间件, 要求会话已经登录 (会话上下文中存在 `CTX_ACCOUNT`), 否则返回 `ERROR_UNAUTHORIZED` 错误响应
+//
+// `public` 参数为无需登录即可访问的业务码, 例如登录业务码本身
+func RequireAuth(public ...ActionCode) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (any, error) {
+			if !slices.Contains(public, req.Action()) {
+				if _, ok := req.Get(CTX_ACCOUNT); !ok {
+					return nil, NewError(ERROR_UNAUTHORIZED, "login required for %v", req.Action())
+				}
+			}
+			return next(ctx, req)
+		}
+	}
+}
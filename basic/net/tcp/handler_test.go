@@ -0,0 +1,169 @@
+package tcp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试使用的业务码
+const (
+	ACTION_ECHO    ActionCode = 100 + iota // 回显业务码
+	ACTION_PANIC                           // 引发 panic 的业务码
+	ACTION_BAD_ACK                         // 响应无法编码的业务码
+)
+
+// 回显请求和响应结构体
+type EchoAsk struct {
+	Text string `json:"text"`
+}
+
+type EchoAck struct {
+	Text    string `json:"text"`
+	Account string `json:"account"`
+}
+
+// 启动测试服务端, 注册回显业务处理器, 并连接服务端
+func startEchoServer(t *testing.T, mws ...Middleware) (*Server, *Client) {
+	server, err := ServerStart("127.0.0.1:0")
+	assert.Nil(t, err)
+	t.Cleanup(server.Close)
+
+	server.Use(mws...)
+	server.Handle(ACTION_ECHO, Typed(func(ctx context.Context, ask *EchoAsk) (*EchoAck, error) {
+		if ask.Text == "" {
+			return nil, NewError(ERROR_BAD_REQUEST, "empty text")
+		}
+
+		account, _ := RequestFrom(ctx).Get(CTX_ACCOUNT)
+		ack := &EchoAck{Text: strings.ToUpper(ask.Text)}
+		if account != nil {
+			ack.Account = account.(string)
+		}
+		return ack, nil
+	}))
+	server.Handle(ACTION_PANIC, func(ctx context.Context, req *Request) (any, error) {
+		panic("boom")
+	})
+	server.Handle(ACTION_BAD_ACK, func(ctx context.Context, req *Request) (any, error) {
+		return make(chan int), nil
+	})
+
+	client, err := Connect(server.Addr().String())
+	assert.Nil(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return server, client
+}
+
+// 测试注册处理器, 并通过类型确定的请求调用
+func TestHandler_Typed(t *testing.T) {
+	_, client := startEchoServer(t)
+
+	ack, err := Call[EchoAsk, EchoAck](client, ACTION_ECHO, &EchoAsk{Text: "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, "HELLO", ack.Text)
+
+	// 处理器返回错误, 客户端接收到错误响应
+	_, err = Call[EchoAsk, EchoAck](client, ACTION_ECHO, &EchoAsk{})
+	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.Equal(t, "BAD_REQUEST: empty text", err.Error())
+
+	// 通过注册的响应类型发送请求
+	_, err = client.Request(ACTION_ECHO, &EchoAsk{Text: "hello"})
+	assert.ErrorIs(t, err, ErrUnknownAction)
+
+	RegisterResponse[EchoAck](client, ACTION_ECHO)
+	resp, err := client.Request(ACTION_ECHO, &EchoAsk{Text: "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, "HELLO", resp.(*EchoAck).Text)
+}
+
+// 测试未知的业务码, 客户端接收到错误响应, 且连接可以继续使用
+func TestHandler_UnknownAction(t *testing.T) {
+	_, client := startEchoServer(t)
+
+	_, err := Call[Empty, Empty](client, ActionCode(999), &Empty{})
+	assert.ErrorIs(t, err, ErrUnknownAction)
+
+	var e *Error
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, "unknown action code ACTION(999)", e.Message)
+
+	ack, err := Call[EchoAsk, EchoAck](client, ACTION_ECHO, &EchoAsk{Text: "ok"})
+	assert.Nil(t, err)
+	assert.Equal(t, "OK", ack.Text)
+}
+
+// 测试处理器中的 panic 被转为错误响应
+func TestHandler_Recovery(t *testing.T) {
+	_, client := startEchoServer(t)
+
+	_, err := Call[Empty, Empty](client, ACTION_PANIC, &Empty{})
+	assert.ErrorIs(t, err, ErrInternal)
+
+	_, err = Call[EchoAsk, EchoAck](client, ACTION_ECHO, &EchoAsk{Text: "ok"})
+	assert.Nil(t, err)
+}
+
+// 测试响应无法编码时, 客户端接收到错误响应, 且连接可以继续使用
+func TestHandler_EncodeAckFailed(t *testing.T) {
+	_, client := startEchoServer(t)
+
+	_, err := Call[Empty, Empty](client, ACTION_BAD_ACK, &Empty{})
+	assert.ErrorIs(t, err, ErrInternal)
+	assert.Contains(t, err.Error(), ErrEncodeFrame.Error())
+
+	ack, err := Call[EchoAsk, EchoAck](client, ACTION_ECHO, &EchoAsk{Text: "ok"})
+	assert.Nil(t, err)
+	assert.Equal(t, "OK", ack.Text)
+}
+
+// 测试认证中间件, 登录后会话中的请求均可访问
+func TestHandler_RequireAuth(t *testing.T) {
+	_, client := startEchoServer(t, RequireAuth(ACTION_LOGIN))
+
+	_, err := Call[EchoAsk, EchoAck](client, ACTION_ECHO, &EchoAsk{Text: "hello"})
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = client.Request(ACTION_LOGIN, &LoginAsk{Account: "Alvin", Password: "password"})
+	assert.Nil(t, err)
+
+	ack, err := Call[EchoAsk, EchoAck](client, ACTION_ECHO, &EchoAsk{Text: "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Alvin", ack.Account)
+}
+
+// 测试中间件的执行顺序
+func TestHandler_MiddlewareOrder(t *testing.T) {
+	var calls []string
+
+	mw := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) (any, error) {
+				calls = append(calls, name+">")
+				resp, err := next(ctx, req)
+				calls = append(calls, "<"+name)
+				return resp, err
+			}
+		}
+	}
+
+	h := chain(func(ctx context.Context, req *Request) (any, error) {
+		calls = append(calls, "handler")
+		return nil, nil
+	}, []Middleware{mw("A"), mw("B")})
+
+	h(context.Background(), &Request{})
+	assert.Equal(t, []string{"A>", "B>", "handler", "<B", "<A"}, calls)
+}
+
+// 测试业务码转字符串, 未知的业务码不会引发 panic
+func TestHandler_ActionString(t *testing.T) {
+	assert.Equal(t, "ACTION_LOGIN", ACTION_LOGIN.String())
+	assert.Equal(t, "ACTION_PING", ACTION_PING.String())
+	assert.Equal(t, "ACTION(100)", ACTION_ECHO.String())
+}
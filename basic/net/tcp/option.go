@@ -0,0 +1,214 @@
+package tcp
+
+import "time"
+
+// 服务端和客户端共用的可选参数
+type options struct {
+	codecs       []string      // 支持的编解码器名称, 客户端按优先级排列
+	readTimeout  time.Duration // 接收一帧数据的超时时间, 为 `0` 表示不限制
+	writeTimeout time.Duration // 发送一帧数据的超时时间, 为 `0` 表示不限制
+	idleTimeout  time.Duration // 连接的最长空闲时间, 为 `0` 表示不限制
+
+	handshakeTimeout time.Duration // 完成 TLS 握手以及编解码器握手的超时时间, 为 `0` 表示使用其它超时时间
+}
+
+// 获取握手的超时时间
+//
+// 未设置握手超时时间时, 使用连接的最长空闲时间, 二者均未设置时, 使用收发一帧数据的超时时间中较长的一个;
+// 以免对端建立连接后不完成握手, 一直占用服务端的 goroutine
+func (o *options) handshake() time.Duration {
+	switch {
+	case o.handshakeTimeout > 0:
+		return o.handshakeTimeout
+	case o.idleTimeout > 0:
+		return o.idleTimeout
+	}
+	return max(o.readTimeout, o.writeTimeout)
+}
+
+// 服务端的可选参数
+type serverOptions struct {
+	options
+	maxPending int                 // 每个连接同时处理的最大请求数量
+	maxConns   int                 // 同时保持的最大连接数量, 为 `0` 表示不限制
+	admin      func(*Request) bool // 校验会话是否可以执行管理业务 (例如关闭服务器) 的函数
+}
+
+// 客户端的可选参数
+type clientOptions struct {
+	options
+	heartbeat  time.Duration            // 发送心跳的间隔时间, 为 `0` 表示不发送心跳
+	backoffMin time.Duration            // 自动重连客户端重新连接的最短等待时间
+	backoffMax time.Duration            // 自动重连客户端重新连接的最长等待时间
+	onState    func(from, to ConnState) // 自动重连客户端连接状态变化的回调函数
+}
+
+// 服务端的可选参数, 用于 `ServerStart` 和 `ServerStartTLS` 函数
+type ServerOption interface {
+	applyServer(o *serverOptions)
+}
+
+// 客户端的可选参数, 用于 `Connect`, `ConnectTLS`, `Dial` 和 `DialTLS` 函数
+type ClientOption interface {
+	applyClient(o *clientOptions)
+}
+
+// 服务端和客户端共用的可选参数, 既是 `ServerOption` 也是 `ClientOption`
+//
+// 服务端和客户端的可选参数类型不同, 所以将只适用于一端的参数传给另一端时, 编译即会报错, 而不会被静默忽略
+type Option interface {
+	ServerOption
+	ClientOption
+}
+
+// 用于设置共用可选参数的回调类型
+type optionFunc func(*options)
+
+func (f optionFunc) applyServer(o *serverOptions) { f(&o.options) }
+func (f optionFunc) applyClient(o *clientOptions) { f(&o.options) }
+
+// 用于设置服务端可选参数的回调类型
+type serverOptionFunc func(*serverOptions)
+
+func (f serverOptionFunc) applyServer(o *serverOptions) { f(o) }
+
+// 用于设置客户端可选参数的回调类型
+type clientOptionFunc func(*clientOptions)
+
+func (f clientOptionFunc) applyClient(o *clientOptions) { f(o) }
+
+// 设置支持的编解码器
+//
+// 对于客户端, 按优先级从高到低排列, 默认为 gob, json, binary; 对于服务端, 默认支持所有已注册的编解码器
+func WithCodecs(names ...string) Option {
+	return optionFunc(func(o *options) {
+		o.codecs = names
+	})
+}
+
+// 设置服务端每个连接同时处理的最大请求数量, 默认为 `DEFAULT_MAX_PENDING`
+//
+// 达到该数量后, 服务端暂停接收该连接的请求, 直到有请求处理完毕
+func WithMaxPending(n int) ServerOption {
+	return serverOptionFunc(func(o *serverOptions) {
+		o.maxPending = max(n, 1)
+	})
+}
+
+// 设置服务端同时保持的最大连接数量
+//
+// 达到该数量后, 服务端接受的新连接会被立即关闭, 直到有连接断开
+func WithMaxConns(n int) ServerOption {
+	return serverOptionFunc(func(o *serverOptions) {
+		o.maxConns = n
+	})
+}
+
+// 设置接收一帧数据的超时时间
+//
+// 从接收到一帧的第一个字节开始计时, 用于发现发送数据不完整的对端
+func WithReadTimeout(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.readTimeout = d
+	})
+}
+
+// 设置发送一帧数据的超时时间, 用于发现不再接收数据的对端
+func WithWriteTimeout(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.writeTimeout = d
+	})
+}
+
+// 设置连接的最长空闲时间
+//
+// 超过该时间没有接收到任何数据, 连接被关闭; 对于服务端, 空闲的会话会被回收, 对于客户端,
+// 等待响应的请求均以错误结束, 所以客户端应同时通过 `WithHeartbeat` 设置更短的心跳间隔
+func WithIdleTimeout(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.idleTimeout = d
+	})
+}
+
+// 设置完成握手 (包括 TLS 握手以及协商编解码器的握手) 的超时时间
+//
+// 超过该时间未完成握手, 连接被关闭; 未设置时使用 `WithIdleTimeout` 设置的时间,
+// 二者均未设置时, 使用 `WithReadTimeout` 和 `WithWriteTimeout` 设置的时间中较长的一个
+func WithHandshakeTimeout(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.handshakeTimeout = d
+	})
+}
+
+// 设置客户端发送心跳的间隔时间
+//
+// 客户端每隔该时间向服务端发送一次 `ACTION_PING` 请求, 如果在下一次发送心跳前仍未接收到响应,
+// 则认为连接已经断开 (例如半开连接), 关闭客户端
+func WithHeartbeat(d time.Duration) ClientOption {
+	return clientOptionFunc(func(o *clientOptions) {
+		o.heartbeat = d
+	})
+}
+
+// 设置校验会话是否可以执行管理业务 (例如远程关闭服务器) 的函数
+//
+// 未设置时, 服务端拒绝所有管理业务请求; 校验函数可以通过会话上下文中的登录账号 (`CTX_ACCOUNT`)
+// 或客户端证书 (`Request.Peer`) 判断会话的身份
+func WithAdmin(fn func(*Request) bool) ServerOption {
+	return serverOptionFunc(func(o *serverOptions) {
+		o.admin = fn
+	})
+}
+
+// 设置自动重连客户端重新连接的等待时间
+//
+// 第 n 次重新连接失败后, 等待时间为 `min * 2^n` (不超过 `max`), 并在等待时间的后一半内随机取值,
+// 以免大量客户端同时重新连接服务端; 默认为 `DEFAULT_BACKOFF_MIN` 和 `DEFAULT_BACKOFF_MAX`
+//
+// 该参数只对通过 `Dial` 或 `DialTLS` 函数创建的自动重连客户端有效
+func WithBackoff(min, max time.Duration) ClientOption {
+	return clientOptionFunc(func(o *clientOptions) {
+		o.backoffMin, o.backoffMax = min, max
+	})
+}
+
+// 设置自动重连客户端连接状态变化的回调函数
+//
+// 回调函数在客户端内部的一个独立 goroutine 中按状态变化的顺序依次调用, 调用时不持有客户端的任何锁,
+// 可以在其中调用客户端的方法 (包括 `Close` 方法), 但回调函数可能在状态变化之后稍晚才被调用;
+// 回调函数不应长时间阻塞, 该参数只对自动重连客户端有效
+func WithStateChange(fn func(from, to ConnState)) ClientOption {
+	return clientOptionFunc(func(o *clientOptions) {
+		o.onState = fn
+	})
+}
+
+// 自动重连客户端默认的重新连接等待时间
+const (
+	DEFAULT_BACKOFF_MIN = 100 * time.Millisecond
+	DEFAULT_BACKOFF_MAX = 30 * time.Second
+)
+
+// 服务端每个连接默认同时处理的最大请求数量
+const DEFAULT_MAX_PENDING = 64
+
+// 创建服务端可选参数实例
+func newServerOptions(opts []ServerOption) *serverOptions {
+	o := &serverOptions{maxPending: DEFAULT_MAX_PENDING}
+	for _, opt := range opts {
+		opt.applyServer(o)
+	}
+	return o
+}
+
+// 创建客户端可选参数实例
+func newClientOptions(opts []ClientOption) *clientOptions {
+	o := &clientOptions{
+		backoffMin: DEFAULT_BACKOFF_MIN,
+		backoffMax: DEFAULT_BACKOFF_MAX,
+	}
+	for _, opt := range opts {
+		opt.applyClient(o)
+	}
+	return o
+}
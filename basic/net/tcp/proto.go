@@ -1,6 +1,6 @@
 package tcp
 
-import "errors"
+import "fmt"
 
 // 定义业务代码
 type ActionCode int
@@ -12,37 +12,46 @@ func (a ActionCode) String() string {
 		return "ACTION_LOGIN"
 	case ACTION_SHUTDOWN:
 		return "ACTION_SHUTDOWN"
+	case ACTION_PING:
+		return "ACTION_PING"
 	default:
-		panic(errors.New("invalid action code"))
+		return fmt.Sprintf("ACTION(%d)", int(a))
 	}
 }
 
 const (
 	ACTION_LOGIN    ActionCode = iota // 登录业务码
 	ACTION_SHUTDOWN                   // 关闭服务器业务码
+	ACTION_PING                       // 心跳业务码
 )
 
 // 请求头结构体
+//
+// 请求和响应的头及内容结构体均通过握手协商的编解码器编码, 其中 JSON 编码使用 `json` 标签指定的字段名,
+// 二进制编码使用字段的序号作为字段编号, 所以只能在结构体末尾增加字段
 type AskHeader struct {
-	Action ActionCode // 业务码
+	Action ActionCode `json:"action"` // 业务码
+	Id     uint64     `json:"id"`     // 请求 id, 响应头中携带相同的 id, 用于在同一个连接上同时发送多个请求
 }
 
 // 响应头结构体
 type AckHeader struct {
-	Action ActionCode // 业务码
-	IsOk   bool       // 是否成功
-	Error  string     // 错误信息
+	Action ActionCode `json:"action"`          // 业务码
+	IsOk   bool       `json:"ok"`              // 是否成功
+	Error  string     `json:"error,omitempty"` // 错误信息
+	Code   ErrorCode  `json:"code,omitempty"`  // 错误码
+	Id     uint64     `json:"id"`              // 对应请求的 id
 }
 
 // 登录请求结构体
 type LoginAsk struct {
-	Account  string
-	Password string
+	Account  string `json:"account"`
+	Password string `json:"password"`
 }
 
 // 登陆响应结构体
 type LoginAck struct {
-	Welcome string
+	Welcome string `json:"welcome"`
 }
 
 // 关闭请求结构体
@@ -52,3 +61,17 @@ type ShutdownAsk struct {
 // 关闭响应结构体
 type ShutdownAck struct {
 }
+
+// 心跳请求结构体
+type PingAsk struct {
+	Time int64 `json:"time"` // 客户端发送心跳的时间 (Unix 纳秒)
+}
+
+// 心跳响应结构体
+type PingAck struct {
+	Time int64 `json:"time"` // 原样返回请求中的时间
+}
+
+// 空内容结构体, 用于错误响应等没有内容的情况
+type Empty struct {
+}
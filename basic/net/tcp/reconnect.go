@@ -0,0 +1,404 @@
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+var (
+	// 自动重连客户端正在重新连接错误, 可以在重新连接后重试请求
+	ErrReconnecting = fmt.Errorf("%w: reconnecting", ErrConnectionLost)
+
+	// 重新连接后服务端拒绝重放的登录请求错误 (例如账号凭证已失效), 自动重连客户端随之关闭, 不再重新连接
+	ErrLoginRejected = errors.New("login rejected")
+)
+
+// 重新连接后重放登录请求的超时时间
+const DEFAULT_REPLAY_TIMEOUT = 10 * time.Second
+
+// 定义连接状态
+type ConnState int
+
+const (
+	STATE_CONNECTING   ConnState = iota // 正在连接
+	STATE_CONNECTED                     // 已连接
+	STATE_DISCONNECTED                  // 连接已断开, 等待重新连接
+	STATE_CLOSED                        // 客户端已关闭
+)
+
+// 连接状态转字符串
+func (s ConnState) String() string {
+	switch s {
+	case STATE_CONNECTING:
+		return "CONNECTING"
+	case STATE_CONNECTED:
+		return "CONNECTED"
+	case STATE_DISCONNECTED:
+		return "DISCONNECTED"
+	case STATE_CLOSED:
+		return "CLOSED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// 自动重连客户端
+//
+// 连接意外断开后 (例如服务端重启, 或心跳超时), 按指数退避的等待时间重新连接服务端, 重新连接后重放最近一次成功的登录请求,
+// 以恢复服务端的会话状态; 连接断开时等待响应的请求, 以及重新连接期间发送的请求, 均以可重试的错误结束 (参见 `IsRetryable` 函数)
+type ReconnectClient struct {
+	address string         // 服务端地址
+	config  *tls.Config    // TLS 配置, 为 `nil` 表示不使用 TLS
+	o       *clientOptions // 创建每个连接时使用的可选参数
+
+	mux       sync.RWMutex
+	client    *Client                   // 当前的连接, 连接断开后为 `nil`
+	state     ConnState                 // 当前的连接状态
+	err       error                     // 客户端自行关闭的原因, 为 `nil` 表示客户端未关闭或通过 `Close` 方法关闭
+	login     *LoginAsk                 // 最近一次成功的登录请求, 重新连接后重放
+	responses map[ActionCode]func() any // 注册的响应类型, 重新连接后重新注册
+	changes   []stateChange             // 等待通知回调函数的状态变化
+
+	notify  chan struct{} // 有新的状态变化时发送信号的通道
+	closeCh chan struct{} // 客户端关闭时关闭的通道
+	done    chan struct{} // 重新连接的 goroutine 结束时关闭的通道
+}
+
+// 连接状态的一次变化
+type stateChange struct {
+	from, to ConnState
+}
+
+// 连接服务端, 返回自动重连客户端
+//
+// 首次连接失败时直接返回错误; 可通过 `WithBackoff` 参数设置重新连接的等待时间, 通过 `WithStateChange` 参数监听连接状态变化,
+// 其余可选参数和 `Connect` 函数相同, 建议通过 `WithHeartbeat` 参数及时发现断开的连接
+func Dial(address string, opts ...ClientOption) (*ReconnectClient, error) {
+	return dial(address, nil, opts)
+}
+
+// 通过 TLS 连接服务端, 返回自动重连客户端, 参见 `Dial` 以及 `ConnectTLS` 函数
+func DialTLS(address string, config *tls.Config, opts ...ClientOption) (*ReconnectClient, error) {
+	if config == nil {
+		return nil, ErrNoTLSConfig
+	}
+	return dial(address, config, opts)
+}
+
+// 连接服务端, 返回自动重连客户端, `config` 参数为 `nil` 表示不使用 TLS
+func dial(address string, config *tls.Config, opts []ClientOption) (*ReconnectClient, error) {
+	rc := &ReconnectClient{
+		address:   address,
+		config:    config,
+		o:         newClientOptions(opts),
+		state:     STATE_CONNECTING,
+		responses: make(map[ActionCode]func() any),
+		notify:    make(chan struct{}, 1),
+		closeCh:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	c, err := rc.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	// 启动调用状态变化回调函数的 goroutine
+	if rc.o.onState != nil {
+		go rc.notifyLoop()
+	}
+
+	rc.client = c
+	rc.setState(STATE_CONNECTED)
+
+	// 启动监听连接断开并重新连接的 goroutine
+	go rc.run()
+
+	return rc, nil
+}
+
+// 建立一个连接, 注册响应类型, 并重放登录请求
+//
+// 重放登录请求的时间受 `DEFAULT_REPLAY_TIMEOUT` 限制, 客户端关闭时立即放弃重放, 以免 `Close` 方法长时间阻塞;
+// 服务端以 `ERROR_INTERNAL` 以外的错误响应拒绝登录请求时, 返回 `ErrLoginRejected` 错误, 重试也不会成功
+func (rc *ReconnectClient) connect() (*Client, error) {
+	c, err := connect(rc.address, rc.config, rc.o)
+	if err != nil {
+		return nil, err
+	}
+
+	rc.mux.RLock()
+	for code, factory := range rc.responses {
+		c.Register(code, factory)
+	}
+	login := rc.login
+	rc.mux.RUnlock()
+
+	if login != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_REPLAY_TIMEOUT)
+		defer cancel()
+
+		// 客户端关闭时结束上下文
+		go func() {
+			select {
+			case <-rc.closeCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		if _, err := c.RequestAsync(ACTION_LOGIN, login).Await(ctx); err != nil {
+			c.Close()
+			if e, ok := errors.AsType[*Error](err); ok && e.Code != ERROR_INTERNAL {
+				return nil, fmt.Errorf("%w: %w", ErrLoginRejected, err)
+			}
+			return nil, fmt.Errorf("replay login failed: %w", err)
+		}
+		cLog.Printf("Login replayed to %v, account=%v", rc.address, login.Account)
+	}
+	return c, nil
+}
+
+// 设置连接状态, 状态变化时通知调用回调函数的 goroutine
+//
+// 状态变化在持有锁时按发生顺序记录, 回调函数则在 `notifyLoop` 中不持有任何锁地调用,
+// 所以回调函数中可以调用客户端的任意方法 (包括 `Close` 方法)
+func (rc *ReconnectClient) setState(state ConnState) {
+	rc.mux.Lock()
+	from := rc.state
+	if from == STATE_CLOSED || from == state {
+		rc.mux.Unlock()
+		return
+	}
+	rc.state = state
+	if rc.o.onState != nil {
+		rc.changes = append(rc.changes, stateChange{from: from, to: state})
+	}
+	rc.mux.Unlock()
+
+	cLog.Printf("Connection to %v state changed, %v => %v", rc.address, from, state)
+	if rc.o.onState != nil {
+		select {
+		case rc.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// 按发生顺序依次调用状态变化回调函数, 直到通知了客户端关闭的状态变化
+func (rc *ReconnectClient) notifyLoop() {
+	for range rc.notify {
+		rc.mux.Lock()
+		changes := rc.changes
+		rc.changes = nil
+		rc.mux.Unlock()
+
+		for _, c := range changes {
+			rc.o.onState(c.from, c.to)
+			if c.to == STATE_CLOSED {
+				return
+			}
+		}
+	}
+}
+
+// 等待连接断开, 并重新连接服务端, 直到客户端关闭
+func (rc *ReconnectClient) run() {
+	defer close(rc.done)
+
+	for {
+		rc.mux.RLock()
+		c := rc.client
+		rc.mux.RUnlock()
+
+		select {
+		case <-c.done:
+		case <-rc.closeCh:
+			return
+		}
+
+		rc.mux.Lock()
+		rc.client = nil
+		rc.mux.Unlock()
+
+		c.pmux.Lock()
+		cLog.Printf("Connection to %v lost: %v", rc.address, c.err)
+		c.pmux.Unlock()
+
+		rc.setState(STATE_DISCONNECTED)
+		if !rc.reconnect() {
+			return
+		}
+	}
+}
+
+// 按指数退避的等待时间重新连接服务端, 直到连接成功 (返回 `true`) 或客户端关闭 (返回 `false`)
+//
+// 服务端拒绝重放的登录请求时不再重试, 客户端以该错误关闭, 并通过状态变化回调函数通知 `STATE_CLOSED` 状态
+func (rc *ReconnectClient) reconnect() bool {
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-time.After(rc.backoff(attempt)):
+		case <-rc.closeCh:
+			return false
+		}
+
+		rc.setState(STATE_CONNECTING)
+
+		c, err := rc.connect()
+		if errors.Is(err, ErrLoginRejected) {
+			cLog.Printf("Reconnect to %v failed: %v, stop reconnecting", rc.address, err)
+
+			rc.mux.Lock()
+			if rc.state != STATE_CLOSED {
+				rc.err = fmt.Errorf("%w: %w", ErrClientClosed, err)
+			}
+			rc.mux.Unlock()
+
+			rc.setState(STATE_CLOSED)
+			return false
+		}
+		if err != nil {
+			cLog.Printf("Reconnect to %v failed: %v, attempt=%v", rc.address, err, attempt+1)
+			rc.setState(STATE_DISCONNECTED)
+			continue
+		}
+
+		// 客户端在连接期间被关闭
+		rc.mux.Lock()
+		if rc.state == STATE_CLOSED {
+			rc.mux.Unlock()
+			c.Close()
+			return false
+		}
+		rc.client = c
+		rc.mux.Unlock()
+
+		rc.setState(STATE_CONNECTED)
+		return true
+	}
+}
+
+// 计算第 `attempt` 次重新连接前的等待时间
+func (rc *ReconnectClient) backoff(attempt int) time.Duration {
+	d := rc.o.backoffMax
+	if attempt < 32 {
+		d = min(rc.o.backoffMin<<attempt, rc.o.backoffMax)
+	}
+
+	// 在等待时间的后一半内随机取值
+	if half := d / 2; half > 0 {
+		d = half + rand.N(half)
+	}
+	return d
+}
+
+// 获取当前的连接状态
+func (rc *ReconnectClient) State() ConnState {
+	rc.mux.RLock()
+	defer rc.mux.RUnlock()
+
+	return rc.state
+}
+
+// 获取当前的连接, 用于调用 `Call` 等函数
+//
+// 正在重新连接时返回 `ErrReconnecting` 错误, 客户端关闭后返回 `ErrClientClosed` 错误;
+// 客户端因服务端拒绝重放的登录请求而关闭时, 返回的错误同时包装了 `ErrLoginRejected` 以及服务端的错误响应
+func (rc *ReconnectClient) Client() (*Client, error) {
+	rc.mux.RLock()
+	defer rc.mux.RUnlock()
+
+	switch {
+	case rc.state == STATE_CLOSED && rc.err != nil:
+		return nil, rc.err
+	case rc.state == STATE_CLOSED:
+		return nil, ErrClientClosed
+	case rc.client == nil:
+		return nil, ErrReconnecting
+	}
+	return rc.client, nil
+}
+
+// 注册业务码对应的响应类型, 参见 `Client.Register` 方法, 重新连接后会自动重新注册
+func (rc *ReconnectClient) Register(code ActionCode, factory func() any) {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+
+	rc.responses[code] = factory
+	if rc.client != nil {
+		rc.client.Register(code, factory)
+	}
+}
+
+// 发送请求数据, 等待并返回响应内容, 参见 `Client.Request` 方法
+func (rc *ReconnectClient) Request(action ActionCode, body any) (any, error) {
+	return rc.RequestAsync(action, body).Await(context.Background())
+}
+
+// 发送请求数据, 不等待响应, 返回表示响应的 `Future` 实例, 参见 `Client.RequestAsync` 方法
+func (rc *ReconnectClient) RequestAsync(action ActionCode, body any) *Future {
+	c, err := rc.Client()
+	if err != nil {
+		f := newFuture()
+		f.resolve(nil, err)
+		return f
+	}
+	return c.RequestAsync(action, body)
+}
+
+// 发送登录请求, 登录成功后记录该请求, 重新连接后自动重放
+func (rc *ReconnectClient) Login(ask *LoginAsk) (*LoginAck, error) {
+	resp, err := rc.Request(ACTION_LOGIN, ask)
+	if err != nil {
+		return nil, err
+	}
+
+	login := *ask
+
+	rc.mux.Lock()
+	rc.login = &login
+	rc.mux.Unlock()
+
+	return resp.(*LoginAck), nil
+}
+
+// 向服务端发送心跳请求, 返回请求的往返时间, 参见 `Client.Ping` 方法
+func (rc *ReconnectClient) Ping(ctx context.Context) (time.Duration, error) {
+	c, err := rc.Client()
+	if err != nil {
+		return 0, err
+	}
+	return c.Ping(ctx)
+}
+
+// 关闭客户端, 停止重新连接并关闭当前的连接
+func (rc *ReconnectClient) Close() error {
+	rc.setState(STATE_CLOSED)
+
+	rc.mux.Lock()
+	select {
+	case <-rc.closeCh:
+		rc.mux.Unlock()
+		return nil
+	default:
+		close(rc.closeCh)
+	}
+	c := rc.client
+	rc.client = nil
+	rc.mux.Unlock()
+
+	var err error
+	if c != nil {
+		err = c.Close()
+	}
+
+	// 等待重新连接的 goroutine 结束
+	<-rc.done
+	return err
+}
@@ -0,0 +1,306 @@
+package tcp
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 在指定地址启动测试服务端, 注册回显和等待业务处理器
+func startReconnectServer(t *testing.T, address string) *Server {
+	server, err := ServerStart(address)
+	assert.Nil(t, err)
+
+	server.Handle(ACTION_ECHO, Typed(func(ctx context.Context, ask *EchoAsk) (*EchoAck, error) {
+		account, _ := RequestFrom(ctx).Get(CTX_ACCOUNT)
+		ack := &EchoAck{Text: strings.ToUpper(ask.Text)}
+		if account != nil {
+			ack.Account = account.(string)
+		}
+		return ack, nil
+	}))
+	server.Handle(ACTION_SLEEP, Typed(func(ctx context.Context, ask *SleepAsk) (*SleepAck, error) {
+		time.Sleep(time.Duration(ask.Millis) * time.Millisecond)
+		return &SleepAck{Millis: ask.Millis}, nil
+	}))
+	return server
+}
+
+// 记录连接状态的变化
+type stateRecorder struct {
+	mux    sync.Mutex
+	states []ConnState
+}
+
+func (r *stateRecorder) record(from, to ConnState) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.states = append(r.states, to)
+}
+
+func (r *stateRecorder) get() []ConnState {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return append([]ConnState(nil), r.states...)
+}
+
+// 测试服务端重启后, 客户端重新连接并重放登录请求
+func TestReconnect_ServerRestart(t *testing.T) {
+	server := startReconnectServer(t, "127.0.0.1:0")
+	address := server.Addr().String()
+
+	var states stateRecorder
+	rc, err := Dial(address, WithBackoff(10*time.Millisecond, 50*time.Millisecond), WithStateChange(states.record))
+	assert.Nil(t, err)
+	defer rc.Close()
+
+	assert.Equal(t, STATE_CONNECTED, rc.State())
+	rc.Register(ACTION_ECHO, func() any { return &EchoAck{} })
+
+	ack, err := rc.Login(&LoginAsk{Account: "Alvin"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello Alvin", ack.Welcome)
+
+	// 停止服务端, 客户端连接断开, 请求以可重试的错误结束
+	server.Close()
+	assert.Eventually(t, func() bool { return rc.State() != STATE_CONNECTED }, time.Second, 5*time.Millisecond)
+
+	_, err = rc.Request(ACTION_ECHO, &EchoAsk{Text: "hello"})
+	assert.True(t, IsRetryable(err))
+	assert.ErrorIs(t, err, ErrReconnecting)
+
+	// 在相同地址重启服务端, 客户端重新连接
+	server = startReconnectServer(t, address)
+	defer server.Close()
+
+	assert.Eventually(t, func() bool { return rc.State() == STATE_CONNECTED }, 2*time.Second, 5*time.Millisecond)
+
+	// 重新连接后, 登录请求被重放, 会话状态恢复, 注册的响应类型仍然可用
+	resp, err := rc.Request(ACTION_ECHO, &EchoAsk{Text: "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, &EchoAck{Text: "HELLO", Account: "Alvin"}, resp)
+
+	// 连接状态依次为: 已连接, 断开, (正在连接, 断开)..., 正在连接, 已连接
+	s := states.get()
+	if assert.GreaterOrEqual(t, len(s), 4) {
+		assert.Equal(t, []ConnState{STATE_CONNECTED, STATE_DISCONNECTED}, s[:2])
+		assert.Equal(t, []ConnState{STATE_CONNECTING, STATE_CONNECTED}, s[len(s)-2:])
+	}
+
+	// 关闭客户端, 请求以不可重试的错误结束
+	rc.Close()
+	assert.Equal(t, STATE_CLOSED, rc.State())
+
+	_, err = rc.Request(ACTION_ECHO, &EchoAsk{Text: "hello"})
+	assert.ErrorIs(t, err, ErrClientClosed)
+	assert.False(t, IsRetryable(err))
+}
+
+// 测试连接断开时, 等待响应的请求以可重试的错误结束
+func TestReconnect_PendingRetryable(t *testing.T) {
+	server := startReconnectServer(t, "127.0.0.1:0")
+
+	rc, err := Dial(server.Addr().String(), WithBackoff(10*time.Millisecond, 50*time.Millisecond))
+	assert.Nil(t, err)
+	defer rc.Close()
+
+	rc.Register(ACTION_SLEEP, func() any { return &SleepAck{} })
+
+	f := rc.RequestAsync(ACTION_SLEEP, &SleepAsk{Millis: 500})
+	time.Sleep(20 * time.Millisecond)
+
+	server.Close()
+
+	_, err = f.Await(context.Background())
+	assert.ErrorIs(t, err, ErrConnectionLost)
+	assert.True(t, IsRetryable(err))
+}
+
+// 测试重新连接后重放登录请求期间关闭客户端, 关闭操作不会被阻塞
+func TestReconnect_CloseDuringReplay(t *testing.T) {
+	server := startReconnectServer(t, "127.0.0.1:0")
+	address := server.Addr().String()
+
+	rc, err := Dial(address, WithBackoff(10*time.Millisecond, 50*time.Millisecond))
+	assert.Nil(t, err)
+
+	_, err = rc.Login(&LoginAsk{Account: "Alvin"})
+	assert.Nil(t, err)
+
+	// 在相同地址重启服务端, 新的服务端不响应登录请求
+	server.Close()
+
+	entered := make(chan struct{}, 1)
+	block := make(chan struct{})
+	defer close(block)
+
+	server = startReconnectServer(t, address)
+	defer server.Close()
+
+	server.Handle(ACTION_LOGIN, Typed(func(ctx context.Context, ask *LoginAsk) (*LoginAck, error) {
+		entered <- struct{}{}
+		<-block
+		return &LoginAck{}, nil
+	}))
+
+	// 等待客户端重新连接并开始重放登录请求, 之后关闭客户端
+	select {
+	case <-entered:
+	case <-time.After(2 * time.Second):
+		assert.Fail(t, "login not replayed")
+	}
+
+	start := time.Now()
+	rc.Close()
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, STATE_CLOSED, rc.State())
+}
+
+// 测试在状态变化回调函数中关闭客户端, 关闭操作不会死锁
+func TestReconnect_CloseInStateChange(t *testing.T) {
+	server := startReconnectServer(t, "127.0.0.1:0")
+
+	var states stateRecorder
+	var rc *ReconnectClient
+	closed := make(chan struct{})
+
+	rc, err := Dial(server.Addr().String(), WithBackoff(10*time.Millisecond, 50*time.Millisecond),
+		WithStateChange(func(from, to ConnState) {
+			states.record(from, to)
+			if to == STATE_DISCONNECTED {
+				rc.Close()
+				close(closed)
+			}
+		}))
+	assert.Nil(t, err)
+
+	// 停止服务端, 客户端在连接断开的回调函数中关闭
+	server.Close()
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		assert.Fail(t, "close in state change deadlocked")
+	}
+
+	assert.Equal(t, STATE_CLOSED, rc.State())
+	assert.Eventually(t, func() bool { return len(states.get()) == 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []ConnState{STATE_CONNECTED, STATE_DISCONNECTED, STATE_CLOSED}, states.get())
+}
+
+// 测试重新连接后服务端拒绝重放的登录请求, 客户端停止重新连接并关闭
+func TestReconnect_LoginRejected(t *testing.T) {
+	server := startReconnectServer(t, "127.0.0.1:0")
+	address := server.Addr().String()
+
+	var states stateRecorder
+	rc, err := Dial(address, WithBackoff(10*time.Millisecond, 50*time.Millisecond), WithStateChange(states.record))
+	assert.Nil(t, err)
+	defer rc.Close()
+
+	_, err = rc.Login(&LoginAsk{Account: "Alvin"})
+	assert.Nil(t, err)
+
+	// 在相同地址重启服务端, 新的服务端拒绝该账号登录
+	server.Close()
+
+	var attempts atomic.Int32
+	server = startReconnectServer(t, address)
+	defer server.Close()
+
+	server.Handle(ACTION_LOGIN, Typed(func(ctx context.Context, ask *LoginAsk) (*LoginAck, error) {
+		attempts.Add(1)
+		return nil, NewError(ERROR_UNAUTHORIZED, "account %v disabled", ask.Account)
+	}))
+
+	// 客户端关闭, 并通过状态变化回调函数通知
+	assert.Eventually(t, func() bool { return rc.State() == STATE_CLOSED }, 2*time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		s := states.get()
+		return s[len(s)-1] == STATE_CLOSED
+	}, time.Second, 5*time.Millisecond)
+
+	// 请求返回不可重试的错误, 错误中包含服务端的错误响应
+	_, err = rc.Request(ACTION_ECHO, &EchoAsk{Text: "hello"})
+	assert.ErrorIs(t, err, ErrClientClosed)
+	assert.ErrorIs(t, err, ErrLoginRejected)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.False(t, IsRetryable(err))
+
+	// 不再重新连接
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), attempts.Load())
+	assert.Nil(t, rc.Close())
+}
+
+// 测试重放登录请求时服务端内部错误, 客户端继续重新连接
+func TestReconnect_LoginInternalError(t *testing.T) {
+	server := startReconnectServer(t, "127.0.0.1:0")
+	address := server.Addr().String()
+
+	rc, err := Dial(address, WithBackoff(10*time.Millisecond, 50*time.Millisecond))
+	assert.Nil(t, err)
+	defer rc.Close()
+
+	rc.Register(ACTION_ECHO, func() any { return &EchoAck{} })
+
+	_, err = rc.Login(&LoginAsk{Account: "Alvin"})
+	assert.Nil(t, err)
+
+	// 在相同地址重启服务端, 新的服务端前两次登录请求返回内部错误
+	server.Close()
+
+	var attempts atomic.Int32
+	server = startReconnectServer(t, address)
+	defer server.Close()
+
+	server.Handle(ACTION_LOGIN, Typed(func(ctx context.Context, ask *LoginAsk) (*LoginAck, error) {
+		if attempts.Add(1) <= 2 {
+			return nil, ErrInternal
+		}
+		return handleLogin(ctx, ask)
+	}))
+
+	// 第三次重放登录请求成功后, 客户端恢复连接
+	assert.Eventually(t, func() bool {
+		return attempts.Load() == 3 && rc.State() == STATE_CONNECTED
+	}, 2*time.Second, 5*time.Millisecond)
+
+	resp, err := rc.Request(ACTION_ECHO, &EchoAsk{Text: "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, &EchoAck{Text: "HELLO", Account: "Alvin"}, resp)
+}
+
+// 测试首次连接失败时直接返回错误
+func TestReconnect_DialFailed(t *testing.T) {
+	server := startReconnectServer(t, "127.0.0.1:0")
+	address := server.Addr().String()
+	server.Close()
+
+	_, err := Dial(address)
+	assert.NotNil(t, err)
+}
+
+// 测试重新连接的等待时间
+func TestReconnect_Backoff(t *testing.T) {
+	rc := &ReconnectClient{o: newClientOptions([]ClientOption{WithBackoff(100*time.Millisecond, time.Second)})}
+
+	for attempt, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		want *= time.Millisecond
+
+		d := rc.backoff(attempt)
+		assert.GreaterOrEqual(t, d, want/2)
+		assert.Less(t, d, want)
+	}
+
+	// 重试次数极大时不会溢出
+	assert.LessOrEqual(t, rc.backoff(100), time.Second)
+	assert.Equal(t, "CONNECTED", STATE_CONNECTED.String())
+}
@@ -1,11 +1,15 @@
 package tcp
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"sync/atomic"
-	"unsafe"
+	"time"
 )
 
 // 定义协议名称
@@ -14,16 +18,55 @@ const TCP string = "tcp"
 var (
 	// 服务器关闭错误
 	ErrServerShouldClose = fmt.Errorf("server should close")
+
+	// 未提供 TLS 配置错误
+	ErrNoTLSConfig = errors.New("tls config required")
 )
 
+// 远程关闭服务器时, 等待会话结束的最长时间
+const DEFAULT_SHUTDOWN_TIMEOUT = 10 * time.Second
+
 // 服务端结构体
 type Server struct {
-	closeCh  chan struct{}    // 服务器关闭事件的 channel
-	listener *net.TCPListener // 服务端侦听实例
+	closeCh  chan struct{}                   // 服务器关闭事件的 channel
+	listener atomic.Pointer[net.TCPListener] // 服务端侦听实例, 停止接受新连接后为 `nil`
+	addr     net.Addr                        // 服务端侦听地址
+	opts     *serverOptions                  // 服务端可选参数
+	tls      *tls.Config                     // TLS 配置, 为 `nil` 表示不使用 TLS
+
+	smux     sync.Mutex            // 保护会话集合的互斥锁
+	sessions map[*session]struct{} // 当前的所有会话
+	closing  bool                  // 服务端是否正在关闭, 关闭后不再开始新的会话
+	swg      sync.WaitGroup        // 等待所有会话 goroutine 结束
+
+	mux         sync.RWMutex           // 保护处理器和中间件的读写锁
+	handlers    map[ActionCode]Handler // 业务码对应的处理器
+	middlewares []Middleware           // 所有处理器共用的中间件
 }
 
 // 启动服务端
-func ServerStart(address string) (*Server, error) {
+//
+// 可通过 `WithCodecs` 参数限制服务端支持的编解码器, 默认支持所有已注册的编解码器, 可通过 `WithReadTimeout`,
+// `WithWriteTimeout`, `WithIdleTimeout`, `WithHandshakeTimeout` 以及 `WithMaxConns` 参数设置连接的超时时间和最大连接数量;
+// 服务端默认注册了登录, 关闭服务器和心跳业务的处理器, 以及 `Recovery` 和 `Logging` 中间件
+func ServerStart(address string, opts ...ServerOption) (*Server, error) {
+	return serverStart(address, nil, opts)
+}
+
+// 启动使用 TLS 的服务端
+//
+// `config` 参数需包含服务端证书 (`Certificates` 字段); 如需校验客户端证书 (双向 TLS), 需设置 `ClientAuth`
+// 字段 (例如 `tls.RequireAndVerifyClientCert`) 以及用于校验客户端证书的 `ClientCAs` 字段,
+// 客户端提供的证书会被存入会话上下文 (参见 `CTX_PEER` 以及 `Request.Peer` 方法)
+func ServerStartTLS(address string, config *tls.Config, opts ...ServerOption) (*Server, error) {
+	if config == nil {
+		return nil, ErrNoTLSConfig
+	}
+	return serverStart(address, config, opts)
+}
+
+// 启动服务端, `config` 参数为 `nil` 表示不使用 TLS
+func serverStart(address string, config *tls.Config, opts []ServerOption) (*Server, error) {
 	// 解析服务端监听地址, 形如: "0.0.0.0:8888"
 	addr, err := net.ResolveTCPAddr(TCP, address)
 	if err != nil {
@@ -36,30 +79,36 @@ func ServerStart(address string) (*Server, error) {
 		sLog.Fatalf("Network error: %v", err)
 		return nil, err
 	}
-	sLog.Printf("Start listening at %v", addr)
+	sLog.Printf("Start listening at %v", listener.Addr())
 
 	// 产生服务端对象
 	server := &Server{
 		closeCh:  make(chan struct{}),
-		listener: listener,
+		addr:     listener.Addr(),
+		opts:     newServerOptions(opts),
+		tls:      config,
+		sessions: make(map[*session]struct{}),
+		handlers: make(map[ActionCode]Handler),
 	}
+	server.listener.Store(listener)
+
+	// 注册默认的中间件和处理器
+	server.Use(Recovery(), Logging())
+	server.Handle(ACTION_LOGIN, Typed(handleLogin))
+	server.Handle(ACTION_SHUTDOWN, Typed(server.handleShutdown))
+	server.Handle(ACTION_PING, Typed(handlePing))
 
 	// 调用客户端连接处理函数
-	go server.handleAcceptation()
+	go server.handleAcceptation(listener)
 
 	return server, nil
 }
 
 // 接受客户端连接, 启动客户端处理协程
-func (s *Server) handleAcceptation() {
-	l := s.listener
-	if l == nil {
-		return
-	}
-
+func (s *Server) handleAcceptation(l *net.TCPListener) {
 	defer func() {
 		close(s.closeCh)
-		s.Close()
+		s.stopAccepting()
 	}()
 
 	for {
@@ -70,169 +119,432 @@ func (s *Server) handleAcceptation() {
 		}
 		sLog.Printf("New connection coming, %v", conn.RemoteAddr())
 
-		// 处理一次会话
-		go s.handleClientSession(conn)
+		// 连接数量达到上限, 拒绝新连接
+		if s.opts.maxConns > 0 && s.Conns() >= s.opts.maxConns {
+			sLog.Printf("Too many connections, reject %v", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+
+		// 记录会话, 并启动会话 goroutine
+		ctx, cancel := context.WithCancel(context.Background())
+		sess := &session{conn: conn, cancel: cancel}
+
+		s.smux.Lock()
+		s.sessions[sess] = struct{}{}
+		s.smux.Unlock()
+
+		s.swg.Go(func() {
+			defer func() {
+				s.smux.Lock()
+				delete(s.sessions, sess)
+				s.smux.Unlock()
+			}()
+			s.handleClientSession(ctx, sess)
+		})
 	}
 }
 
-// 停止服务端
-func (s *Server) Close() {
-	l := (*net.TCPListener)(atomic.SwapPointer((*unsafe.Pointer)(unsafe.Pointer(&s.listener)), nil))
+// 服务端会话
+type session struct {
+	conn   *net.TCPConn       // 原始的 TCP 连接
+	tc     *TCPConn           // 握手完成后的连接, 握手完成前为 `nil`
+	cancel context.CancelFunc // 取消会话上下文的函数
+}
+
+// 停止接收会话的新请求, 握手尚未完成的会话直接关闭
+func (ss *session) drain() {
+	if ss.tc != nil {
+		ss.tc.interrupt()
+	} else {
+		ss.conn.Close()
+	}
+}
+
+// 强制关闭会话, 取消会话上下文并关闭连接
+func (ss *session) close() {
+	ss.cancel()
+	ss.conn.Close()
+}
+
+// 握手完成后标记会话就绪, 服务端正在关闭时返回 `false`
+func (s *Server) ready(sess *session, tc *TCPConn) bool {
+	s.smux.Lock()
+	defer s.smux.Unlock()
+
+	if s.closing {
+		return false
+	}
+	sess.tc = tc
+	return true
+}
+
+// 注册业务码的处理器, 同一个业务码的处理器会被替换
+func (s *Server) Handle(code ActionCode, h Handler) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.handlers[code] = h
+}
+
+// 添加所有处理器共用的中间件, 先添加的中间件位于外层
+func (s *Server) Use(mws ...Middleware) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.middlewares = append(s.middlewares, mws...)
+}
+
+// 获取业务码对应的处理器 (已包装中间件), 没有对应的处理器时, 返回 `ERROR_UNKNOWN_ACTION` 错误
+//
+// 业务码未知时同样经过中间件, 以便记录日志等
+func (s *Server) handler(code ActionCode) Handler {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	h, ok := s.handlers[code]
+	if !ok {
+		h = func(ctx context.Context, req *Request) (any, error) {
+			return nil, NewError(ERROR_UNKNOWN_ACTION, "unknown action code %v", req.Action())
+		}
+	}
+	return chain(h, s.middlewares)
+}
+
+// 获取服务端当前保持的连接数量
+func (s *Server) Conns() int {
+	s.smux.Lock()
+	defer s.smux.Unlock()
+
+	return len(s.sessions)
+}
+
+// 获取服务端侦听地址, 当启动时指定的端口为 `0` 时, 可以通过该方法获取实际侦听的端口
+func (s *Server) Addr() net.Addr { return s.addr }
+
+// 停止接受新连接, 并等待 accept goroutine 结束, 返回是否由本次调用关闭侦听
+func (s *Server) stopAccepting() bool {
+	l := s.listener.Swap(nil)
 	if l != nil {
 		l.Close()
+	}
 
-		// 等待 accept goroutine 结束
-		<-s.closeCh
+	// 等待 accept goroutine 结束, 之后不会再有新的会话
+	<-s.closeCh
+	return l != nil
+}
+
+// 对所有会话执行 `fn` 函数, 并标记服务端正在关闭
+func (s *Server) eachSession(fn func(*session)) {
+	s.smux.Lock()
+	defer s.smux.Unlock()
 
+	s.closing = true
+	for sess := range s.sessions {
+		fn(sess)
+	}
+}
+
+// 立即停止服务端
+//
+// 停止接受新连接, 并强制关闭所有会话 (取消会话上下文并关闭连接), 不等待正在处理的请求
+func (s *Server) Close() {
+	if s.stopAccepting() {
 		sLog.Printf("Server stop successful")
 	}
+	s.eachSession((*session).close)
+}
+
+// 优雅的停止服务端
+//
+// 停止接受新连接, 通知所有会话停止接收新的请求, 并等待会话处理完已接收的请求并发送响应后结束;
+// 如果 `ctx` 参数在所有会话结束前结束, 则强制关闭剩余的会话, 并返回 `ctx` 的错误
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopAccepting()
+	s.eachSession((*session).drain)
+
+	done := make(chan struct{})
+	go func() {
+		s.swg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		sLog.Printf("Server shutdown gracefully")
+		return nil
+	case <-ctx.Done():
+		s.eachSession((*session).close)
+		sLog.Printf("Server shutdown timeout, sessions closed: %v", ctx.Err())
+		return ctx.Err()
+	}
+}
+
+// 会话上下文类型
+//
+// 同一个连接 (会话) 的所有请求共享同一个上下文对象, 由于同一个会话的请求可能被同时处理, 所以通过读写锁保护
+type Context struct {
+	mux    sync.RWMutex
+	values map[string]any
+}
+
+// 创建会话上下文实例
+func newContext() *Context {
+	return &Context{values: make(map[string]any)}
 }
 
-// 上下文对象类型
-type Context map[string]interface{}
+// 从会话上下文中获取值
+func (c *Context) Get(key string) (any, bool) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	v, ok := c.values[key]
+	return v, ok
+}
+
+// 在会话上下文中设置值
+func (c *Context) Set(key string, value any) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.values[key] = value
+}
 
 // 服务端请求结构体
+//
+// 每个请求对应一个实例, 同一个连接 (会话) 的所有请求共享同一个上下文对象
 type Request struct {
 	header  AskHeader // 请求头
-	context Context   // 上下文对象
+	frame   *Frame    // 当前请求的数据帧
+	context *Context  // 会话上下文对象
 	conn    *TCPConn  // 客户端连接
 }
 
+// 获取请求的业务码
+func (r *Request) Action() ActionCode { return r.header.Action }
+
+// 获取客户端地址
+func (r *Request) RemoteAddr() net.Addr { return r.conn.RemoteAddr() }
+
+// 将请求内容解码到 `body` 参数指向的实例中
+func (r *Request) Bind(body any) error {
+	if err := r.frame.Body(body); err != nil {
+		sLog.Printf("Decode ask body failed: %v", err)
+		return err
+	}
+
+	sLog.Printf("Ask body received from %v, action=%v", r.RemoteAddr(), r.Action())
+	return nil
+}
+
+// 获取客户端证书, 客户端未通过 TLS 连接或未提供证书时返回 `nil`
+func (r *Request) Peer() *x509.Certificate {
+	if v, ok := r.Get(CTX_PEER); ok {
+		return v.(*x509.Certificate)
+	}
+	return nil
+}
+
+// 从会话上下文中获取值
+func (r *Request) Get(key string) (any, bool) {
+	return r.context.Get(key)
+}
+
+// 在会话上下文中设置值, 会话中之后的请求均可获取该值
+func (r *Request) Set(key string, value any) {
+	r.context.Set(key, value)
+}
+
 // 处理一次会话
-func (s *Server) handleClientSession(conn *net.TCPConn) {
+//
+// `ctx` 参数为会话的上下文, 强制关闭会话时被取消
+func (s *Server) handleClientSession(ctx context.Context, sess *session) {
+	conn := sess.conn
 	defer conn.Close()
 
 	// 设置连接保持
 	conn.SetKeepAlive(true)
 
-	// 创建请求实例
-	req := Request{
-		context: make(Context),
-		conn:    NewTCPConn(conn),
-	}
-	defer func() {
-		req.conn.Close()
-		req.context = nil
-	}()
-
-	for {
-		// 设置读写超时
-		// conn.SetReadDeadline(time.Now().Add(time.Second * 10))
-		// conn.SetWriteDeadline(time.Now().Add(time.Second * 30))
+	session := newContext()
 
-		// 解码请求头
-		header, err := req.decodeAskHeader()
+	// 使用 TLS 时, 先完成 TLS 握手, 并记录客户端证书
+	var nc net.Conn = conn
+	if s.tls != nil {
+		tlsConn, err := s.tlsHandshake(conn)
 		if err != nil {
-			break
+			sLog.Printf("TLS handshake with %v failed: %v", conn.RemoteAddr(), err)
+			return
 		}
-
-		// 根据请求头处理会话
-		switch header.Action {
-		case ACTION_LOGIN:
-			err = req.handleLoginAction()
-		case ACTION_SHUTDOWN:
-			err = req.handleShutdownAction()
+		if certs := tlsConn.ConnectionState().PeerCertificates; len(certs) > 0 {
+			session.Set(CTX_PEER, certs[0])
+			sLog.Printf("TLS peer %v, subject=%v", conn.RemoteAddr(), certs[0].Subject)
 		}
+		nc = tlsConn
+	}
 
-		if err != nil {
-			if errors.Is(err, ErrServerShouldClose) {
-				s.Close()
-			} else {
-				sLog.Printf("handle session error, %v", err)
+	tc := NewTCPConn(nc)
+	tc.setTimeouts(&s.opts.options)
+	defer tc.Close()
+
+	// 握手, 协商编解码器
+	if err := tc.serverHandshake(s.opts.codecs); err != nil {
+		sLog.Printf("Handshake with %v failed: %v", conn.RemoteAddr(), err)
+		return
+	}
+	sLog.Printf("Handshake with %v, codec=%v", conn.RemoteAddr(), tc.Codec().Name())
+
+	// 会话的上下文, 会话结束时取消
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// 服务端正在关闭, 不再开始新的会话
+	if !s.ready(sess, tc) {
+		return
+	}
+
+	// 同一个会话的请求被同时处理, 通过信号量限制同时处理的请求数量, 并在会话结束前等待所有请求处理完毕
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	sem := make(chan struct{}, s.opts.maxPending)
+
+	for {
+		// 创建请求实例, 接收请求帧并解码请求头
+		req := &Request{context: session, conn: tc}
+		if err := req.decodeAskHeader(); err != nil {
+			if tc.IsInterrupted() {
+				// 服务端正在关闭, 等待已接收的请求处理完毕后结束会话
+				sLog.Printf("Session %v draining", conn.RemoteAddr())
+			} else if ne, ok := errors.AsType[net.Error](err); ok && ne.Timeout() {
+				// 连接空闲或接收数据超时, 回收会话
+				sLog.Printf("Session %v reaped: %v", conn.RemoteAddr(), err)
 			}
 			break
 		}
+
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			s.serve(ctx, req)
+		})
 	}
 }
 
-// 处理登录会话
-func (r *Request) handleLoginAction() error {
-	// 解码请求内容
-	ask := LoginAsk{}
-	if err := r.decodeAskBody(&ask); err != nil {
-		return err
-	}
-	sLog.Printf("Do login action, account=%v, password=%v", ask.Account, ask.Password)
+// 作为服务端进行 TLS 握手, 握手时间受握手超时时间限制
+func (s *Server) tlsHandshake(conn *net.TCPConn) (*tls.Conn, error) {
+	tlsConn := tls.Server(conn, s.tls)
 
-	// 编码响应头
-	if err := r.encodeAckHeader(&AckHeader{Action: ACTION_LOGIN, IsOk: true}); err != nil {
-		return err
-	}
+	conn.SetDeadline(deadline(s.opts.handshake()))
+	defer conn.SetDeadline(time.Time{})
 
-	// 编码响应内容
-	content := fmt.Sprintf("Hello %v", ask.Account)
-	if err := r.encodeAckBody(&LoginAck{Welcome: content}); err != nil {
-		return err
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, err
 	}
-
-	return nil
+	return tlsConn, nil
 }
 
-// 处理关闭服务会话
-func (r *Request) handleShutdownAction() error {
-	// 解码请求内容
-	ask := ShutdownAsk{}
-	if err := r.decodeAskBody(&ask); err != nil {
-		return err
+// 处理一个请求, 调用业务码对应的处理器, 并发送响应
+func (s *Server) serve(ctx context.Context, req *Request) {
+	resp, err := s.handler(req.Action())(context.WithValue(ctx, requestKey{}, req), req)
+	if err := req.encodeAck(resp, err); err != nil {
+		// 响应写入连接失败, 连接中可能已写入了不完整的帧, 关闭连接以结束会话
+		req.conn.Close()
+		return
 	}
-	sLog.Print("Do shutdown action")
 
-	// 编码响应头
-	if err := r.encodeAckHeader(&AckHeader{Action: ACTION_SHUTDOWN, IsOk: true}); err != nil {
-		return err
-	}
+	// 响应发送后, 优雅的关闭服务器, 不能在会话中等待会话结束, 所以启动新的 goroutine
+	if errors.Is(err, ErrServerShouldClose) {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_SHUTDOWN_TIMEOUT)
+			defer cancel()
 
-	// 编码响应内容
-	if err := r.encodeAckBody(&ShutdownAsk{}); err != nil {
-		return err
+			s.Shutdown(ctx)
+		}()
 	}
+}
 
-	return ErrServerShouldClose
+// 处理登录业务
+func handleLogin(ctx context.Context, ask *LoginAsk) (*LoginAck, error) {
+	sLog.Printf("Do login action, account=%v", ask.Account)
+
+	// 在会话上下文中记录登录的账号
+	RequestFrom(ctx).Set(CTX_ACCOUNT, ask.Account)
+
+	return &LoginAck{Welcome: fmt.Sprintf("Hello %v", ask.Account)}, nil
 }
 
-// 解码请求头
-func (r *Request) decodeAskHeader() (AskHeader, error) {
-	// 接收请求头数据
-	if err := r.conn.Decode(&r.header); err != nil {
-		sLog.Printf("Decode ask header failed: %v", err)
-		return r.header, err
+// 处理关闭服务器业务, 发送响应后优雅的关闭服务器
+//
+// 只有通过 `WithAdmin` 参数设置的校验函数认可的会话可以关闭服务器, 未设置时拒绝所有关闭请求
+func (s *Server) handleShutdown(ctx context.Context, ask *ShutdownAsk) (*ShutdownAck, error) {
+	req := RequestFrom(ctx)
+	if s.opts.admin == nil || !s.opts.admin(req) {
+		sLog.Printf("Shutdown refused, %v is not admin", req.RemoteAddr())
+		return nil, NewError(ERROR_UNAUTHORIZED, "admin required for %v", req.Action())
 	}
 
-	sLog.Printf("Ask header received from %v, action=%v", r.conn.RemoteAddr(), r.header.Action)
-	return r.header, nil
+	sLog.Print("Do shutdown action")
+	return &ShutdownAck{}, ErrServerShouldClose
+}
+
+// 处理心跳业务, 原样返回请求中的时间
+func handlePing(ctx context.Context, ask *PingAsk) (*PingAck, error) {
+	return &PingAck{Time: ask.Time}, nil
 }
 
-// 解码请求内容
-func (r *Request) decodeAskBody(body interface{}) error {
-	// 接收请求内容数据
-	if err := r.conn.Decode(body); err != nil {
-		sLog.Printf("Decode ask body failed: %v", err)
+// 接收请求帧并解码请求头
+func (r *Request) decodeAskHeader() error {
+	// 接收请求帧
+	frame, err := r.conn.ReadFrame()
+	if err != nil {
+		sLog.Printf("Receive ask frame failed: %v", err)
 		return err
 	}
+	r.frame = frame
 
-	sLog.Printf("Ask body received from %v, action=%v", r.conn.RemoteAddr(), r.header.Action)
-	return nil
-}
-
-// 编码响应头
-func (r *Request) encodeAckHeader(header *AckHeader) error {
-	// 发送响应头
-	if err := r.conn.Encode(header); err != nil {
-		sLog.Printf("Encode ack header failed: %v", err)
+	// 解码请求头
+	if err := frame.Header(&r.header); err != nil {
+		sLog.Printf("Decode ask header failed: %v", err)
 		return err
 	}
 
-	sLog.Printf("Ack header sent to %v, action=%v", r.conn.RemoteAddr(), r.header.Action)
+	sLog.Printf("Ask header received from %v, action=%v", r.RemoteAddr(), r.Action())
 	return nil
 }
 
-// 编码相应内容
-func (r *Request) encodeAckBody(body interface{}) error {
-	// 发送响应内容
-	if err := r.conn.Encode(body); err != nil {
-		sLog.Printf("Encode ack body failed: %v", err)
+// 根据处理器的结果发送响应
+//
+// 处理器返回错误时发送错误响应 (`ErrServerShouldClose` 除外, 该错误表示响应发送后关闭服务器);
+// 响应编码失败时 (例如响应内容超过帧的最大长度), 改为发送 `ERROR_INTERNAL` 错误响应, 只有写入连接失败时才返回错误
+func (r *Request) encodeAck(resp any, err error) error {
+	header := &AckHeader{Action: r.Action(), IsOk: true, Id: r.header.Id}
+
+	if err != nil && !errors.Is(err, ErrServerShouldClose) {
+		e, ok := errors.AsType[*Error](err)
+		if !ok {
+			e = &Error{Code: ERROR_INTERNAL, Message: err.Error()}
+		}
+		header.IsOk, header.Code, header.Error = false, e.Code, e.Message
+		resp = nil
+	}
+
+	if resp == nil {
+		resp = &Empty{}
+	}
+
+	err = r.conn.WriteFrame(header, resp)
+	if errors.Is(err, ErrEncodeFrame) {
+		sLog.Printf("Encode ack failed: %v", err)
+
+		// 编码失败时没有数据被发送, 错误信息不包含响应内容, 保证错误响应可以被编码
+		header.IsOk, header.Code, header.Error = false, ERROR_INTERNAL, err.Error()
+		err = r.conn.WriteFrame(header, &Empty{})
+	}
+	if err != nil {
+		sLog.Printf("Send ack failed: %v", err)
+		return err
 	}
 
-	sLog.Printf("Ack body sent to %v, action=%v", r.conn.RemoteAddr(), r.header.Action)
+	sLog.Printf("Ack sent to %v, action=%v, ok=%v", r.RemoteAddr(), r.Action(), header.IsOk)
 	return nil
 }
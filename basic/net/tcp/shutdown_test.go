@@ -0,0 +1,101 @@
+package tcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试优雅的停止服务端, 等待正在处理的请求完成
+func TestShutdown_Graceful(t *testing.T) {
+	server, client := startSleepServer(t)
+
+	// 空闲的连接
+	idle, err := Connect(server.Addr().String())
+	assert.Nil(t, err)
+	defer idle.Close()
+
+	f := client.RequestAsync(ACTION_SLEEP, &SleepAsk{Millis: 200})
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// 等待正在处理的请求完成后, 服务端才停止
+	start := time.Now()
+	assert.Nil(t, server.Shutdown(ctx))
+	assert.Greater(t, time.Since(start), 150*time.Millisecond)
+	assert.Equal(t, 0, server.Conns())
+
+	resp, err := f.Await(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 200, resp.(*SleepAck).Millis)
+
+	// 会话结束后, 客户端连接断开
+	_, err = client.Ping(context.Background())
+	assert.ErrorIs(t, err, ErrConnectionLost)
+
+	// 服务端不再接受新连接
+	_, err = Connect(server.Addr().String())
+	assert.NotNil(t, err)
+}
+
+// 测试等待超时后强制关闭会话
+func TestShutdown_Timeout(t *testing.T) {
+	server, client := startSleepServer(t)
+
+	f := client.RequestAsync(ACTION_SLEEP, &SleepAsk{Millis: 300})
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, server.Shutdown(ctx), context.DeadlineExceeded)
+
+	// 连接被强制关闭, 请求无法得到响应
+	_, err := f.Await(context.Background())
+	assert.ErrorIs(t, err, ErrConnectionLost)
+}
+
+// 测试只有管理员可以远程关闭服务器
+func TestShutdown_Remote(t *testing.T) {
+	server, err := ServerStart("127.0.0.1:0", WithAdmin(func(r *Request) bool {
+		account, _ := r.Get(CTX_ACCOUNT)
+		return account == "admin"
+	}))
+	assert.Nil(t, err)
+	defer server.Close()
+
+	client, err := Connect(server.Addr().String())
+	assert.Nil(t, err)
+	defer client.Close()
+
+	// 未登录或非管理员账号无法关闭服务器
+	_, err = client.Request(ACTION_SHUTDOWN, &ShutdownAsk{})
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = client.Request(ACTION_LOGIN, &LoginAsk{Account: "Alvin"})
+	assert.Nil(t, err)
+
+	_, err = client.Request(ACTION_SHUTDOWN, &ShutdownAsk{})
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	// 服务器仍可正常使用
+	_, err = client.Ping(context.Background())
+	assert.Nil(t, err)
+
+	// 管理员账号关闭服务器
+	_, err = client.Request(ACTION_LOGIN, &LoginAsk{Account: "admin"})
+	assert.Nil(t, err)
+
+	resp, err := client.Request(ACTION_SHUTDOWN, &ShutdownAsk{})
+	assert.Nil(t, err)
+	assert.Equal(t, &ShutdownAck{}, resp)
+
+	assert.Eventually(t, func() bool { return server.Conns() == 0 }, time.Second, 10*time.Millisecond)
+
+	_, err = Connect(server.Addr().String())
+	assert.NotNil(t, err)
+}
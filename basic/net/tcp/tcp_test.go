@@ -24,30 +24,40 @@ func init() {
 }
 
 func TestTCP_Network(t *testing.T) {
-	// 启动服务器
-	server, err := ServerStart(":18888")
-	assert.Nil(t, err)
-	defer server.Close()
-
-	// 连接服务器
-	client, err := Connect("127.0.0.1:18888")
-	assert.Nil(t, err)
-	defer client.Close()
-
-	// 发送登录请求
-	resp, err := client.Request(ACTION_LOGIN, &LoginAsk{
-		Account:  "Alvin",
-		Password: "password",
-	})
-	assert.Nil(t, err)
-
-	// 接收登录响应
-	loginAck, ok := resp.(*LoginAck)
-	assert.True(t, ok)
-	assert.Equal(t, "Hello Alvin", loginAck.Welcome)
-
-	// 发送关闭服务请求
-	resp, err = client.Request(ACTION_SHUTDOWN, &ShutdownAsk{})
-	assert.Nil(t, err)
-	assert.Equal(t, &ShutdownAck{}, resp)
+	// 依次使用每种内置的编解码器
+	for _, codec := range []string{CODEC_GOB, CODEC_JSON, CODEC_BINARY} {
+		t.Run(codec, func(t *testing.T) {
+			// 启动服务器, 登录账号为 Alvin 的会话可以关闭服务器
+			server, err := ServerStart("127.0.0.1:0", WithAdmin(func(r *Request) bool {
+				account, _ := r.Get(CTX_ACCOUNT)
+				return account == "Alvin"
+			}))
+			assert.Nil(t, err)
+			defer server.Close()
+
+			// 连接服务器
+			client, err := Connect(server.Addr().String(), WithCodecs(codec))
+			assert.Nil(t, err)
+			defer client.Close()
+
+			assert.Equal(t, codec, client.conn.Codec().Name())
+
+			// 发送登录请求
+			resp, err := client.Request(ACTION_LOGIN, &LoginAsk{
+				Account:  "Alvin",
+				Password: "password",
+			})
+			assert.Nil(t, err)
+
+			// 接收登录响应
+			loginAck, ok := resp.(*LoginAck)
+			assert.True(t, ok)
+			assert.Equal(t, "Hello Alvin", loginAck.Welcome)
+
+			// 发送关闭服务请求
+			resp, err = client.Request(ACTION_SHUTDOWN, &ShutdownAsk{})
+			assert.Nil(t, err)
+			assert.Equal(t, &ShutdownAck{}, resp)
+		})
+	}
 }
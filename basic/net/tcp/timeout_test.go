@@ -0,0 +1,193 @@
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试服务端回收空闲的会话
+func TestTimeout_IdleReaped(t *testing.T) {
+	server, err := ServerStart("127.0.0.1:0", WithIdleTimeout(50*time.Millisecond))
+	assert.Nil(t, err)
+	defer server.Close()
+
+	client, err := Connect(server.Addr().String())
+	assert.Nil(t, err)
+	defer client.Close()
+
+	_, err = client.Ping(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, server.Conns())
+
+	// 超过空闲时间后, 会话被回收, 客户端连接断开
+	assert.Eventually(t, func() bool { return server.Conns() == 0 }, time.Second, 10*time.Millisecond)
+
+	_, err = client.Ping(context.Background())
+	assert.ErrorIs(t, err, ErrConnectionLost)
+	assert.NotErrorIs(t, err, ErrClientClosed)
+}
+
+// 测试心跳保持连接不被回收
+func TestTimeout_Heartbeat(t *testing.T) {
+	server, err := ServerStart("127.0.0.1:0", WithIdleTimeout(100*time.Millisecond))
+	assert.Nil(t, err)
+	defer server.Close()
+
+	client, err := Connect(server.Addr().String(), WithHeartbeat(20*time.Millisecond))
+	assert.Nil(t, err)
+	defer client.Close()
+
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, 1, server.Conns())
+
+	rtt, err := client.Ping(context.Background())
+	assert.Nil(t, err)
+	assert.Greater(t, rtt, time.Duration(0))
+}
+
+// 测试客户端通过心跳发现不再响应的服务端 (半开连接)
+func TestTimeout_HeartbeatTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+
+	// 服务端完成握手后不再响应任何请求
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		serverHandshake(conn, nil)
+		io.Copy(io.Discard, conn)
+	}()
+
+	client, err := Connect(l.Addr().String(), WithHeartbeat(30*time.Millisecond))
+	assert.Nil(t, err)
+	defer client.Close()
+
+	_, err = client.Request(ACTION_LOGIN, &LoginAsk{Account: "Alvin"})
+	assert.ErrorIs(t, err, ErrConnectionLost)
+	assert.ErrorIs(t, err, ErrHeartbeatTimeout)
+	assert.NotErrorIs(t, err, ErrClientClosed)
+}
+
+// 测试服务端回收发送数据不完整的会话
+func TestTimeout_ReadTimeout(t *testing.T) {
+	server, err := ServerStart("127.0.0.1:0", WithReadTimeout(50*time.Millisecond))
+	assert.Nil(t, err)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	_, err = clientHandshake(conn, []string{CODEC_JSON})
+	assert.Nil(t, err)
+
+	// 只发送帧长度的一部分
+	_, err = conn.Write([]byte{0, 0})
+	assert.Nil(t, err)
+
+	// 服务端超时后关闭连接
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+// 测试发送超时后连接被关闭, 编码失败则不影响连接
+func TestTimeout_WriteTimeout(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+
+	conn := NewTCPConn(local)
+	conn.codec = jsonCodec{}
+	conn.writeTimeout = 50 * time.Millisecond
+
+	// 编码失败, 没有数据被写入, 连接未被关闭
+	err := conn.WriteFrame(&AskHeader{Action: ACTION_PING}, make(chan int))
+	assert.ErrorIs(t, err, ErrEncodeFrame)
+	assert.False(t, conn.IsClosed())
+
+	// 对端不接收数据, 发送超时后连接被关闭
+	err = conn.WriteFrame(&AskHeader{Action: ACTION_PING}, &PingAsk{})
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	assert.True(t, conn.IsClosed())
+
+	_, err = remote.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+// 测试服务端关闭建立连接后不完成握手的对端
+func TestTimeout_HandshakeTimeout(t *testing.T) {
+	ca := newTestCA(t, "test ca")
+	config := &tls.Config{Certificates: []tls.Certificate{ca.issue("server", x509.ExtKeyUsageServerAuth)}}
+
+	for _, tc := range []struct {
+		name  string
+		start func(opts ...ServerOption) (*Server, error)
+		opts  []ServerOption
+	}{
+		// 只设置空闲时间时, 握手同样受空闲时间限制
+		{"idle", func(opts ...ServerOption) (*Server, error) { return ServerStart("127.0.0.1:0", opts...) },
+			[]ServerOption{WithIdleTimeout(50 * time.Millisecond)}},
+		{"idle-tls", func(opts ...ServerOption) (*Server, error) { return ServerStartTLS("127.0.0.1:0", config, opts...) },
+			[]ServerOption{WithIdleTimeout(50 * time.Millisecond)}},
+
+		// 握手超时时间优先于空闲时间
+		{"handshake", func(opts ...ServerOption) (*Server, error) { return ServerStart("127.0.0.1:0", opts...) },
+			[]ServerOption{WithIdleTimeout(time.Hour), WithHandshakeTimeout(50 * time.Millisecond)}},
+		{"handshake-tls", func(opts ...ServerOption) (*Server, error) { return ServerStartTLS("127.0.0.1:0", config, opts...) },
+			[]ServerOption{WithIdleTimeout(time.Hour), WithHandshakeTimeout(50 * time.Millisecond)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server, err := tc.start(tc.opts...)
+			assert.Nil(t, err)
+			defer server.Close()
+
+			// 建立连接后不发送任何数据
+			conn, err := net.Dial("tcp", server.Addr().String())
+			assert.Nil(t, err)
+			defer conn.Close()
+
+			// 握手超时后, 服务端关闭连接
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			_, err = conn.Read(make([]byte, 1))
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+// 测试服务端限制最大连接数量
+func TestTimeout_MaxConns(t *testing.T) {
+	server, err := ServerStart("127.0.0.1:0", WithMaxConns(1))
+	assert.Nil(t, err)
+	defer server.Close()
+
+	client, err := Connect(server.Addr().String())
+	assert.Nil(t, err)
+
+	// 连接数量达到上限, 新连接被拒绝
+	_, err = Connect(server.Addr().String())
+	assert.NotNil(t, err)
+
+	// 连接断开后, 可以建立新连接
+	client.Close()
+	assert.Eventually(t, func() bool { return server.Conns() == 0 }, time.Second, 10*time.Millisecond)
+
+	client, err = Connect(server.Addr().String())
+	assert.Nil(t, err)
+	defer client.Close()
+
+	_, err = client.Ping(context.Background())
+	assert.Nil(t, err)
+}
@@ -0,0 +1,152 @@
+package tcp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试使用的内存 CA, 用于签发服务端和客户端证书
+type testCA struct {
+	t      *testing.T
+	cert   *x509.Certificate
+	key    *ecdsa.PrivateKey
+	serial int64
+}
+
+// 创建自签名的 CA
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.Nil(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+
+	return &testCA{t: t, cert: cert, key: key, serial: 1}
+}
+
+// 获取包含 CA 证书的证书池
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// 签发证书, `usage` 参数表示证书用于服务端或客户端
+func (ca *testCA) issue(name string, usage x509.ExtKeyUsage) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(ca.t, err)
+
+	ca.serial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(ca.serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	assert.Nil(ca.t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// 测试通过 TLS 连接服务端
+func TestTLS_Connect(t *testing.T) {
+	ca := newTestCA(t, "test ca")
+
+	server, err := ServerStartTLS("127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{ca.issue("server", x509.ExtKeyUsageServerAuth)},
+	})
+	assert.Nil(t, err)
+	defer server.Close()
+
+	client, err := ConnectTLS(server.Addr().String(), &tls.Config{RootCAs: ca.pool()})
+	assert.Nil(t, err)
+	defer client.Close()
+
+	resp, err := client.Request(ACTION_LOGIN, &LoginAsk{Account: "Alvin", Password: "password"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello Alvin", resp.(*LoginAck).Welcome)
+
+	// 服务端证书不是由信任的 CA 签发
+	_, err = ConnectTLS(server.Addr().String(), &tls.Config{RootCAs: newTestCA(t, "other ca").pool()})
+	assert.NotNil(t, err)
+
+	// 使用明文连接 TLS 服务端
+	_, err = Connect(server.Addr().String(), WithReadTimeout(time.Second))
+	assert.NotNil(t, err)
+
+	// 未提供 TLS 配置
+	_, err = ServerStartTLS("127.0.0.1:0", nil)
+	assert.ErrorIs(t, err, ErrNoTLSConfig)
+}
+
+// 测试双向 TLS, 服务端校验客户端证书, 并通过会话上下文获取客户端身份
+func TestTLS_Mutual(t *testing.T) {
+	ca := newTestCA(t, "test ca")
+
+	server, err := ServerStartTLS("127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{ca.issue("server", x509.ExtKeyUsageServerAuth)},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    ca.pool(),
+	})
+	assert.Nil(t, err)
+	defer server.Close()
+
+	// 返回客户端证书中的名称
+	server.Handle(ACTION_ECHO, Typed(func(ctx context.Context, ask *EchoAsk) (*EchoAck, error) {
+		peer := RequestFrom(ctx).Peer()
+		if peer == nil {
+			return nil, NewError(ERROR_UNAUTHORIZED, "no peer certificate")
+		}
+		return &EchoAck{Text: ask.Text, Account: peer.Subject.CommonName}, nil
+	}))
+
+	client, err := ConnectTLS(server.Addr().String(), &tls.Config{
+		RootCAs:      ca.pool(),
+		Certificates: []tls.Certificate{ca.issue("alice", x509.ExtKeyUsageClientAuth)},
+	})
+	assert.Nil(t, err)
+	defer client.Close()
+
+	ack, err := Call[EchoAsk, EchoAck](client, ACTION_ECHO, &EchoAsk{Text: "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", ack.Account)
+
+	// 客户端未提供证书, 连接失败
+	//
+	// TLS 1.3 中客户端握手先于服务端校验客户端证书完成, 所以错误可能在之后的读写中才出现
+	client, err = ConnectTLS(server.Addr().String(), &tls.Config{RootCAs: ca.pool()})
+	if err == nil {
+		defer client.Close()
+		_, err = client.Ping(context.Background())
+	}
+	assert.NotNil(t, err)
+}